@@ -0,0 +1,245 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolate — окружение без параметров конфигурации и файл file в CONFIG_FILE ("" — без файла).
+func isolate(t *testing.T, file string) {
+	t.Helper()
+	l, _ := newLoaded()
+	for _, f := range l.fields {
+		t.Setenv(f.env, "") // пустые значения окружения Load пропускает
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if file != "" {
+		if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CONFIG_FILE", "")
+	if file != "" {
+		t.Setenv("CONFIG_FILE", path)
+	}
+}
+
+// valid — минимальный файл, с которым конфигурация проходит проверку.
+const valid = `
+demo: true
+ai:
+  provider: fake
+`
+
+func TestLoadPrecedence(t *testing.T) {
+	isolate(t, `
+demo: true
+server:
+  port: 8081
+  shutdown_timeout: 20s
+ai:
+  provider: fake
+  model: from-file
+  temperature: 0.5
+log:
+  level: warn
+`)
+	t.Setenv("PORT", "8082")
+	t.Setenv("AI_MODEL", "from-env")
+	t.Setenv("AI_TEMPERATURE", "0.7")
+
+	c, err := Load([]string{"-server.port=8083", "-ai.temperature=0.9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		what      string
+		got, want any
+	}{
+		{"flag over env and file", c.PORT, "8083"},
+		{"flag over env (float)", c.AITemperature, 0.9},
+		{"env over file", c.AIModel, "from-env"},
+		{"file over default", c.LogLevel, "warn"},
+		{"file over default (duration)", c.ShutdownTimeout, 20 * time.Second},
+		{"default", c.ReadHeaderTimeout, 5 * time.Second},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: %v, want %v", tc.what, tc.got, tc.want)
+		}
+	}
+}
+
+func TestLoadDatabaseURLFromParts(t *testing.T) {
+	isolate(t, `
+ai:
+  provider: fake
+database:
+  host: db
+  user: app
+  password: p@ss
+  name: market
+  sslmode: disable
+`)
+	c, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "postgres://app:p%40ss@db:5432/market?sslmode=disable"; c.DatabaseURL != want {
+		t.Errorf("DatabaseURL = %q, want %q", c.DatabaseURL, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		want []string // подстроки ошибки; все нарушения — в одной ошибке
+	}{
+		{
+			name: "unknown file keys",
+			file: valid + "server:\n  prot: 8080\ncache:\n  size: 1\n",
+			want: []string{`unknown key "server.prot"`, `unknown key "cache.size"`},
+		},
+		{
+			name: "bad values from file and env together",
+			file: valid + "server:\n  shutdown_timeout: soon\n",
+			env:  map[string]string{"DB_MAX_CONNS": "many"},
+			want: []string{`server.shutdown_timeout="soon": not a valid duration`, `DB_MAX_CONNS="many"`},
+		},
+		{
+			name: "bad flag value",
+			file: valid,
+			args: []string{"-ai.temperature=hot"},
+			want: []string{"-ai.temperature"},
+		},
+		{
+			name: "bad env value",
+			file: valid,
+			env:  map[string]string{"DB_MAX_CONNS": "many", "HEALTH_CHECK_EXTERNAL": "maybe"},
+			want: []string{`DB_MAX_CONNS="many": not a valid integer`, `HEALTH_CHECK_EXTERNAL="maybe": not a valid boolean`},
+		},
+		{
+			name: "checks are aggregated",
+			file: valid,
+			env:  map[string]string{"PORT": "99999", "AI_PROVIDER": "gpt", "TRACING_SAMPLE_RATIO": "2", "LOG_LEVEL": "loud"},
+			want: []string{"server.port:", "ai.provider:", "tracing.sample_ratio:", "log.level:"},
+		},
+		{
+			name: "no database outside demo",
+			file: "ai:\n  provider: fake\n",
+			want: []string{"database.url: is not set"},
+		},
+		{
+			name: "provider requirements",
+			file: "demo: true\n",
+			want: []string{"ai.openai_key: is required for provider openai"},
+		},
+		{
+			name: "jwt secret in production",
+			file: valid,
+			env:  map[string]string{"APP_ENV": "production"},
+			want: []string{"auth.jwt_secret: is required in production"},
+		},
+		{
+			name: "unknown environment",
+			file: valid,
+			env:  map[string]string{"APP_ENV": "prod", "JWT_SECRET": "s"},
+			want: []string{`server.env: "prod" is not one of development, production`},
+		},
+		{
+			name: "metrics on the API port",
+			file: valid,
+			env:  map[string]string{"METRICS_ADDR": ":8080"},
+			want: []string{"metrics.addr:"},
+		},
+		{
+			name: "missing explicit file",
+			args: []string{"-config=/nonexistent/config.yaml"},
+			want: []string{"config file:"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			isolate(t, tc.file)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load(tc.args)
+			if err == nil {
+				t.Fatal("Load = nil error")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadProductionWithSecret(t *testing.T) {
+	isolate(t, valid)
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("JWT_SECRET", "s3cret")
+	if _, err := Load(nil); err != nil {
+		t.Fatalf("production with JWT_SECRET: %v", err)
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	isolate(t, valid)
+	secrets := map[string]string{
+		"JWT_SECRET":     "jwt-s3cret",
+		"CURSOR_SECRET":  "cursor-s3cret",
+		"OPENAI_API_KEY": "sk-s3cret",
+		"DATABASE_URL":   "postgres://app:db-s3cret@db:5432/market",
+	}
+	for k, v := range secrets {
+		t.Setenv(k, v)
+	}
+	l, err := LoadLayers(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := l.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{"jwt-s3cret", "cursor-s3cret", "sk-s3cret", "db-s3cret"} {
+		if strings.Contains(out, s) {
+			t.Errorf("config print leaks %q:\n%s", s, out)
+		}
+	}
+	for _, want := range []string{"jwt_secret: '***'", "openai_key: '***'", "url: postgres://app:xxxxx@db:5432/market", "local_key: \"\"", "provider: fake"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("config print has no %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintIsLoadable(t *testing.T) {
+	isolate(t, valid+"server:\n  port: 9000\n")
+	l, err := LoadLayers(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := l.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	isolate(t, buf.String()) // вывод — валидный config.yaml без неизвестных ключей
+	c, err := Load(nil)
+	if err != nil {
+		t.Fatalf("loading config print output: %v\n%s", err, buf.String())
+	}
+	if c != l.Config {
+		t.Errorf("round trip changed the config:\n%+v\n%+v", c, l.Config)
+	}
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"flag"
+	"time"
+)
+
+// field — параметр конфигурации: ключ в YAML (он же имя флага) и переменная окружения.
+type field struct {
+	key    string
+	env    string
+	secret bool // в `config print` — "***"
+}
+
+// binder — регистрирует параметры во FlagSet: флаг хранит значение по умолчанию, умеет разобрать
+// строку своего типа (Set) — через него же применяются значения из файла и окружения.
+type binder struct {
+	fs     *flag.FlagSet
+	fields []field
+}
+
+func (b *binder) add(key, env string, secret bool) {
+	b.fields = append(b.fields, field{key: key, env: env, secret: secret})
+}
+
+func (b *binder) str(p *string, key, env, def, usage string) {
+	b.fs.StringVar(p, key, def, usage+" ("+env+")")
+	b.add(key, env, false)
+}
+
+func (b *binder) secret(p *string, key, env, usage string) {
+	b.fs.StringVar(p, key, "", usage+" ("+env+")")
+	b.add(key, env, true)
+}
+
+func (b *binder) int(p *int, key, env string, def int, usage string) {
+	b.fs.IntVar(p, key, def, usage+" ("+env+")")
+	b.add(key, env, false)
+}
+
+func (b *binder) float(p *float64, key, env string, def float64, usage string) {
+	b.fs.Float64Var(p, key, def, usage+" ("+env+")")
+	b.add(key, env, false)
+}
+
+func (b *binder) bool(p *bool, key, env string, def bool, usage string) {
+	b.fs.BoolVar(p, key, def, usage+" ("+env+")")
+	b.add(key, env, false)
+}
+
+func (b *binder) duration(p *time.Duration, key, env string, def time.Duration, usage string) {
+	b.fs.DurationVar(p, key, def, usage+" ("+env+")")
+	b.add(key, env, false)
+}
+
+// bind — таблица всех параметров: ключ, переменная окружения, значение по умолчанию.
+// Порядок — порядок вывода `config print`.
+func bind(fs *flag.FlagSet, c *EnvConfig) []field {
+	b := &binder{fs: fs}
+
+	b.bool(&c.Demo, "demo", "DEMO", false, "демо-режим: данные в памяти из фикстур, без Postgres")
+	b.str(&c.DemoFixtures, "demo_fixtures", "DEMO_FIXTURES", "", "JSON с данными демо-режима (пусто — встроенный каталог)")
+
+	b.str(&c.AppEnv, "server.env", "APP_ENV", "development", "окружение: development | production")
+	b.str(&c.PORT, "server.port", "PORT", "8080", "порт HTTP-сервера")
+	b.duration(&c.ReadHeaderTimeout, "server.read_header_timeout", "READ_HEADER_TIMEOUT", 5*time.Second, "таймаут чтения заголовков запроса")
+	b.duration(&c.ShutdownTimeout, "server.shutdown_timeout", "SHUTDOWN_TIMEOUT", 10*time.Second, "сколько ждать текущих запросов при остановке")
+	b.duration(&c.ShutdownDrainDelay, "server.shutdown_drain_delay", "SHUTDOWN_DRAIN_DELAY", 5*time.Second, "пауза между drain (/readyz → 503) и остановкой")
+	b.str(&c.AssetsBaseURL, "server.assets_base_url", "ASSETS_BASE_URL", "/static", "путь или CDN-адрес статики")
+	b.str(&c.TemplatesDir, "server.templates_dir", "TEMPLATES_DIR", "", "dev: каталог шаблонов с перезагрузкой")
+	b.bool(&c.TrustProxy, "server.trust_proxy", "TRUST_PROXY", false, "доверять последнему адресу X-Forwarded-For")
+
+	b.secret(&c.DatabaseURL, "database.url", "DATABASE_URL", "DSN Postgres (важнее частей host/port/…)")
+	b.str(&c.DatabaseHost, "database.host", "POSTGRES_HOST", "", "хост Postgres")
+	b.int(&c.DatabasePort, "database.port", "POSTGRES_PORT", 5432, "порт Postgres")
+	b.str(&c.DatabaseUser, "database.user", "POSTGRES_USER", "", "пользователь Postgres")
+	b.secret(&c.DatabasePassword, "database.password", "POSTGRES_PASSWORD", "пароль Postgres")
+	b.str(&c.DatabaseName, "database.name", "POSTGRES_DB", "", "имя базы")
+	b.str(&c.DatabaseSSLMode, "database.sslmode", "POSTGRES_SSLMODE", "", "sslmode")
+	b.int(&c.DBMaxConns, "database.max_conns", "DB_MAX_CONNS", 10, "размер пула соединений")
+	b.int(&c.DBMinConns, "database.min_conns", "DB_MIN_CONNS", 0, "минимум открытых соединений")
+	b.duration(&c.DBMaxConnLifetime, "database.max_conn_lifetime", "DB_MAX_CONN_LIFETIME", 55*time.Minute, "время жизни соединения")
+	b.duration(&c.DBMaxConnIdleTime, "database.max_conn_idle_time", "DB_MAX_CONN_IDLE_TIME", 10*time.Minute, "простой соединения до закрытия")
+	b.duration(&c.DBHealthCheckPeriod, "database.health_check_period", "DB_HEALTH_CHECK_PERIOD", 30*time.Second, "период проверки соединений пула")
+	b.duration(&c.DBConnectTimeout, "database.connect_timeout", "DB_CONNECT_TIMEOUT", 3*time.Second, "таймаут подключения при старте")
+	b.duration(&c.DBSlowQuery, "database.slow_query", "DB_SLOW_QUERY", 500*time.Millisecond, "порог медленного запроса (WARN в логе)")
+
+	b.str(&c.AIProvider, "ai.provider", "AI_PROVIDER", "openai", "LLM-провайдер: openai | local | fake (без сети, ответы из сценария)")
+	b.str(&c.AIModel, "ai.model", "AI_MODEL", "gpt-4o-mini", "модель")
+	b.float(&c.AITemperature, "ai.temperature", "AI_TEMPERATURE", 0.2, "temperature (0..2)")
+	b.duration(&c.AITimeout, "ai.timeout", "AI_TIMEOUT", 20*time.Second, "таймаут вызова LLM")
+	b.secret(&c.OpenAIKey, "ai.openai_key", "OPENAI_API_KEY", "ключ OpenAI")
+	b.str(&c.LocalAIURL, "ai.local_url", "LOCAL_AI_URL", "", "адрес локального LLM API")
+	b.secret(&c.LocalAIKey, "ai.local_key", "LOCAL_AI_KEY", "ключ локального LLM API")
+	b.str(&c.AIFakeScript, "ai.fake_script", "AI_FAKE_SCRIPT", "", "JSON-сценарий провайдера fake: правила и записанные обмены (пусто — встроенный)")
+	b.str(&c.AIRecord, "ai.record", "AI_RECORD", "", "записывать обмены с openai/local в этот файл (сценарий для fake)")
+	b.int(&c.AIDailyTokens, "ai.daily_tokens", "AI_DAILY_TOKENS", 100000, "суточная квота токенов на пользователя (0 — без лимита)")
+	b.int(&c.AIDailyTokensAnon, "ai.daily_tokens_anon", "AI_DAILY_TOKENS_ANON", 20000, "суточная квота токенов анонима")
+
+	b.str(&c.N8NBuyerWebhookURL, "n8n.buyer_webhook_url", "N8N_BUYER_ASSISTANT_WEBHOOK_URL", "", "webhook ассистента покупателя")
+	b.str(&c.N8NSellerWebhookURL, "n8n.seller_webhook_url", "N8N_SELLER_ASSISTANT_WEBHOOK_URL", "", "webhook ассистента продавца")
+	b.duration(&c.N8NTimeout, "n8n.timeout", "N8N_TIMEOUT", 30*time.Second, "таймаут вызова webhook-а")
+
+	b.str(&c.DisplayCurrency, "currency.display", "DISPLAY_CURRENCY", "KGS", "валюта отображения цен")
+	b.str(&c.ExchangeRatesFile, "currency.rates_file", "EXCHANGE_RATES_FILE", "", "CSV с курсами")
+	b.duration(&c.ExchangeRatesRefresh, "currency.rates_refresh", "EXCHANGE_RATES_REFRESH", time.Hour, "период обновления курсов")
+
+	b.duration(&c.SavedSearchInterval, "alerts.saved_search_interval", "SAVED_SEARCH_INTERVAL", 5*time.Minute, "период прогона сохранённых поисков")
+	b.duration(&c.ChatSessionTTL, "chat.session_ttl", "CHAT_SESSION_TTL", 7*24*time.Hour, "срок жизни сессии чата")
+
+	b.str(&c.RateLimitStore, "ratelimit.store", "RATE_LIMIT_STORE", "memory", "хранилище лимитов: memory | postgres")
+	b.int(&c.RateLimitPerMinute, "ratelimit.per_minute", "RATE_LIMIT_PER_MINUTE", 20, "запросов в минуту")
+	b.int(&c.RateLimitBurst, "ratelimit.burst", "RATE_LIMIT_BURST", 5, "допустимый всплеск")
+
+	b.str(&c.RealtimeBroker, "realtime.broker", "REALTIME_BROKER", "postgres", "брокер событий: postgres | memory")
+
+	b.secret(&c.JWTSecret, "auth.jwt_secret", "JWT_SECRET", "HS256-секрет Bearer-токенов")
+	b.secret(&c.CursorSecret, "auth.cursor_secret", "CURSOR_SECRET", "ключ подписи курсоров пагинации")
+
+	b.bool(&c.HealthCheckExternal, "health.check_external", "HEALTH_CHECK_EXTERNAL", false, "/readyz проверяет LLM и n8n")
+	b.duration(&c.HealthCacheTTL, "health.cache_ttl", "HEALTH_CACHE_TTL", 30*time.Second, "кэш проверок внешних сервисов")
+
+	b.str(&c.MetricsAddr, "metrics.addr", "METRICS_ADDR", "127.0.0.1:9090", "адрес /metrics (пусто — выключено)")
+
+	b.str(&c.TracingExporter, "tracing.exporter", "TRACING_EXPORTER", "none", "экспорт трейсов: otlp | stdout | none")
+	b.float(&c.TracingSampleRatio, "tracing.sample_ratio", "TRACING_SAMPLE_RATIO", 1, "доля новых трейсов (0..1)")
+
+	b.str(&c.LogLevel, "log.level", "LOG_LEVEL", "info", "уровень логов: debug | info | warn | error")
+	b.bool(&c.DebugMode, "log.debug", "DEBUG", false, "уровень debug и все SQL-запросы в логе")
+
+	return b.fields
+}
+
+// lookup — параметр по ключу.
+func (l *Loaded) lookup(key string) *field {
+	for i := range l.fields {
+		if l.fields[i].key == key {
+			return &l.fields[i]
+		}
+	}
+	return nil
+}
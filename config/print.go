@@ -0,0 +1,64 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// redacted — замена секретов в `config print`.
+const redacted = "***"
+
+// Print — итоговая конфигурация в формате config.yaml (её можно сохранить и использовать как файл).
+// Секреты заменены на "***", пароль в database.url — тоже.
+func (l *Loaded) Print(w io.Writer) error {
+	var doc yaml.MapSlice
+	for _, f := range l.fields {
+		section, name, nested := strings.Cut(f.key, ".")
+		if !nested { // параметр верхнего уровня (demo)
+			doc = append(doc, yaml.MapItem{Key: f.key, Value: l.value(f)})
+			continue
+		}
+		if len(doc) == 0 || doc[len(doc)-1].Key != section {
+			doc = append(doc, yaml.MapItem{Key: section, Value: yaml.MapSlice{}})
+		}
+		last := &doc[len(doc)-1]
+		last.Value = append(last.Value.(yaml.MapSlice), yaml.MapItem{Key: name, Value: l.value(f)})
+	}
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// value — значение параметра для вывода: длительности строкой ("30s"), секреты скрыты.
+func (l *Loaded) value(f field) any {
+	v := l.fs.Lookup(f.key).Value.(flag.Getter).Get()
+	switch v := v.(type) {
+	case time.Duration:
+		return v.String()
+	case string:
+		if f.key == "database.url" {
+			if u, err := url.Parse(v); err == nil && u.User != nil {
+				return u.Redacted()
+			}
+		}
+		if f.secret && v != "" {
+			return redacted
+		}
+	}
+	return v
+}
+
+// PrintDefaults — список флагов с переменными окружения и значениями по умолчанию (для -h).
+func PrintDefaults(w io.Writer) {
+	l, _ := newLoaded()
+	l.fs.SetOutput(w)
+	l.fs.PrintDefaults()
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validate — проверка собранной конфигурации; возвращает все нарушения, а не первое.
+func (c *EnvConfig) validate() []error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+	}
+	oneOf := func(key, v string, allowed ...string) {
+		for _, a := range allowed {
+			if v == a {
+				return
+			}
+		}
+		fail(key, "%q is not one of %s", v, strings.Join(allowed, ", "))
+	}
+	positive := func(key string, d time.Duration) {
+		if d <= 0 {
+			fail(key, "must be positive, got %s", d)
+		}
+	}
+	absURL := func(key, v string) {
+		if v == "" {
+			return
+		}
+		if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail(key, "%q is not an http(s) URL", v)
+		}
+	}
+
+	oneOf("server.env", c.AppEnv, "development", "production")
+	if p, err := strconv.Atoi(c.PORT); err != nil || p < 1 || p > 65535 {
+		fail("server.port", "%q is not a TCP port", c.PORT)
+	}
+	positive("server.read_header_timeout", c.ReadHeaderTimeout)
+	positive("server.shutdown_timeout", c.ShutdownTimeout)
+	if c.ShutdownDrainDelay < 0 {
+		fail("server.shutdown_drain_delay", "must not be negative")
+	}
+	if !strings.HasPrefix(c.AssetsBaseURL, "/") {
+		absURL("server.assets_base_url", c.AssetsBaseURL)
+	}
+
+	if c.DemoFixtures != "" && !c.Demo {
+		fail("demo_fixtures", "is set, but demo mode is off")
+	}
+	// в демо-режиме база не нужна; если URL всё же задан — он должен быть правильным
+	if c.DatabaseURL == "" {
+		if !c.Demo {
+			fail("database.url", "is not set (DATABASE_URL or database.host)")
+		}
+	} else if u, err := url.Parse(c.DatabaseURL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		fail("database.url", "must be a postgres:// URL")
+	}
+	if c.DBMaxConns < 1 {
+		fail("database.max_conns", "must be at least 1, got %d", c.DBMaxConns)
+	}
+	if c.DBMinConns < 0 || c.DBMinConns > c.DBMaxConns {
+		fail("database.min_conns", "must be within 0..max_conns, got %d", c.DBMinConns)
+	}
+	positive("database.max_conn_lifetime", c.DBMaxConnLifetime)
+	positive("database.max_conn_idle_time", c.DBMaxConnIdleTime)
+	positive("database.health_check_period", c.DBHealthCheckPeriod)
+	positive("database.connect_timeout", c.DBConnectTimeout)
+	positive("database.slow_query", c.DBSlowQuery)
+
+	oneOf("ai.provider", c.AIProvider, "openai", "local", "fake")
+	switch c.AIProvider {
+	case "openai":
+		if c.OpenAIKey == "" {
+			fail("ai.openai_key", "is required for provider openai (OPENAI_API_KEY)")
+		}
+	case "local":
+		if c.LocalAIURL == "" {
+			fail("ai.local_url", "is required for provider local (LOCAL_AI_URL)")
+		}
+		absURL("ai.local_url", c.LocalAIURL)
+	}
+	if c.AIFakeScript != "" && c.AIProvider != "fake" {
+		fail("ai.fake_script", "is set, but provider is %q, not fake", c.AIProvider)
+	}
+	if c.AIRecord != "" && c.AIProvider == "fake" {
+		fail("ai.record", "records a real provider; with fake there is nothing to record")
+	}
+	if c.AIModel == "" {
+		fail("ai.model", "is required")
+	}
+	if c.AITemperature < 0 || c.AITemperature > 2 {
+		fail("ai.temperature", "must be within 0..2, got %g", c.AITemperature)
+	}
+	positive("ai.timeout", c.AITimeout)
+	if c.AIDailyTokens < 0 {
+		fail("ai.daily_tokens", "must not be negative")
+	}
+	if c.AIDailyTokensAnon < 0 {
+		fail("ai.daily_tokens_anon", "must not be negative")
+	}
+
+	absURL("n8n.buyer_webhook_url", c.N8NBuyerWebhookURL)
+	absURL("n8n.seller_webhook_url", c.N8NSellerWebhookURL)
+	positive("n8n.timeout", c.N8NTimeout)
+
+	if len(c.DisplayCurrency) != 3 {
+		fail("currency.display", "%q is not an ISO 4217 code", c.DisplayCurrency)
+	}
+	positive("currency.rates_refresh", c.ExchangeRatesRefresh)
+	positive("alerts.saved_search_interval", c.SavedSearchInterval)
+	positive("chat.session_ttl", c.ChatSessionTTL)
+
+	oneOf("ratelimit.store", c.RateLimitStore, "memory", "postgres")
+	if c.RateLimitPerMinute < 1 {
+		fail("ratelimit.per_minute", "must be at least 1, got %d", c.RateLimitPerMinute)
+	}
+	if c.RateLimitBurst < 0 {
+		fail("ratelimit.burst", "must not be negative")
+	}
+	oneOf("realtime.broker", c.RealtimeBroker, "postgres", "memory")
+
+	// без секрета любой Bearer отклоняется, и все запросы анонимны — в production это поломка, а не режим
+	if c.JWTSecret == "" && c.AppEnv == "production" {
+		fail("auth.jwt_secret", "is required in production (JWT_SECRET)")
+	}
+
+	if c.HealthCacheTTL < 0 {
+		fail("health.cache_ttl", "must not be negative")
+	}
+	if c.MetricsAddr != "" {
+		if _, port, err := net.SplitHostPort(c.MetricsAddr); err != nil || port == "" || port == c.PORT {
+			fail("metrics.addr", "%q must be host:port other than the API port", c.MetricsAddr)
+		}
+	}
+	oneOf("tracing.exporter", c.TracingExporter, "otlp", "stdout", "none")
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		fail("tracing.sample_ratio", "must be within 0..1, got %g", c.TracingSampleRatio)
+	}
+	oneOf("log.level", strings.ToLower(c.LogLevel), "debug", "info", "warn", "warning", "error")
+	return errs
+}
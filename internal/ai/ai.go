@@ -1,14 +1,38 @@
 package ai
 
-import "context"
+import (
+	"context"
+	"unicode/utf8"
+)
 
 // Message — единый формат сообщений для любых LLM.
 type Message struct {
-	Role    string // "system" | "user" | "assistant" | "tool"
-	Content string
+	Role    string `json:"role"` // "system" | "user" | "assistant" | "tool"
+	Content string `json:"content"`
 }
 
 // Client — абстракция поверх любого поставщика LLM.
 type Client interface {
 	Chat(ctx context.Context, model string, temperature float64, messages []Message) (string, error)
 }
+
+// Usage — расход токенов на один вызов Chat.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// UsageFunc — хук учёта расхода (квоты, метрики). ctx — контекст вызова Chat.
+type UsageFunc func(ctx context.Context, model string, u Usage)
+
+// EstimateUsage — грубая оценка (~4 символа на токен) для API, не сообщающих usage.
+func EstimateUsage(messages []Message, reply string) Usage {
+	var prompt int
+	for _, m := range messages {
+		prompt += utf8.RuneCountInString(m.Content)
+	}
+	u := Usage{PromptTokens: (prompt + 3) / 4, CompletionTokens: (utf8.RuneCountInString(reply) + 3) / 4}
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	return u
+}
@@ -0,0 +1,197 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrNoReply — у FakeClient нет ответа на запрос: ни записанного обмена, ни правила, ни Default.
+var ErrNoReply = errors.New("ai: fake has no reply")
+
+// Script — сценарий FakeClient (JSON-файл AI_FAKE_SCRIPT; его же пишет Recorder).
+// На запрос сначала ищется записанный обмен с теми же сообщениями, затем первое подходящее правило,
+// затем Default.
+type Script struct {
+	Exchanges []Exchange `json:"exchanges,omitempty"`
+	Rules     []Rule     `json:"rules,omitempty"`
+	Default   string     `json:"default,omitempty"` // пусто — ErrNoReply
+}
+
+// Exchange — записанный обмен с провайдером (кассета). Совпадение — по сообщениям, модель и
+// temperature не учитываются; одинаковые запросы получают записанные ответы по очереди,
+// последний повторяется.
+type Exchange struct {
+	Model    string    `json:"model,omitempty"`
+	Messages []Message `json:"messages"`
+	Reply    string    `json:"reply"`
+}
+
+// Rule — ответ по регулярным выражениям.
+type Rule struct {
+	System string `json:"system,omitempty"` // по system-сообщениям запроса (пусто — любые)
+	Match  string `json:"match,omitempty"`  // по последнему сообщению user (пусто — любое)
+	Reply  string `json:"reply,omitempty"`  // шаблон: $0 — весь текст, $1, ${name} — группы Match
+	Error  string `json:"error,omitempty"`  // вместо ответа — ошибка (сбой провайдера)
+}
+
+//go:embed fake_demo.json
+var demoScript []byte
+
+// DemoScript — встроенный сценарий демо-режима: намерения по ключевым словам, остальное — эхо.
+func DemoScript() (Script, error) {
+	return ParseScript(demoScript)
+}
+
+// LoadScript — сценарий из JSON-файла.
+func LoadScript(path string) (Script, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Script{}, err
+	}
+	s, err := ParseScript(b)
+	if err != nil {
+		return Script{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ParseScript — сценарий из JSON; неизвестные поля — ошибка.
+func ParseScript(b []byte) (Script, error) {
+	var s Script
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return Script{}, fmt.Errorf("ai script: %w", err)
+	}
+	return s, nil
+}
+
+// FakeClient — Client без сети: ответы из Script. Безопасен для конкурентных вызовов;
+// все вызовы запоминаются (Calls) для проверок в тестах.
+type FakeClient struct {
+	// OnUsage — вызывается после каждого ответа с оценкой EstimateUsage (опционально).
+	OnUsage UsageFunc
+
+	rules    []fakeRule
+	def      string
+	replies  map[string][]string // ключ сообщений → записанные ответы
+	mu       sync.Mutex
+	replayed map[string]int
+	calls    []Exchange
+}
+
+type fakeRule struct {
+	system, match *regexp.Regexp
+	Rule
+}
+
+// NewFake — клиент по сценарию s; ошибка — некорректное регулярное выражение в правиле.
+func NewFake(s Script) (*FakeClient, error) {
+	c := &FakeClient{def: s.Default, replies: map[string][]string{}, replayed: map[string]int{}}
+	for _, e := range s.Exchanges {
+		k := exchangeKey(e.Messages)
+		c.replies[k] = append(c.replies[k], e.Reply)
+	}
+	for i, r := range s.Rules {
+		fr := fakeRule{Rule: r}
+		var err error
+		if r.System != "" {
+			if fr.system, err = regexp.Compile(r.System); err != nil {
+				return nil, fmt.Errorf("ai script: rule %d: system: %w", i, err)
+			}
+		}
+		match := r.Match
+		if match == "" {
+			match = `(?s).*`
+		}
+		if fr.match, err = regexp.Compile(match); err != nil {
+			return nil, fmt.Errorf("ai script: rule %d: match: %w", i, err)
+		}
+		c.rules = append(c.rules, fr)
+	}
+	return c, nil
+}
+
+func (c *FakeClient) Chat(ctx context.Context, model string, _ float64, messages []Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	reply, err := c.reply(messages)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.calls = append(c.calls, Exchange{Model: model, Messages: append([]Message(nil), messages...), Reply: reply})
+	c.mu.Unlock()
+	if c.OnUsage != nil {
+		c.OnUsage(ctx, model, EstimateUsage(messages, reply))
+	}
+	return reply, nil
+}
+
+// Calls — копия всех успешных вызовов Chat по порядку.
+func (c *FakeClient) Calls() []Exchange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Exchange(nil), c.calls...)
+}
+
+func (c *FakeClient) reply(messages []Message) (string, error) {
+	k := exchangeKey(messages)
+	if rs := c.replies[k]; len(rs) > 0 {
+		c.mu.Lock()
+		n := c.replayed[k]
+		c.replayed[k]++
+		c.mu.Unlock()
+		return rs[min(n, len(rs)-1)], nil
+	}
+
+	var system []string
+	user := ""
+	for _, m := range messages {
+		switch m.Role {
+		case "system":
+			system = append(system, m.Content)
+		case "user":
+			user = m.Content
+		}
+	}
+	for _, r := range c.rules {
+		if r.system != nil && !r.system.MatchString(strings.Join(system, "\n")) {
+			continue
+		}
+		idx := r.match.FindStringSubmatchIndex(user)
+		if idx == nil {
+			continue
+		}
+		if r.Error != "" {
+			return "", errors.New(r.Error)
+		}
+		return string(r.match.ExpandString(nil, r.Reply, user, idx)), nil
+	}
+	if c.def != "" {
+		return c.def, nil
+	}
+	return "", fmt.Errorf("%w to %q", ErrNoReply, truncate(user, 80))
+}
+
+// exchangeKey — сообщения запроса одной строкой (ключ поиска записанного обмена).
+func exchangeKey(messages []Message) string {
+	b, _ := json.Marshal(messages)
+	return string(b)
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
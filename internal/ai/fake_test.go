@@ -0,0 +1,182 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btynybekov/marketplace/internal/ai"
+)
+
+var ctx = context.Background()
+
+func newFake(t *testing.T, s ai.Script) *ai.FakeClient {
+	t.Helper()
+	c, err := ai.NewFake(s)
+	if err != nil {
+		t.Fatalf("NewFake: %v", err)
+	}
+	return c
+}
+
+func ask(t *testing.T, c ai.Client, messages ...ai.Message) string {
+	t.Helper()
+	reply, err := c.Chat(ctx, "test-model", 0, messages)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	return reply
+}
+
+func user(text string) ai.Message   { return ai.Message{Role: "user", Content: text} }
+func system(text string) ai.Message { return ai.Message{Role: "system", Content: text} }
+
+func TestFakeRules(t *testing.T) {
+	c := newFake(t, ai.Script{Rules: []ai.Rule{
+		{System: "классификатор", Match: `(?i)куп`, Reply: "buy"},
+		{System: "классификатор", Reply: "chitchat"},
+		{Match: `цена (?P<n>\d+)`, Reply: "дорого: ${n}"},
+		{Match: `упади`, Error: "provider is down"},
+	}})
+
+	for _, tc := range []struct {
+		messages []ai.Message
+		want     string
+	}{
+		{[]ai.Message{system("Ты классификатор."), user("Хочу КУПИТЬ айфон")}, "buy"},
+		{[]ai.Message{system("Ты классификатор."), user("привет")}, "chitchat"},
+		{[]ai.Message{system("Ты помощник."), user("старое"), ai.Message{Role: "assistant", Content: "?"}, user("цена 500")}, "дорого: 500"},
+	} {
+		if got := ask(t, c, tc.messages...); got != tc.want {
+			t.Errorf("Chat(%v) = %q, want %q", tc.messages, got, tc.want)
+		}
+	}
+
+	if _, err := c.Chat(ctx, "m", 0, []ai.Message{user("упади")}); err == nil || err.Error() != "provider is down" {
+		t.Errorf("error rule: err = %v", err)
+	}
+	if _, err := c.Chat(ctx, "m", 0, []ai.Message{user("купить")}); !errors.Is(err, ai.ErrNoReply) {
+		t.Errorf("no matching rule: err = %v, want ErrNoReply", err)
+	}
+	if calls := c.Calls(); len(calls) != 3 || calls[2].Reply != "дорого: 500" || calls[0].Model != "test-model" {
+		t.Errorf("Calls = %+v", calls)
+	}
+
+	if _, err := ai.NewFake(ai.Script{Rules: []ai.Rule{{Match: "("}}}); err == nil {
+		t.Error("NewFake with invalid regexp: want error")
+	}
+}
+
+func TestFakeExchanges(t *testing.T) {
+	q := []ai.Message{system("s"), user("как дела?")}
+	c := newFake(t, ai.Script{
+		Exchanges: []ai.Exchange{{Messages: q, Reply: "первый"}, {Messages: q, Reply: "второй"}},
+		Rules:     []ai.Rule{{Reply: "правило"}},
+	})
+	for _, want := range []string{"первый", "второй", "второй"} {
+		if got := ask(t, c, q...); got != want {
+			t.Errorf("replay = %q, want %q", got, want)
+		}
+	}
+	if got := ask(t, c, user("как дела?")); got != "правило" {
+		t.Errorf("other messages = %q, want the rule", got)
+	}
+}
+
+func TestFakeUsageAndDefault(t *testing.T) {
+	c := newFake(t, ai.Script{Default: "ok"})
+	var total int
+	c.OnUsage = func(_ context.Context, _ string, u ai.Usage) { total += u.TotalTokens }
+	if got := ask(t, c, user("12345678")); got != "ok" {
+		t.Fatalf("Default = %q", got)
+	}
+	if total != 3 {
+		t.Errorf("usage = %d tokens, want 3 (estimate)", total)
+	}
+}
+
+func TestDemoScript(t *testing.T) {
+	s, err := ai.DemoScript()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newFake(t, s)
+	classify := func(text string) string {
+		return ask(t, c, system("Ты классификатор намерений."),
+			user("Определи намерение пользователя как одно слово из списка [buy, sell, chitchat].\nТекст: "+text))
+	}
+	for text, want := range map[string]string{
+		"ищу айфон до 60 000":    "buy",
+		"хочу продать велосипед": "sell",
+		"привет, как дела?":      "chitchat",
+	} {
+		if got := classify(text); got != want {
+			t.Errorf("intent(%q) = %q, want %q", text, got, want)
+		}
+	}
+	if got := ask(t, c, system("Ты дружелюбный помощник маркетплейса."), user("привет")); got == "" {
+		t.Error("chitchat: empty reply")
+	}
+}
+
+func TestRecorderReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	live := newFake(t, ai.Script{Rules: []ai.Rule{{Match: `(.+)`, Reply: "live: $1"}}})
+	rec, err := ai.NewRecorder(live, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q1 := []ai.Message{system("s"), user("раз")}
+	q2 := []ai.Message{system("s"), user("два")}
+	ask(t, rec, q1...)
+	ask(t, rec, q2...)
+
+	// повторный запуск дописывает, а не затирает
+	rec, err = ai.NewRecorder(live, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ask(t, rec, user("три"))
+
+	s, err := ai.LoadScript(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Exchanges) != 3 || s.Exchanges[0].Model != "test-model" {
+		t.Fatalf("recorded = %+v", s.Exchanges)
+	}
+	replay := newFake(t, s)
+	for q, want := range map[string]string{"раз": "live: раз", "два": "live: два"} {
+		if got := ask(t, replay, system("s"), user(q)); got != want {
+			t.Errorf("replay %q = %q, want %q", q, got, want)
+		}
+	}
+	if _, err := replay.Chat(ctx, "m", 0, []ai.Message{user("не записано")}); !errors.Is(err, ai.ErrNoReply) {
+		t.Errorf("unrecorded request: err = %v, want ErrNoReply", err)
+	}
+
+	// ошибки провайдера не записываются
+	failing := newFake(t, ai.Script{})
+	rec, err = ai.NewRecorder(failing, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.Chat(ctx, "m", 0, []ai.Message{user("x")}); err == nil {
+		t.Fatal("want provider error")
+	}
+	if s, _ := ai.LoadScript(path); len(s.Exchanges) != 3 {
+		t.Errorf("after failed call: %d exchanges, want 3", len(s.Exchanges))
+	}
+}
+
+func TestRecorderRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ai.NewRecorder(newFake(t, ai.Script{}), path); err == nil {
+		t.Fatal("NewRecorder over a corrupt file: want error")
+	}
+}
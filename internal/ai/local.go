@@ -7,28 +7,35 @@ import (
 	"errors"
 	"net/http"
 	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/btynybekov/marketplace/internal/logging"
 )
 
 // LocalClient — универсальный клиент для локального/самостоятельного HTTP API.
 // Ожидается endpoint вида POST {BaseURL}/chat с payload:
 // { "model": "...", "temperature": 0.2, "messages": [{role, content}, ...] }
-// и ответ: { "reply": "..." }
+// и ответ: { "reply": "...", "usage": {...} } (usage опционален — иначе оценивается по длине).
 type LocalClient struct {
 	BaseURL string
 	Client  *http.Client
 	// Доп. заголовки/ключи если нужны (например, X-API-Key)
 	Headers map[string]string
+	// OnUsage — вызывается после каждого успешного ответа (опционально).
+	OnUsage UsageFunc
 }
 
-func NewLocal(baseURL string, headers map[string]string) *LocalClient {
+// NewLocal — клиент локального LLM API; без адреса — ошибка.
+func NewLocal(baseURL string, headers map[string]string) (*LocalClient, error) {
 	if baseURL == "" {
-		panic("LocalAI BaseURL is empty")
+		return nil, errors.New("ai: local AI base URL is empty")
 	}
 	return &LocalClient{
 		BaseURL: baseURL,
-		Client:  &http.Client{Timeout: 20 * time.Second},
+		Client:  &http.Client{Timeout: 20 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)},
 		Headers: headers,
-	}
+	}, nil
 }
 
 func (c *LocalClient) Chat(ctx context.Context, model string, temperature float64, messages []Message) (string, error) {
@@ -41,6 +48,7 @@ func (c *LocalClient) Chat(ctx context.Context, model string, temperature float6
 
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/chat", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
+	logging.Propagate(req)
 	for k, v := range c.Headers {
 		req.Header.Set(k, v)
 	}
@@ -56,9 +64,17 @@ func (c *LocalClient) Chat(ctx context.Context, model string, temperature float6
 
 	var out struct {
 		Reply string `json:"reply"`
+		Usage *Usage `json:"usage"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
 		return "", err
 	}
+	if c.OnUsage != nil {
+		u := EstimateUsage(messages, out.Reply)
+		if out.Usage != nil {
+			u = *out.Usage
+		}
+		c.OnUsage(ctx, model, u)
+	}
 	return out.Reply, nil
 }
@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/btynybekov/marketplace/internal/logging"
+	"github.com/btynybekov/marketplace/internal/tracing"
+)
+
+var tracer = tracing.Tracer("ai")
+
+// Observer — приёмник метрик вызовов LLM (реализация — metrics.Metrics).
+type Observer interface {
+	ObserveLLM(provider, model string, elapsed time.Duration, err error)
+}
+
+// instrumented — Client, который логирует и измеряет каждый вызов Chat.
+type instrumented struct {
+	Client
+	provider string
+	obs      Observer
+}
+
+// Instrument — оборачивает клиент провайдера provider ("openai", "local"): каждый вызов Chat
+// попадает в лог (с request_id из ctx), в obs (nil — только лог) и в трейс спаном "chat <model>".
+func Instrument(c Client, provider string, obs Observer) Client {
+	return &instrumented{Client: c, provider: provider, obs: obs}
+}
+
+func (c *instrumented) Chat(ctx context.Context, model string, temperature float64, messages []Message) (string, error) {
+	ctx, span := tracer.Start(ctx, "chat "+model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.GenAIOperationNameChat,
+			semconv.GenAIProviderNameKey.String(c.provider),
+			semconv.GenAIRequestModel(model),
+			semconv.GenAIRequestTemperature(temperature),
+		),
+	)
+	defer span.End()
+
+	start := time.Now()
+	reply, err := c.Client.Chat(ctx, model, temperature, messages)
+	elapsed := time.Since(start)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if c.obs != nil {
+		c.obs.ObserveLLM(c.provider, model, elapsed, err)
+	}
+
+	attrs := []any{
+		slog.String("provider", c.provider),
+		slog.String("model", model),
+		slog.Int("messages", len(messages)),
+		slog.Duration("duration", elapsed),
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "llm call failed", append(attrs, logging.Err(err))...)
+	} else {
+		slog.DebugContext(ctx, "llm call", attrs...)
+	}
+	return reply, err
+}
+
+// TraceUsage — UsageFunc: токены вызова — атрибутами его спана (цепляется через ChainUsage;
+// спан создаёт Instrument, поэтому клиент должен быть обёрнут).
+func TraceUsage(ctx context.Context, _ string, u Usage) {
+	trace.SpanFromContext(ctx).SetAttributes(
+		semconv.GenAIUsageInputTokens(u.PromptTokens),
+		semconv.GenAIUsageOutputTokens(u.CompletionTokens),
+	)
+}
+
+// ChainUsage — UsageFunc, вызывающая все fns по очереди (квоты + метрики); nil пропускаются.
+func ChainUsage(fns ...UsageFunc) UsageFunc {
+	return func(ctx context.Context, model string, u Usage) {
+		for _, fn := range fns {
+			if fn != nil {
+				fn(ctx, model, u)
+			}
+		}
+	}
+}
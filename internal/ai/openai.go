@@ -7,21 +7,28 @@ import (
 	"errors"
 	"net/http"
 	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/btynybekov/marketplace/internal/logging"
 )
 
 type OpenAIClient struct {
 	Key    string
 	Client *http.Client
+	// OnUsage — вызывается после каждого успешного ответа (опционально).
+	OnUsage UsageFunc
 }
 
-func NewOpenAI(key string) *OpenAIClient {
+// NewOpenAI — клиент OpenAI; без ключа — ошибка (сервер не стартует, а не падает на первом вызове).
+func NewOpenAI(key string) (*OpenAIClient, error) {
 	if key == "" {
-		panic("OPENAI_API_KEY is empty")
+		return nil, errors.New("ai: OpenAI API key is empty")
 	}
 	return &OpenAIClient{
 		Key:    key,
-		Client: &http.Client{Timeout: 20 * time.Second},
-	}
+		Client: &http.Client{Timeout: 20 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}, nil
 }
 
 func (c *OpenAIClient) Chat(ctx context.Context, model string, temperature float64, messages []Message) (string, error) {
@@ -35,6 +42,7 @@ func (c *OpenAIClient) Chat(ctx context.Context, model string, temperature float
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.openai.com/v1/chat/completions", bytes.NewReader(body))
 	req.Header.Set("Authorization", "Bearer "+c.Key)
 	req.Header.Set("Content-Type", "application/json")
+	logging.Propagate(req)
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
@@ -53,6 +61,7 @@ func (c *OpenAIClient) Chat(ctx context.Context, model string, temperature float
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage Usage `json:"usage"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
 		return "", err
@@ -60,5 +69,8 @@ func (c *OpenAIClient) Chat(ctx context.Context, model string, temperature float
 	if len(out.Choices) == 0 {
 		return "", errors.New("openai: empty choices")
 	}
+	if c.OnUsage != nil {
+		c.OnUsage(ctx, model, out.Usage)
+	}
 	return out.Choices[0].Message.Content, nil
 }
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// Recorder — Client, который передаёт вызовы настоящему провайдеру и дописывает каждый успешный
+// обмен в файл сценария (AI_RECORD). Записанное воспроизводит FakeClient (AI_PROVIDER=fake,
+// AI_FAKE_SCRIPT — тот же файл): тесты и разработка без сети с ответами живой модели.
+type Recorder struct {
+	Client
+	path string
+
+	mu     sync.Mutex
+	script Script
+}
+
+// NewRecorder — запись обменов c в path. Существующий файл дополняется (правила и прежние
+// обмены сохраняются); некорректный — ошибка, чтобы не затереть его.
+func NewRecorder(c Client, path string) (*Recorder, error) {
+	r := &Recorder{Client: c, path: path}
+	s, err := LoadScript(path)
+	switch {
+	case err == nil:
+		r.script = s
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) Chat(ctx context.Context, model string, temperature float64, messages []Message) (string, error) {
+	reply, err := r.Client.Chat(ctx, model, temperature, messages)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.script.Exchanges = append(r.script.Exchanges, Exchange{
+		Model: model, Messages: append([]Message(nil), messages...), Reply: reply,
+	})
+	if err := r.save(); err != nil {
+		return "", fmt.Errorf("ai record: %w", err)
+	}
+	return reply, nil
+}
+
+// save — файл целиком, через временный и rename: прерванная запись не портит кассету.
+func (r *Recorder) save() error {
+	b, err := json.MarshalIndent(r.script, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
@@ -0,0 +1,200 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/catalog"
+	"github.com/btynybekov/marketplace/internal/currency"
+	"github.com/btynybekov/marketplace/internal/logging"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/realtime"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// KindSavedSearchMatch — тип уведомления о новых объявлениях по сохранённому поиску.
+const KindSavedSearchMatch = "saved_search_match"
+
+// pageSize — размер страницы при выборке новых объявлений одного поиска.
+const pageSize = 50
+
+// Matcher — периодически прогоняет сохранённые поиски и кладёт в outbox
+// уведомления о новых объявлениях с момента предыдущего прогона.
+type Matcher struct {
+	repos  repository.RepositorySet
+	brands *catalog.BrandResolver
+	rates  *currency.Converter
+	events realtime.Publisher // nil — только outbox
+	batch  int
+}
+
+func NewMatcher(repos repository.RepositorySet, rates *currency.Converter, events realtime.Publisher) *Matcher {
+	return &Matcher{
+		repos:  repos,
+		brands: catalog.NewBrandResolver(repos.Brands()),
+		rates:  rates,
+		events: events,
+		batch:  200,
+	}
+}
+
+// Run — прогон каждые every до отмены ctx.
+func (m *Matcher) Run(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if n, err := m.RunOnce(ctx, every); err != nil {
+				slog.ErrorContext(ctx, "saved search matcher failed", logging.Err(err))
+			} else if n > 0 {
+				slog.InfoContext(ctx, "saved search matcher done", slog.Int("notifications", n))
+			}
+		}
+	}
+}
+
+// RunOnce — обрабатывает поиски, не прогонявшиеся дольше minAge. Возвращает число уведомлений.
+func (m *Matcher) RunOnce(ctx context.Context, minAge time.Duration) (int, error) {
+	now := time.Now().UTC()
+	due, err := m.repos.SavedSearches().ListDue(ctx, now.Add(-minAge), m.batch)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, ss := range due {
+		since := ss.CreatedAt
+		if ss.LastRunAt != nil {
+			since = *ss.LastRunAt
+		}
+		// фиксируем время до запроса: объявления, созданные во время прогона, попадут в следующий
+		runAt := time.Now().UTC()
+
+		found, err := m.newMatches(ctx, ss, since, runAt)
+		if err != nil {
+			slog.WarnContext(ctx, "saved search match failed", slog.String("saved_search_id", ss.ID.String()), logging.Err(err))
+			continue
+		}
+		if len(found) > 0 {
+			n := notificationFor(ss, found)
+			n.CreatedAt = runAt
+			if n.ID, err = m.repos.Notifications().Enqueue(ctx, n); err != nil {
+				return sent, err
+			}
+			// push — best effort; уведомление остаётся в outbox до подтверждения клиентом
+			if m.events != nil {
+				m.events.Publish(ctx, ss.UserID, realtime.Event{Type: realtime.EventNotification, Data: n})
+			}
+			sent++
+		}
+		if err := m.repos.SavedSearches().MarkRun(ctx, ss.ID, runAt); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// newMatches — все объявления окна (since, runAt]: следующий прогон начнётся с runAt,
+// поэтому окно выбирается целиком, страницами по pageSize.
+func (m *Matcher) newMatches(ctx context.Context, ss models.SavedSearch, since, runAt time.Time) ([]models.Product, error) {
+	f, ok, err := Filter(ctx, ss.Requirements, m.brands, m.rates)
+	if err != nil || !ok {
+		return nil, err
+	}
+	f.CreatedAfter = &since
+	f.CreatedBefore = &runAt
+	f.Limit = pageSize
+
+	var found []models.Product
+	for {
+		page, err := m.repos.Products().List(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, page...)
+		if len(page) < f.Limit {
+			return found, nil
+		}
+		after := repository.ProductCursor(page[len(page)-1], f.Sort)
+		f.After = &after
+	}
+}
+
+// Filter — переводит requirements (формат buyer-ассистента) в фильтр выдачи.
+// ok=false — требования заведомо ничего не найдут (неизвестный бренд или валюта).
+func Filter(ctx context.Context, req models.SearchRequirements, brands *catalog.BrandResolver, rates *currency.Converter) (repository.ProductFilter, bool, error) {
+	f := repository.ProductFilter{
+		CategorySlug: req.CategorySlug,
+		Query:        strings.TrimSpace(req.Query),
+		Condition:    req.Condition,
+		Sort:         repository.SortNewest,
+	}
+	if req.Brand != "" {
+		b, found, err := brands.Resolve(ctx, req.Brand)
+		if err != nil || !found {
+			return f, false, err
+		}
+		f.BrandSlug = b.Slug
+	}
+
+	cur := req.Currency
+	if cur == "" {
+		cur = currency.Base
+	}
+	for _, p := range []struct {
+		src *float64
+		dst **float64
+	}{{req.PriceMin, &f.PriceMinKGS}, {req.PriceMax, &f.PriceMaxKGS}} {
+		if p.src == nil {
+			continue
+		}
+		v, ok := rates.Convert(*p.src, cur, currency.Base)
+		if !ok {
+			return f, false, nil
+		}
+		*p.dst = &v
+	}
+	return f, true, nil
+}
+
+func notificationFor(ss models.SavedSearch, found []models.Product) models.Notification {
+	ids := make([]uuid.UUID, 0, len(found))
+	for _, p := range found {
+		ids = append(ids, p.ID)
+	}
+	return models.Notification{
+		UserID: ss.UserID,
+		Kind:   KindSavedSearchMatch,
+		Text: fmt.Sprintf("%d %s по поиску «%s»",
+			len(found), plural(len(found), "новое объявление", "новых объявления", "новых объявлений"), ss.Name),
+		Payload: map[string]any{
+			"saved_search_id": ss.ID,
+			"count":           len(found),
+			"listing_ids":     ids,
+		},
+	}
+}
+
+// plural — русская форма числительного: 1 объявление, 3 объявления, 5 объявлений.
+func plural(n int, one, few, many string) string {
+	n %= 100
+	if n >= 11 && n <= 14 {
+		return many
+	}
+	switch n % 10 {
+	case 1:
+		return one
+	case 2, 3, 4:
+		return few
+	default:
+		return many
+	}
+}
@@ -0,0 +1,62 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/currency"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository/fixtures"
+	"github.com/btynybekov/marketplace/internal/repository/memory"
+)
+
+// TestRunOnceCoversWholeWindow — уведомление перечисляет все объявления с прошлого прогона,
+// даже если их больше страницы выборки.
+func TestRunOnceCoversWholeWindow(t *testing.T) {
+	ctx := context.Background()
+	cat, user := uuid.New(), uuid.New()
+	repos, err := memory.New(fixtures.Data{
+		Categories: []fixtures.Category{{ID: cat, Name: "Телефоны", Slug: "phones"}},
+		Users:      []fixtures.User{{UserProfile: models.UserProfile{ID: user, DisplayName: "Алиса"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, err := repos.SavedSearches().Create(ctx, models.SavedSearch{
+		UserID: user, Name: "телефоны", Requirements: models.SearchRequirements{CategorySlug: "phones"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const total = 2*pageSize + 7
+	for range total {
+		_, err := repos.Listings().Create(ctx, models.Listing{
+			SellerID: user, CategoryID: cat, Title: "iPhone", PriceAmount: 1000, CurrencyCode: "KGS", Condition: "used",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(time.Millisecond) // время прогона — после всех объявлений
+
+	m := NewMatcher(repos, currency.NewConverter(), nil)
+	if n, err := m.RunOnce(ctx, 0); err != nil || n != 1 {
+		t.Fatalf("RunOnce = %d, %v; want 1 notification", n, err)
+	}
+	pending, err := repos.Notifications().ListPending(ctx, user, 10)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("pending = %+v, %v", pending, err)
+	}
+	if got := pending[0].Payload["count"]; got != float64(total) { // payload — JSONB
+		t.Fatalf("notification for %q counts %v listings, want %d", ss.Name, got, total)
+	}
+
+	// следующий прогон начинается с времени предыдущего: повторов нет
+	if n, err := m.RunOnce(ctx, 0); err != nil || n != 0 {
+		t.Fatalf("second RunOnce = %d, %v; want 0", n, err)
+	}
+}
@@ -0,0 +1,350 @@
+// Package app — сборка приложения из конфигурации: БД, репозитории, лимиты, AI-клиент, шаблоны,
+// хендлеры, роутер, пробы и фоновые задачи. main только читает конфиг и запускает App;
+// тесты поднимают тот же сервер с подменёнными зависимостями (Options).
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+
+	"github.com/btynybekov/marketplace/config"
+	"github.com/btynybekov/marketplace/internal/ai"
+	"github.com/btynybekov/marketplace/internal/alerts"
+	"github.com/btynybekov/marketplace/internal/currency"
+	"github.com/btynybekov/marketplace/internal/handlers/chat"
+	"github.com/btynybekov/marketplace/internal/handlers/factory"
+	"github.com/btynybekov/marketplace/internal/health"
+	"github.com/btynybekov/marketplace/internal/logging"
+	"github.com/btynybekov/marketplace/internal/metrics"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/ratelimit"
+	"github.com/btynybekov/marketplace/internal/realtime"
+	"github.com/btynybekov/marketplace/internal/repository"
+	"github.com/btynybekov/marketplace/internal/tracing"
+	"github.com/btynybekov/marketplace/internal/web"
+	"github.com/btynybekov/marketplace/static"
+	"github.com/btynybekov/marketplace/storage"
+	"github.com/btynybekov/marketplace/templates"
+)
+
+// Options — подмена зависимостей (тесты, демо); nil — настоящая из конфигурации.
+type Options struct {
+	// Repos — хранилище вместо Postgres: пул не открывается, события realtime — в памяти,
+	// в /readyz нет проверок БД.
+	Repos repository.RepositorySet
+	// AI — LLM-клиент вместо OpenAI/локального (учёт токенов — на его совести).
+	AI ai.Client
+}
+
+// App — собранное приложение.
+type App struct {
+	cfg config.EnvConfig
+
+	Repos    repository.RepositorySet
+	Handlers *factory.HandlersFactory
+	Health   *health.Health
+	Metrics  *metrics.Metrics
+
+	db      *storage.DB // nil, если хранилище подменено
+	hub     *realtime.Hub
+	handler http.Handler
+
+	jobs    []func(ctx context.Context) // фоновые задачи, запускаются Start
+	stop    context.CancelFunc
+	running sync.WaitGroup
+	closers []func()
+}
+
+// New — собирает приложение. Ошибка — всё, что уже открыто, закрыто.
+// ctx — только для подключения и первичной загрузки (курсы валют), не для жизни App.
+func New(ctx context.Context, cfg config.EnvConfig, opts Options) (_ *App, err error) {
+	a := &App{cfg: cfg, Metrics: metrics.New()}
+	defer func() {
+		if err != nil {
+			a.Close()
+		}
+	}()
+
+	// 1) БД и репозитории
+	a.Repos = opts.Repos
+	if a.Repos == nil {
+		db, err := storage.New(ctx, cfg.DatabaseURL, storage.Options{
+			MaxConns:          cfg.DBMaxConns,
+			MinConns:          cfg.DBMinConns,
+			MaxConnLifetime:   cfg.DBMaxConnLifetime,
+			MaxConnIdleTime:   cfg.DBMaxConnIdleTime,
+			HealthCheckPeriod: cfg.DBHealthCheckPeriod,
+			ConnectTimeout:    cfg.DBConnectTimeout,
+			SlowQuery:         cfg.DBSlowQuery,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("db connect: %w", err)
+		}
+		a.db = db
+		a.closers = append(a.closers, db.Close)
+		if err := a.Metrics.Register(metrics.NewPoolCollector(db.Pool)); err != nil {
+			return nil, fmt.Errorf("metrics register: %w", err)
+		}
+		a.Repos = repository.New(db.Pool)
+	}
+
+	// 2) Лимиты: rate limit на платные эндпоинты и суточные квоты LLM-токенов
+	var (
+		limitStore ratelimit.Store      = ratelimit.NewMemoryStore()
+		usageStore ratelimit.UsageStore = ratelimit.NewMemoryUsageStore()
+	)
+	if cfg.RateLimitStore == "postgres" {
+		limitStore, usageStore = a.Repos.RateLimits(), a.Repos.AIUsage()
+		a.jobs = append(a.jobs, func(ctx context.Context) {
+			ratelimit.RunCleanup(ctx, a.Repos.RateLimits(), 10*time.Minute)
+		})
+	}
+	limiter := ratelimit.NewLimiter(limitStore, ratelimit.PerMinute(cfg.RateLimitPerMinute, cfg.RateLimitBurst))
+	quota := ratelimit.NewQuota(usageStore, cfg.AIDailyTokens, cfg.AIDailyTokensAnon, cfg.TrustProxy)
+
+	// 3) AI-клиент
+	aiClient := opts.AI
+	if aiClient == nil {
+		if aiClient, err = newAIClient(cfg, quota, a.Metrics); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4) Статика и шаблоны
+	assets, err := web.LoadAssets(static.FS, cfg.AssetsBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("static assets: %w", err)
+	}
+	tmpl, err := web.Load(templates.FS, web.Options{Assets: assets, Dir: cfg.TemplatesDir})
+	if err != nil {
+		return nil, fmt.Errorf("templates: %w", err)
+	}
+	if cfg.TemplatesDir != "" {
+		slog.Info("templates are reloaded on every request", slog.String("dir", cfg.TemplatesDir))
+	}
+
+	// 5) Realtime-хаб: события в WebSocket; между инстансами — через LISTEN/NOTIFY
+	var broker realtime.Broker = realtime.NewLocalBroker()
+	if a.db != nil && cfg.RealtimeBroker != "memory" {
+		broker = realtime.NewPGBroker(a.db.Pool, "realtime_events")
+	}
+	a.hub = realtime.NewHub(broker)
+	a.jobs = append(a.jobs, a.hub.Run)
+
+	// 6) Хендлеры
+	a.Handlers = factory.NewHandlersFactory(a.Repos, tmpl, cfg, aiClient, a.hub, limiter, quota).WithAssets(assets)
+
+	// 6.1) Курсы валют: прогреваем из хранилища, затем (если задан файл) обновляем периодически
+	var rateProvider currency.Provider
+	if cfg.ExchangeRatesFile != "" {
+		rateProvider = currency.NewFileProvider(cfg.ExchangeRatesFile)
+	}
+	refresher := currency.NewRefresher(rateProvider, a.Repos.ExchangeRates(), a.Handlers.Rates, "file")
+	if err := refresher.Refresh(ctx); err != nil {
+		slog.Error("exchange rates load failed", logging.Err(err))
+	}
+	if rateProvider != nil {
+		a.jobs = append(a.jobs, func(ctx context.Context) { refresher.Run(ctx, cfg.ExchangeRatesRefresh) })
+	}
+
+	// 6.2) Истёкшие сессии чата; сохранённые поиски → outbox уведомлений (+ push в WebSocket)
+	a.jobs = append(a.jobs,
+		func(ctx context.Context) { chat.PurgeExpiredSessions(ctx, a.Repos.ChatSessions(), time.Hour) },
+		func(ctx context.Context) {
+			alerts.NewMatcher(a.Repos, a.Handlers.Rates, a.hub).Run(ctx, cfg.SavedSearchInterval)
+		},
+	)
+
+	// 7) Пробы и роутер
+	if a.Health, err = a.healthChecks(); err != nil {
+		return nil, fmt.Errorf("health checks: %w", err)
+	}
+	a.handler = a.router()
+	return a, nil
+}
+
+// newAIClient — клиент из конфигурации (openai | local | fake); расход токенов идёт в квоты, метрики
+// и спан вызова. С AI_RECORD обмены с настоящим провайдером пишутся в файл сценария для fake.
+func newAIClient(cfg config.EnvConfig, quota *ratelimit.Quota, m *metrics.Metrics) (ai.Client, error) {
+	var c ai.Client
+	switch cfg.AIProvider {
+	case "fake":
+		script, err := ai.DemoScript()
+		if cfg.AIFakeScript != "" {
+			script, err = ai.LoadScript(cfg.AIFakeScript)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fc, err := ai.NewFake(script)
+		if err != nil {
+			return nil, err
+		}
+		fc.OnUsage = ai.ChainUsage(quota.Record, m.LLMUsage("fake"), ai.TraceUsage)
+		return ai.Instrument(fc, "fake", m), nil
+	case "local":
+		headers := map[string]string{}
+		if cfg.LocalAIKey != "" {
+			headers["X-API-Key"] = cfg.LocalAIKey
+		}
+		lc, err := ai.NewLocal(cfg.LocalAIURL, headers)
+		if err != nil {
+			return nil, err
+		}
+		lc.Client.Timeout = cfg.AITimeout
+		lc.OnUsage = ai.ChainUsage(quota.Record, m.LLMUsage("local"), ai.TraceUsage)
+		c = lc
+	default:
+		oc, err := ai.NewOpenAI(cfg.OpenAIKey)
+		if err != nil {
+			return nil, err
+		}
+		oc.Client.Timeout = cfg.AITimeout
+		oc.OnUsage = ai.ChainUsage(quota.Record, m.LLMUsage("openai"), ai.TraceUsage)
+		c = oc
+	}
+	if cfg.AIRecord != "" {
+		rec, err := ai.NewRecorder(c, cfg.AIRecord)
+		if err != nil {
+			return nil, err
+		}
+		slog.Warn("llm exchanges are recorded", slog.String("file", cfg.AIRecord))
+		c = rec
+	}
+	return ai.Instrument(c, cfg.AIProvider, m), nil
+}
+
+// router — API и страницы на mux-роутере; пробы — мимо него: без авторизации,
+// access log и метрик на каждый опрос оркестратора.
+func (a *App) router() http.Handler {
+	r := mux.NewRouter()
+	if a.cfg.JWTSecret == "" {
+		slog.Warn("JWT_SECRET is empty: requests with a bearer token are rejected, the rest are anonymous")
+	}
+	r.Use(otelmux.Middleware(tracing.ServiceName)) // серверный спан с именем по шаблону маршрута
+	r.Use(middleware.Observe(a.Metrics))           // access log и латентность по шаблону маршрута
+	r.Use(middleware.Authenticate(a.cfg.JWTSecret))
+
+	// JSON API — под /api/v1, страницы (HTML по Accept) — в корне
+	a.Handlers.RegisterRoutes(r)
+
+	root := http.NewServeMux()
+	root.Handle("GET /healthz", a.Health.Liveness())
+	root.Handle("GET /readyz", a.Health.Readiness())
+	root.Handle("/", middleware.RequestID(r)) // снаружи роутера: ID есть и у 404
+	return root
+}
+
+// Handler — корневой HTTP-обработчик (для httptest.NewServer в тестах).
+func (a *App) Handler() http.Handler { return a.handler }
+
+// MetricsHandler — GET /metrics для отдельного listener-а cfg.MetricsAddr: на порт API
+// метрики не попадают, наружу их не отдаёт ни сервер, ни забытое правило прокси.
+func (a *App) MetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", a.Metrics.Handler())
+	return mux
+}
+
+// Start — запускает фоновые задачи (realtime, курсы, сохранённые поиски, чистка сессий и лимитов).
+// Останавливаются в Close.
+func (a *App) Start() {
+	if a.stop != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stop = cancel
+	for _, job := range a.jobs {
+		a.running.Add(1)
+		go func() {
+			defer a.running.Done()
+			job(ctx)
+		}()
+	}
+}
+
+// Run — Start и HTTP-сервер на cfg.PORT до отмены ctx (сигнал остановки), затем drain:
+// /readyz отвечает 503, балансировщик успевает убрать инстанс, и только потом сервер перестаёт
+// принимать соединения и дожидается текущих запросов. /metrics — на cfg.MetricsAddr
+// (если задан), доступен и во время drain, закрывается последним.
+func (a *App) Run(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              ":" + a.cfg.PORT,
+		Handler:           a.handler,
+		ReadHeaderTimeout: a.cfg.ReadHeaderTimeout,
+	}
+	srv.RegisterOnShutdown(a.hub.Shutdown) // WebSocket-подключения Shutdown сам не закрывает
+
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	if a.cfg.MetricsAddr != "" {
+		msrv := &http.Server{
+			Addr:              a.cfg.MetricsAddr,
+			Handler:           a.MetricsHandler(),
+			ReadHeaderTimeout: a.cfg.ReadHeaderTimeout,
+		}
+		mln, err := net.Listen("tcp", msrv.Addr)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("metrics listen: %w", err)
+		}
+		go func() {
+			slog.Info("metrics listening", slog.String("addr", msrv.Addr))
+			if err := msrv.Serve(mln); !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("metrics serve failed", logging.Err(err))
+			}
+		}()
+		defer msrv.Close()
+	}
+	a.Start()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", slog.String("addr", srv.Addr), slog.String("env", a.cfg.AppEnv))
+		serveErr <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("http serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	a.Health.Drain()
+	slog.Info("draining", slog.Duration("delay", a.cfg.ShutdownDrainDelay))
+	time.Sleep(a.cfg.ShutdownDrainDelay)
+
+	sctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(sctx); err != nil {
+		return fmt.Errorf("server shutdown: %w", err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("http serve: %w", err)
+	}
+	slog.Info("server gracefully stopped")
+	return nil
+}
+
+// Close — останавливает фоновые задачи (дожидаясь их) и закрывает соединения. Повторный вызов безопасен.
+func (a *App) Close() {
+	if a.stop != nil {
+		a.stop()
+		a.running.Wait()
+	}
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+	a.closers = nil
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btynybekov/marketplace/config"
+	"github.com/btynybekov/marketplace/internal/ai"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// fakeRepos — хранилище без БД: курсы валют (их читает New и /api/v1/rates) — в памяти,
+// остальные репозитории — postgres-реализация без пула (запрос к ним — паника, явный сигнал в тесте).
+type fakeRepos struct {
+	repository.RepositorySet
+	rates fakeRates
+}
+
+func (f *fakeRepos) ExchangeRates() repository.ExchangeRatesRepository { return &f.rates }
+
+type fakeRates struct{ list []models.ExchangeRate }
+
+func (f *fakeRates) List(context.Context) ([]models.ExchangeRate, error) { return f.list, nil }
+
+func (f *fakeRates) Upsert(_ context.Context, rates []models.ExchangeRate) error {
+	f.list = append(f.list, rates...)
+	return nil
+}
+
+// fakeAI — ответ без сети.
+type fakeAI struct{}
+
+func (fakeAI) Chat(context.Context, string, float64, []ai.Message) (string, error) { return "ok", nil }
+
+func newTestApp(t *testing.T) *httptest.Server {
+	t.Helper()
+	a := newApp(t)
+	srv := httptest.NewServer(a.Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newApp — приложение на подставных репозиториях и LLM, фоновые задачи запущены.
+func newApp(t *testing.T) *App {
+	t.Helper()
+	repos := &fakeRepos{RepositorySet: repository.New(nil), rates: fakeRates{list: []models.ExchangeRate{
+		{CurrencyCode: "USD", RateToKGS: 87.45, Source: "test", UpdatedAt: time.Now().UTC()},
+	}}}
+	a, err := New(context.Background(), config.Defaults(), Options{Repos: repos, AI: fakeAI{}})
+	if err != nil {
+		t.Fatalf("app.New: %v", err)
+	}
+	a.Start()
+	t.Cleanup(a.Close)
+	return a
+}
+
+func TestAppBootsWithFakes(t *testing.T) {
+	srv := newTestApp(t)
+
+	for _, tc := range []struct {
+		path   string
+		status int
+	}{
+		{"/healthz", http.StatusOK},
+		{"/readyz", http.StatusOK},
+		{"/api/v1/rates", http.StatusOK},
+		{"/api/v1/openapi.json", http.StatusOK},
+		{"/metrics", http.StatusNotFound}, // только на отдельном listener-е
+		{"/api/v1/no-such-route", http.StatusNotFound},
+	} {
+		resp, err := http.Get(srv.URL + tc.path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", tc.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("GET %s = %d, want %d", tc.path, resp.StatusCode, tc.status)
+		}
+		if resp.Header.Get("X-Request-ID") == "" && tc.path != "/healthz" && tc.path != "/readyz" {
+			t.Errorf("GET %s: no X-Request-ID", tc.path)
+		}
+	}
+}
+
+func TestMetricsOnSeparateHandler(t *testing.T) {
+	a := newApp(t)
+	a.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/rates", nil))
+	srv := httptest.NewServer(a.MetricsHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `route="/api/v1/rates"`) {
+		t.Fatalf("GET /metrics = %d, want 200 with the API request latency", resp.StatusCode)
+	}
+	if resp, err := http.Get(srv.URL + "/api/v1/rates"); err != nil {
+		t.Fatal(err)
+	} else if resp.Body.Close(); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /api/v1/rates on the metrics listener = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestAppRatesFromFakeRepos(t *testing.T) {
+	srv := newTestApp(t)
+
+	resp, err := http.Get(srv.URL + "/api/v1/rates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Rates []models.ExchangeRate `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Rates) != 1 || body.Rates[0].CurrencyCode != "USD" {
+		t.Fatalf("rates = %+v, want the fake USD rate", body.Rates)
+	}
+}
+
+func TestNewFailsWithoutAIKey(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.AIProvider, cfg.OpenAIKey = "openai", ""
+	if _, err := New(context.Background(), cfg, Options{Repos: &fakeRepos{RepositorySet: repository.New(nil)}}); err == nil {
+		t.Fatal("New without OpenAI key: want error, got nil")
+	}
+}
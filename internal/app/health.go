@@ -0,0 +1,53 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/btynybekov/marketplace/internal/health"
+	"github.com/btynybekov/marketplace/migrations"
+)
+
+// healthChecks — проверки /readyz: Postgres и версия схемы (обязательные, если БД настоящая),
+// при HEALTH_CHECK_EXTERNAL — LLM-провайдер и webhook-и n8n (необязательные, результат
+// кэшируется на HEALTH_CACHE_TTL).
+func (a *App) healthChecks() (*health.Health, error) {
+	cfg := a.cfg
+	var checks []health.Check
+	if a.db != nil {
+		want, err := health.LatestMigration(migrations.FS)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks,
+			health.Check{Name: "postgres", Fn: health.Postgres(a.db.Pool)},
+			health.Check{Name: "migrations", Fn: health.Migrations(a.db.Pool, want), TTL: cfg.HealthCacheTTL},
+		)
+	}
+	if !cfg.HealthCheckExternal {
+		return health.New(checks...), nil
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	external := func(name, url string, header http.Header) {
+		if url != "" {
+			checks = append(checks, health.Check{
+				Name: name, Fn: health.HTTP(client, url, header), Optional: true, TTL: cfg.HealthCacheTTL,
+			})
+		}
+	}
+	switch cfg.AIProvider {
+	case "fake": // без сети — проверять нечего
+	case "local":
+		header := http.Header{}
+		if cfg.LocalAIKey != "" {
+			header.Set("X-API-Key", cfg.LocalAIKey)
+		}
+		external("ai", cfg.LocalAIURL, header)
+	default:
+		external("ai", "https://api.openai.com/v1/models", http.Header{"Authorization": {"Bearer " + cfg.OpenAIKey}})
+	}
+	external("n8n_buyer", cfg.N8NBuyerWebhookURL, nil)
+	external("n8n_seller", cfg.N8NSellerWebhookURL, nil)
+	return health.New(checks...), nil
+}
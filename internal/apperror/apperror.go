@@ -0,0 +1,185 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Code — машиночитаемый код ошибки в ответе API (поле "code").
+type Code string
+
+const (
+	CodeBadRequest       Code = "bad_request"
+	CodeValidation       Code = "validation_failed"
+	CodeTooLarge         Code = "payload_too_large"
+	CodeUnauthorized     Code = "unauthorized"
+	CodeForbidden        Code = "forbidden"
+	CodeNotFound         Code = "not_found"
+	CodeMethodNotAllowed Code = "method_not_allowed"
+	CodeNotAcceptable    Code = "not_acceptable"
+	CodeConflict         Code = "conflict"
+	CodeAlreadyExists    Code = "already_exists"
+	CodeRateLimited      Code = "rate_limited"
+	CodeQuotaExceeded    Code = "quota_exceeded"
+	CodeInternal         Code = "internal"
+	CodeNotImplemented   Code = "not_implemented"
+	CodeUpstream         Code = "upstream_error"
+	CodeTimeout          Code = "timeout"
+)
+
+// Error — ошибка для клиента: код, HTTP-статус и безопасное сообщение.
+// Message — английский шаблон (fmt) и одновременно ключ каталога i18n;
+// Fields — ошибки отдельных полей запроса; Cause — внутренняя причина, пишется только в лог.
+type Error struct {
+	Code    Code
+	Status  int
+	Message string
+	Args    []any
+	Fields  []FieldError
+	Cause   error
+}
+
+// FieldError — нарушенное правило одного поля: Field — путь в JSON ("contact.preferred"),
+// Rule — имя правила (required, max, enum, ...), Message/Args — как у Error.
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+	Args    []any
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Text() + ": " + e.Cause.Error()
+	}
+	return e.Text()
+}
+
+func (e *Error) Unwrap() error { return e.Cause }
+
+// Text — сообщение на английском с подставленными аргументами.
+func (e *Error) Text() string {
+	if len(e.Args) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf(e.Message, e.Args...)
+}
+
+// Wrap — копия ошибки с внутренней причиной (исходная не меняется: её можно держать в var).
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.Cause = cause
+	return &c
+}
+
+func New(code Code, status int, msg string, args ...any) *Error {
+	return &Error{Code: code, Status: status, Message: msg, Args: args}
+}
+
+func BadRequest(msg string, args ...any) *Error {
+	return New(CodeBadRequest, http.StatusBadRequest, msg, args...)
+}
+
+// Validation — 400 с перечнем ошибок по полям.
+func Validation(fields ...FieldError) *Error {
+	e := New(CodeValidation, http.StatusBadRequest, "validation failed")
+	e.Fields = fields
+	return e
+}
+
+func TooLarge(msg string, args ...any) *Error {
+	return New(CodeTooLarge, http.StatusRequestEntityTooLarge, msg, args...)
+}
+
+func Unauthorized(msg string, args ...any) *Error {
+	return New(CodeUnauthorized, http.StatusUnauthorized, msg, args...)
+}
+
+func Forbidden(msg string, args ...any) *Error {
+	return New(CodeForbidden, http.StatusForbidden, msg, args...)
+}
+
+func NotFound(msg string, args ...any) *Error {
+	return New(CodeNotFound, http.StatusNotFound, msg, args...)
+}
+
+func Conflict(msg string, args ...any) *Error {
+	return New(CodeConflict, http.StatusConflict, msg, args...)
+}
+
+func TooManyRequests(msg string, args ...any) *Error {
+	return New(CodeRateLimited, http.StatusTooManyRequests, msg, args...)
+}
+
+// QuotaExceeded — исчерпана суточная квота (429, но другой код, чем у rate limit).
+func QuotaExceeded(msg string, args ...any) *Error {
+	return New(CodeQuotaExceeded, http.StatusTooManyRequests, msg, args...)
+}
+
+func MethodNotAllowed() *Error {
+	return New(CodeMethodNotAllowed, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+// NotAcceptable — ни один из форматов ответа не подходит под Accept клиента.
+func NotAcceptable(offers ...string) *Error {
+	return New(CodeNotAcceptable, http.StatusNotAcceptable, "acceptable content types: %s", strings.Join(offers, ", "))
+}
+
+func NotImplemented(msg string, args ...any) *Error {
+	return New(CodeNotImplemented, http.StatusNotImplemented, msg, args...)
+}
+
+// Upstream — внешний сервис (n8n, LLM) недоступен или ответил мусором.
+func Upstream(msg string, cause error) *Error {
+	return New(CodeUpstream, http.StatusBadGateway, msg).Wrap(cause)
+}
+
+// Internal — 500 без подробностей для клиента.
+func Internal(cause error) *Error {
+	return New(CodeInternal, http.StatusInternalServerError, "internal server error").Wrap(cause)
+}
+
+// Коды SQLSTATE нарушений ограничений (https://www.postgresql.org/docs/current/errcodes-appendix.html).
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgExclusionViolation  = "23P01"
+)
+
+// From — приводит любую ошибку к *Error:
+// *Error — как есть, pgx.ErrNoRows — 404, нарушения ограничений Postgres — 409,
+// истёкший дедлайн — 504, остальное — 500.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var ae *Error
+	if errors.As(err, &ae) {
+		return ae
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NotFound("not found").Wrap(err)
+	}
+	var pe *pgconn.PgError
+	if errors.As(err, &pe) {
+		switch pe.Code {
+		case pgUniqueViolation:
+			return New(CodeAlreadyExists, http.StatusConflict, "already exists").Wrap(err)
+		case pgForeignKeyViolation:
+			return Conflict("referenced object does not exist or is still in use").Wrap(err)
+		case pgCheckViolation, pgExclusionViolation:
+			return Conflict("value violates a data constraint").Wrap(err)
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return New(CodeTimeout, http.StatusGatewayTimeout, "request timed out").Wrap(err)
+	}
+	return Internal(err)
+}
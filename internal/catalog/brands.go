@@ -0,0 +1,162 @@
+package catalog
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// BrandSource — откуда резолвер берёт список брендов (обычно repository.BrandsRepository).
+type BrandSource interface {
+	List(ctx context.Context) ([]models.Brand, error)
+}
+
+// BrandResolver — находит бренд по свободному тексту: slug, название или алиас
+// ("айфон" → Apple), с допуском на опечатки.
+type BrandResolver struct {
+	src BrandSource
+}
+
+func NewBrandResolver(src BrandSource) *BrandResolver {
+	return &BrandResolver{src: src}
+}
+
+// Resolve — возвращает бренд и true, если нашлось достаточно близкое совпадение.
+func (r *BrandResolver) Resolve(ctx context.Context, query string) (models.Brand, bool, error) {
+	if Normalize(query) == "" {
+		return models.Brand{}, false, nil
+	}
+	brands, err := r.src.List(ctx)
+	if err != nil {
+		return models.Brand{}, false, err
+	}
+	b, ok := MatchBrand(query, brands)
+	return b, ok, nil
+}
+
+// MatchBrand — подбирает бренд по тексту запроса.
+// Порядок: точное совпадение slug/названия/алиаса → совпадение с одним из слов запроса
+// ("iphone 13 pro" → Apple) → расстояние Левенштейна с порогом по длине.
+func MatchBrand(query string, brands []models.Brand) (models.Brand, bool) {
+	q := Normalize(query)
+	if q == "" {
+		return models.Brand{}, false
+	}
+	tokens := strings.Fields(q)
+	candidates := append(tokens[:len(tokens):len(tokens)], q)
+
+	var (
+		best     models.Brand
+		bestDist = -1
+	)
+	for _, b := range brands {
+		for _, name := range brandNames(b) {
+			if name == q {
+				return b, true
+			}
+		}
+	}
+	for _, b := range brands {
+		for _, name := range brandNames(b) {
+			for _, t := range tokens {
+				if t == name {
+					return b, true
+				}
+			}
+		}
+	}
+	for _, b := range brands {
+		for _, name := range brandNames(b) {
+			for _, t := range candidates {
+				d := levenshtein(t, name)
+				if d <= maxTypos(name) && (bestDist < 0 || d < bestDist) {
+					best, bestDist = b, d
+				}
+			}
+		}
+	}
+	return best, bestDist >= 0
+}
+
+// Normalize — приводит строку к виду для сравнения: нижний регистр, ё→е,
+// пунктуация заменяется пробелами, пробелы схлопываются.
+func Normalize(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.ReplaceAll(s, "ё", "е")
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Slugify — latin-slug из названия ("Xiaomi Redmi" → "xiaomi-redmi").
+// Кириллица транслитерируется, остальное отбрасывается.
+func Slugify(s string) string {
+	var b strings.Builder
+	for _, r := range Normalize(s) {
+		switch {
+		case r == ' ':
+			b.WriteByte('-')
+		case r < unicode.MaxASCII:
+			b.WriteRune(r)
+		default:
+			b.WriteString(translit[r])
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+func brandNames(b models.Brand) []string {
+	out := make([]string, 0, len(b.Aliases)+2)
+	out = append(out, Normalize(b.Name), Normalize(strings.ReplaceAll(b.Slug, "-", " ")))
+	for _, a := range b.Aliases {
+		out = append(out, Normalize(a))
+	}
+	return out
+}
+
+// maxTypos — сколько опечаток допускаем: короткие названия ("lg", "bmw") — только точно.
+func maxTypos(name string) int {
+	switch n := len([]rune(name)); {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p",
+	'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch",
+	'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'ң': "n", 'ө': "o", 'ү': "u",
+}
@@ -0,0 +1,134 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+var testBrands = []models.Brand{
+	{ID: uuid.New(), Name: "Apple", Slug: "apple", Aliases: []string{"iphone", "айфон", "эпл"}},
+	{ID: uuid.New(), Name: "Samsung", Slug: "samsung", Aliases: []string{"galaxy", "самсунг"}},
+	{ID: uuid.New(), Name: "LG", Slug: "lg"},
+	{ID: uuid.New(), Name: "BMW", Slug: "bmw"},
+	{ID: uuid.New(), Name: "Land Rover", Slug: "land-rover"},
+}
+
+func TestMatchBrand(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  string // slug; "" — не найдено
+	}{
+		{"Apple", "apple"},
+		{"  APPLE!! ", "apple"},
+		{"айфон", "apple"},
+		{"Айфон", "apple"},
+		{"iphone 13 pro", "apple"},      // слово запроса — алиас
+		{"galaxy s23 ultra", "samsung"}, // то же для другого бренда
+		{"aple", "apple"},               // 1 опечатка на 5 букв
+		{"appple", "apple"},             // лишняя буква
+		{"samsnug", "samsung"},          // 2 опечатки на 7 букв
+		{"самсугн", "samsung"},          // кириллица, по рунам
+		{"land rover", "land-rover"},    // многословное название
+		{"land rovr", "land-rover"},     // опечатка во всём запросе
+		{"LAND-ROVER", "land-rover"},    // дефис — как пробел
+		{"lg", "lg"},                    // короткое — только точно
+		{"lgg", ""},                     // ...без опечаток
+		{"bmv", ""},                     // 3 буквы — без опечаток
+		{"aplpe", ""},                   // 2 опечатки на 5 букв — много
+		{"nokia", ""},
+		{"", ""},
+		{"!!!", ""},
+	} {
+		b, ok := MatchBrand(tc.query, testBrands)
+		got := ""
+		if ok {
+			got = b.Slug
+		}
+		if got != tc.want {
+			t.Errorf("MatchBrand(%q) = %q, want %q", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestMatchBrandPrefersExactOverTypo(t *testing.T) {
+	brands := []models.Brand{
+		{Name: "Honor", Slug: "honor"},
+		{Name: "Hono", Slug: "hono"},
+	}
+	if b, _ := MatchBrand("hono", brands); b.Slug != "hono" {
+		t.Fatalf("MatchBrand(hono) = %q, want the exact match", b.Slug)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"  Hello,   World!! ": "hello world",
+		"Ёлка":                "елка",
+		"iPhone-13/Pro":       "iphone 13 pro",
+		"Apple™":              "apple",
+		"":                    "",
+		"—":                   "",
+	} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	for in, want := range map[string]string{
+		"Xiaomi Redmi":     "xiaomi-redmi",
+		"Mercedes-Benz":    "mercedes-benz",
+		"  Land   Rover  ": "land-rover",
+		"Самсунг":          "samsung",
+		"Щётка":            "schetka",
+		"Объявление":       "obyavlenie",
+		"Ңөү":              "nou",
+		"Apple™ iPhone":    "apple-iphone",
+		"日本":               "",
+		"Xiaomi 日本":        "xiaomi",
+		"":                 "",
+	} {
+		if got := Slugify(in); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"samsung", "samsnug", 2},
+		{"айфон", "айфн", 1}, // по рунам, не по байтам
+		{"apple", "apple", 0},
+	} {
+		if got := levenshtein(tc.a, tc.b); got != tc.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+		if got := levenshtein(tc.b, tc.a); got != tc.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d (symmetric)", tc.b, tc.a, got, tc.want)
+		}
+	}
+}
+
+func TestMaxTypos(t *testing.T) {
+	for name, want := range map[string]int{
+		"lg": 0, "bmw": 0, "эпл": 0,
+		"audi": 1, "apple": 1, "айфон": 1, "xiaomi": 1, // длина — в рунах
+		"samsung": 2, "land rover": 2,
+	} {
+		if got := maxTypos(name); got != want {
+			t.Errorf("maxTypos(%q) = %d, want %d", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package catalog
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// ProductSource — источник кандидатов для сопоставления (обычно repository.CatalogRepository).
+type ProductSource interface {
+	FindCandidates(ctx context.Context, categoryID uuid.UUID, brandID *uuid.UUID) ([]models.CatalogProduct, error)
+}
+
+// DefaultMatchThreshold — минимальная похожесть, при которой объявление привязывается к модели.
+const DefaultMatchThreshold = 0.6
+
+// Match — результат сопоставления объявления с моделью каталога.
+type Match struct {
+	Product models.CatalogProduct `json:"product"`
+	Score   float64               `json:"score"`
+}
+
+// Matcher — привязывает новые объявления к существующим моделям по бренду, модели и заголовку.
+type Matcher struct {
+	brands    *BrandResolver
+	products  ProductSource
+	Threshold float64
+}
+
+func NewMatcher(brands BrandSource, products ProductSource) *Matcher {
+	return &Matcher{
+		brands:    NewBrandResolver(brands),
+		products:  products,
+		Threshold: DefaultMatchThreshold,
+	}
+}
+
+// Match — ищет лучшую модель для объявления. ok=false, если похожей модели нет.
+// Бренд берётся из attrs["brand"], а если его нет — распознаётся по заголовку.
+func (m *Matcher) Match(ctx context.Context, l models.Listing) (Match, bool, error) {
+	brandHint, _ := l.Attrs["brand"].(string)
+	if brandHint == "" {
+		brandHint = l.Title
+	}
+
+	var brandID *uuid.UUID
+	b, found, err := m.brands.Resolve(ctx, brandHint)
+	if err != nil {
+		return Match{}, false, err
+	}
+	if found {
+		brandID = &b.ID
+	}
+
+	candidates, err := m.products.FindCandidates(ctx, l.CategoryID, brandID)
+	if err != nil {
+		return Match{}, false, err
+	}
+
+	var best Match
+	for _, p := range candidates {
+		if s := Similarity(l.Title, p); s > best.Score {
+			best = Match{Product: p, Score: s}
+		}
+	}
+	return best, best.Score >= m.Threshold, nil
+}
+
+// Similarity — насколько заголовок объявления похож на модель (0..1).
+// Полное вхождение модели ("iphone 13") в заголовок почти гарантирует совпадение;
+// иначе — доля общих слов (коэффициент Жаккара) с заголовком модели.
+func Similarity(title string, p models.CatalogProduct) float64 {
+	t := Normalize(title)
+	if model := Normalize(p.Model); model != "" && containsPhrase(t, model) {
+		return 0.9 + 0.1*jaccard(strings.Fields(t), strings.Fields(Normalize(p.Title)))
+	}
+	return jaccard(strings.Fields(t), strings.Fields(Normalize(p.Title+" "+p.Model)))
+}
+
+// containsPhrase — вхождение по границам слов: "iphone 13" не должен совпасть с "iphone 13 pro".
+func containsPhrase(text, phrase string) bool {
+	words, want := strings.Fields(text), strings.Fields(phrase)
+	for i := 0; i+len(want) <= len(words); i++ {
+		match := true
+		for j := range want {
+			if words[i+j] != want[j] {
+				match = false
+				break
+			}
+		}
+		if match && (i+len(want) == len(words) || !isModelSuffix(words[i+len(want)])) {
+			return true
+		}
+	}
+	return false
+}
+
+// isModelSuffix — слова, которые меняют модель ("pro", "max", "mini", ...), а не уточняют её.
+func isModelSuffix(w string) bool {
+	switch w {
+	case "pro", "max", "mini", "plus", "ultra", "lite", "se", "про", "макс", "мини", "плюс":
+		return true
+	}
+	return false
+}
+
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(a))
+	for _, w := range a {
+		set[w] = true
+	}
+	inter, union := 0, len(set)
+	seen := map[string]bool{}
+	for _, w := range b {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		if set[w] {
+			inter++
+		} else {
+			union++
+		}
+	}
+	return float64(inter) / float64(union)
+}
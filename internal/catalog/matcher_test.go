@@ -0,0 +1,126 @@
+package catalog
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+func TestContainsPhrase(t *testing.T) {
+	for _, tc := range []struct {
+		text, phrase string
+		want         bool
+	}{
+		{"apple iphone 13 128gb", "iphone 13", true},
+		{"iphone 13", "iphone 13", true},              // в конце текста
+		{"iphone 13 black pro", "iphone 13", true},    // суффикс не сразу после модели
+		{"iphone 13 pro 256gb", "iphone 13", false},   // другая модель
+		{"iphone 13 pro max", "iphone 13 pro", false}, // и у длинной модели
+		{"iphone 13 pro", "iphone 13 pro", true},
+		{"продам iphone 13 мини", "iphone 13", false},  // суффикс по-русски
+		{"iphone 130", "iphone 13", false},             // по границам слов
+		{"iphone iphone 13", "iphone 13", true},        // не с первого вхождения
+		{"iphone 13 pro iphone 13", "iphone 13", true}, // второе вхождение подходит
+		{"", "iphone", false},
+		{"iphone", "iphone 13", false},
+	} {
+		if got := containsPhrase(tc.text, tc.phrase); got != tc.want {
+			t.Errorf("containsPhrase(%q, %q) = %v, want %v", tc.text, tc.phrase, got, tc.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	iphone := models.CatalogProduct{Model: "iPhone 13", Title: "Apple iPhone 13"}
+	galaxy := models.CatalogProduct{Title: "Galaxy S23"}
+	for _, tc := range []struct {
+		title string
+		p     models.CatalogProduct
+		want  float64
+	}{
+		// модель целиком в заголовке: 0.9 + 0.1 × Жаккар({продам iphone 13 128gb}, {apple iphone 13}) = 2/5
+		{"Продам iPhone 13, 128GB", iphone, 0.94},
+		{"Apple iPhone 13", iphone, 1},
+		// Pro Max — другая модель: только Жаккар({iphone 13 pro max}, {apple iphone 13}) = 2/5
+		{"iPhone 13 Pro Max", iphone, 0.4},
+		// без модели — Жаккар с заголовком: {samsung galaxy s23} ∩ {galaxy s23}
+		{"Samsung Galaxy S23", galaxy, 2.0 / 3},
+		{"Велосипед", galaxy, 0},
+		{"", iphone, 0},
+	} {
+		if got := Similarity(tc.title, tc.p); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("Similarity(%q, %q) = %.4f, want %.4f", tc.title, tc.p.Title, got, tc.want)
+		}
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	for _, tc := range []struct {
+		a, b []string
+		want float64
+	}{
+		{nil, []string{"a"}, 0},
+		{[]string{"a"}, nil, 0},
+		{[]string{"a", "b"}, []string{"a", "b"}, 1},
+		{[]string{"a", "a", "b"}, []string{"b", "b", "c"}, 1.0 / 3}, // повторы не считаются
+	} {
+		if got := jaccard(tc.a, tc.b); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("jaccard(%v, %v) = %.4f, want %.4f", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+// ===== Matcher на подставных источниках =====
+
+type brandList []models.Brand
+
+func (b brandList) List(context.Context) ([]models.Brand, error) { return b, nil }
+
+type productsByBrand map[uuid.UUID][]models.CatalogProduct
+
+func (p productsByBrand) FindCandidates(_ context.Context, _ uuid.UUID, brandID *uuid.UUID) ([]models.CatalogProduct, error) {
+	if brandID == nil {
+		return p[uuid.Nil], nil
+	}
+	return p[*brandID], nil
+}
+
+func TestMatcherMatch(t *testing.T) {
+	apple, samsung := testBrands[0], testBrands[1]
+	iphone := models.CatalogProduct{ID: uuid.New(), Model: "iPhone 13", Title: "Apple iPhone 13"}
+	s23 := models.CatalogProduct{ID: uuid.New(), Model: "Galaxy S23", Title: "Samsung Galaxy S23"}
+	m := NewMatcher(brandList(testBrands), productsByBrand{
+		apple.ID:   {iphone},
+		samsung.ID: {s23},
+		uuid.Nil:   {iphone, s23}, // бренд не распознан — кандидаты всей категории
+	})
+
+	for _, tc := range []struct {
+		name string
+		l    models.Listing
+		want uuid.UUID // uuid.Nil — не привязано
+	}{
+		{"brand from title", models.Listing{Title: "Айфон iPhone 13 как новый"}, iphone.ID},
+		{"brand from attrs", models.Listing{Title: "Galaxy S23 8/256", Attrs: map[string]any{"brand": "самсунг"}}, s23.ID},
+		{"attrs brand wins over title", models.Listing{Title: "iPhone 13", Attrs: map[string]any{"brand": "samsung"}}, uuid.Nil},
+		{"other model of the brand", models.Listing{Title: "iPhone 13 Pro Max"}, uuid.Nil},
+		{"brand by alias in title", models.Listing{Title: "продам galaxy s23"}, s23.ID},
+		{"nothing similar", models.Listing{Title: "продам велосипед"}, uuid.Nil},
+	} {
+		got, ok, err := m.Match(context.Background(), tc.l)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		id := uuid.Nil
+		if ok {
+			id = got.Product.ID
+		}
+		if id != tc.want {
+			t.Errorf("%s: matched %v (score %.2f), want %v", tc.name, id, got.Score, tc.want)
+		}
+	}
+}
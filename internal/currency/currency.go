@@ -0,0 +1,147 @@
+package currency
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btynybekov/marketplace/internal/logging"
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// Base — базовая валюта: все курсы хранятся как "сколько KGS за 1 единицу валюты".
+const Base = "KGS"
+
+// Provider — источник курсов (файл, внешний API, ...). Ключ — код валюты ISO 4217.
+type Provider interface {
+	Rates(ctx context.Context) (map[string]float64, error)
+}
+
+// Store — куда сохраняются курсы (обычно repository.ExchangeRatesRepository).
+type Store interface {
+	List(ctx context.Context) ([]models.ExchangeRate, error)
+	Upsert(ctx context.Context, rates []models.ExchangeRate) error
+}
+
+// Converter — потокобезопасный кэш курсов для пересчёта цен в ответах.
+type Converter struct {
+	mu    sync.RWMutex
+	rates map[string]float64
+}
+
+func NewConverter() *Converter {
+	return &Converter{rates: map[string]float64{Base: 1}}
+}
+
+// Set — заменяет курсы целиком (базовая валюта всегда = 1).
+func (c *Converter) Set(rates map[string]float64) {
+	next := make(map[string]float64, len(rates)+1)
+	for code, r := range rates {
+		if r > 0 {
+			next[strings.ToUpper(code)] = r
+		}
+	}
+	next[Base] = 1
+
+	c.mu.Lock()
+	c.rates = next
+	c.mu.Unlock()
+}
+
+// Rate — курс валюты к KGS.
+func (c *Converter) Rate(code string) (float64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r, ok := c.rates[strings.ToUpper(code)]
+	return r, ok
+}
+
+// Convert — пересчёт суммы между валютами через KGS. ok=false, если курс неизвестен.
+func (c *Converter) Convert(amount float64, from, to string) (float64, bool) {
+	rf, ok := c.Rate(from)
+	if !ok {
+		return 0, false
+	}
+	rt, ok := c.Rate(to)
+	if !ok {
+		return 0, false
+	}
+	return round2(amount * rf / rt), true
+}
+
+// Refresher — тянет курсы из Provider, сохраняет в Store и обновляет Converter.
+type Refresher struct {
+	provider Provider
+	store    Store
+	conv     *Converter
+	source   string
+}
+
+// NewRefresher — provider может быть nil: тогда используются только курсы из БД.
+func NewRefresher(provider Provider, store Store, conv *Converter, source string) *Refresher {
+	return &Refresher{provider: provider, store: store, conv: conv, source: source}
+}
+
+// Load — прогревает Converter курсами из Store (при старте).
+func (r *Refresher) Load(ctx context.Context) error {
+	list, err := r.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	rates := make(map[string]float64, len(list))
+	for _, er := range list {
+		rates[er.CurrencyCode] = er.RateToKGS
+	}
+	r.conv.Set(rates)
+	return nil
+}
+
+// Refresh — один цикл: Provider → Store → Converter.
+func (r *Refresher) Refresh(ctx context.Context) error {
+	if r.provider == nil {
+		return r.Load(ctx)
+	}
+	rates, err := r.provider.Rates(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	list := make([]models.ExchangeRate, 0, len(rates))
+	for code, rate := range rates {
+		list = append(list, models.ExchangeRate{
+			CurrencyCode: strings.ToUpper(code),
+			RateToKGS:    rate,
+			Source:       r.source,
+			UpdatedAt:    now,
+		})
+	}
+	if err := r.store.Upsert(ctx, list); err != nil {
+		return err
+	}
+	return r.Load(ctx)
+}
+
+// Run — периодическое обновление до отмены ctx.
+func (r *Refresher) Run(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := r.Refresh(ctx); err != nil {
+				slog.ErrorContext(ctx, "exchange rates refresh failed", logging.Err(err))
+			}
+		}
+	}
+}
+
+func round2(v float64) float64 {
+	if v < 0 {
+		return -round2(-v)
+	}
+	return float64(int64(v*100+0.5)) / 100
+}
@@ -0,0 +1,82 @@
+package currency
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		want map[string]float64 // nil — ошибка
+	}{
+		{"header and comments", "# курсы\ncode,rate_to_kgs\nUSD,87.45\n rub , 0.95\n", map[string]float64{"USD": 87.45, "RUB": 0.95}},
+		{"no header", "EUR,94.1\n", map[string]float64{"EUR": 94.1}},
+		{"base rate 1", "KGS,1\nUSD,87\n", map[string]float64{"KGS": 1, "USD": 87}},
+		{"empty", "", map[string]float64{}},
+		{"base rate other than 1", "KGS,2\n", nil},
+		{"one column", "USD\n", nil},
+		{"bad number after header", "code,rate\nUSD,abc\n", nil},
+		{"zero rate", "USD,0\n", nil},
+		{"negative rate", "USD,-1\n", nil},
+		{"NaN", "USD,NaN\n", nil},
+		{"Inf", "USD,+Inf\n", nil},
+		{"code is not 3 letters", "USDT,1\n", nil},
+	} {
+		got, err := ParseCSV(strings.NewReader(tc.in))
+		if tc.want == nil {
+			if err == nil {
+				t.Errorf("%s: ParseCSV = %v, want error", tc.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: ParseCSV = %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+		for code, rate := range tc.want {
+			if got[code] != rate {
+				t.Errorf("%s: %s = %v, want %v", tc.name, code, got[code], rate)
+			}
+		}
+	}
+}
+
+func TestConverter(t *testing.T) {
+	c := NewConverter()
+	if r, ok := c.Rate("kgs"); !ok || r != 1 {
+		t.Fatalf("empty converter: KGS = %v, %v", r, ok)
+	}
+	c.Set(map[string]float64{"usd": 87.45, "RUB": 0.95, "KGS": 2, "BAD": 0})
+
+	for _, tc := range []struct {
+		amount   float64
+		from, to string
+		want     float64
+		ok       bool
+	}{
+		{10, "USD", "KGS", 874.5, true},
+		{874.5, "KGS", "USD", 10, true},
+		{100, "RUB", "USD", 1.09, true}, // 95 / 87.45, округление до копеек
+		{-10, "usd", "kgs", -874.5, true},
+		{5, "KGS", "KGS", 5, true}, // KGS всегда 1, даже если передан другой курс
+		{1, "BAD", "KGS", 0, false},
+		{1, "KGS", "EUR", 0, false},
+	} {
+		got, ok := c.Convert(tc.amount, tc.from, tc.to)
+		if ok != tc.ok || math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("Convert(%v, %s, %s) = %v, %v; want %v, %v", tc.amount, tc.from, tc.to, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	c.Set(map[string]float64{"EUR": 94.1})
+	if _, ok := c.Rate("USD"); ok {
+		t.Error("Set keeps rates from the previous call")
+	}
+}
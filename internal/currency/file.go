@@ -0,0 +1,75 @@
+package currency
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FileProvider — курсы из CSV-файла для офлайн-работы.
+// Формат: "code,rate_to_kgs" на строку, строка-заголовок и строки с # пропускаются:
+//
+//	code,rate_to_kgs
+//	USD,87.45
+//	RUB,0.95
+//
+// Курс KGS, если указан, должен быть 1: Converter всегда считает его равным 1.
+type FileProvider struct {
+	Path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{Path: path}
+}
+
+func (p *FileProvider) Rates(_ context.Context) (map[string]float64, error) {
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseCSV(f)
+}
+
+// ParseCSV — разбор CSV с курсами (см. FileProvider).
+func ParseCSV(r io.Reader) (map[string]float64, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	out := map[string]float64{}
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) < 2 {
+			return nil, fmt.Errorf("rates csv line %d: expected code,rate", line)
+		}
+		code := strings.ToUpper(strings.TrimSpace(rec[0]))
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		if err != nil {
+			if line == 1 {
+				continue // заголовок
+			}
+			return nil, fmt.Errorf("rates csv line %d: %w", line, err)
+		}
+		if len(code) != 3 || rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return nil, fmt.Errorf("rates csv line %d: invalid rate %q=%v", line, code, rate)
+		}
+		if code == Base && rate != 1 {
+			return nil, fmt.Errorf("rates csv line %d: %s is the base currency, its rate must be 1, got %v", line, Base, rate)
+		}
+		out[code] = rate
+	}
+	return out, nil
+}
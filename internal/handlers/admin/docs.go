@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/openapi"
+)
+
+// Docs — описание операций AdminHandler для /openapi.json (ключ — имя метода).
+var Docs = openapi.Operations{
+	"Reports": {
+		Summary: "List abuse reports",
+		Query: []openapi.Param{
+			{Name: "status", Description: "Report status filter (default open)", Enum: []string{"open", "resolved", "rejected", "all"}},
+			{Name: "limit", Description: "Page size, 1..200 (default 50)", Type: "integer"},
+		},
+		Response: reportsResp{},
+	},
+	"ResolveReport": {
+		Summary:  "Resolve or reject an abuse report",
+		Request:  resolveReportReq{},
+		Response: models.AbuseReport{},
+	},
+	"UserRoles": {
+		Summary:  "Get user roles and effective permissions",
+		Response: models.UserAccess{},
+	},
+	"GrantRole":  {Summary: "Grant a role to a user"},
+	"RevokeRole": {Summary: "Revoke a role from a user"},
+}
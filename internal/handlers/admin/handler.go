@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// reportStatuses — допустимые значения фильтра ?status= (совпадает с CHECK в abuse_report).
+var reportStatuses = map[string]bool{"open": true, "resolved": true, "rejected": true}
+
+type resolveReportReq struct {
+	Status string `json:"status" validate:"required,enum=resolved|rejected"`
+}
+
+type reportsResp struct {
+	Items []models.AbuseReport `json:"items"`
+}
+
+// AdminHandler — модерация жалоб и управление ролями (маршруты под /admin,
+// права проверяет middleware.RequirePermission в factory).
+type AdminHandler struct {
+	repos repository.RepositorySet
+}
+
+func NewAdminHandler(repos repository.RepositorySet) *AdminHandler {
+	return &AdminHandler{repos: repos}
+}
+
+// Reports — GET /admin/reports?status=open&limit=50
+func (h *AdminHandler) Reports() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		status := q.Get("status")
+		if status == "" {
+			status = "open"
+		}
+		if status == "all" {
+			status = ""
+		} else if !reportStatuses[status] {
+			shared.BadRequest(w, r, "status must be open, resolved, rejected or all")
+			return
+		}
+		limit := 50
+		if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 && v <= 200 {
+			limit = v
+		}
+
+		list, err := h.repos.Reports().List(r.Context(), status, limit)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, reportsResp{Items: list})
+	})
+}
+
+// ResolveReport — PATCH /admin/reports/{id} {status: resolved|rejected}
+func (h *AdminHandler) ResolveReport() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			shared.BadRequest(w, r, "invalid report id")
+			return
+		}
+		var req resolveReportReq
+		if !shared.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		rep, err := h.repos.Reports().Resolve(r.Context(), id, req.Status, uid)
+		if errors.Is(err, pgx.ErrNoRows) {
+			shared.NotFound(w, r, "report not found")
+			return
+		}
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, rep)
+	})
+}
+
+// UserRoles — GET /admin/users/{id}/roles: роли и итоговые права пользователя.
+func (h *AdminHandler) UserRoles() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := userID(w, r)
+		if !ok {
+			return
+		}
+		a, err := h.repos.Roles().Access(r.Context(), id)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, a)
+	})
+}
+
+// GrantRole — PUT /admin/users/{id}/roles/{role}
+func (h *AdminHandler) GrantRole() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := userID(w, r)
+		if !ok {
+			return
+		}
+		err := h.repos.Roles().Grant(r.Context(), id, mux.Vars(r)["role"])
+		if errors.Is(err, pgx.ErrNoRows) {
+			shared.BadRequest(w, r, "unknown role")
+			return
+		}
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// RevokeRole — DELETE /admin/users/{id}/roles/{role}
+func (h *AdminHandler) RevokeRole() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := userID(w, r)
+		if !ok {
+			return
+		}
+		if err := h.repos.Roles().Revoke(r.Context(), id, mux.Vars(r)["role"]); err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func userID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		shared.BadRequest(w, r, "invalid user id")
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,9 @@
+package assistant
+
+import "github.com/btynybekov/marketplace/internal/openapi"
+
+// Docs — описание операций AssistantHandler для /openapi.json (ключ — роль ассистента).
+var Docs = openapi.Operations{
+	"Buyer":  {Summary: "Ask the buyer assistant", Request: AssistantRequest{}, Response: AssistantResponse{}},
+	"Seller": {Summary: "Ask the seller assistant", Request: AssistantRequest{}, Response: AssistantResponse{}},
+}
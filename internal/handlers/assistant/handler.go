@@ -3,14 +3,21 @@ package assistant
 import (
 	"bytes"
 	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/logging"
 )
 
 type AssistantRequest struct {
-	UserID string                 `json:"userId"`
-	Task   string                 `json:"task"`
-	Data   map[string]interface{} `json:"data"`
+	UserID string                 `json:"userId" validate:"max=64"`
+	Task   string                 `json:"task" validate:"required,max=100"`
+	Data   map[string]interface{} `json:"data" validate:"max=50"`
 }
 
 type AssistantResponse struct {
@@ -19,16 +26,24 @@ type AssistantResponse struct {
 
 type AssistantHandler struct {
 	N8nWebhookURL string
+	client        *http.Client
 }
 
-func NewAssistantHandler(url string) *AssistantHandler {
-	return &AssistantHandler{N8nWebhookURL: url}
+// NewAssistantHandler — прокси к webhook-у n8n; timeout <= 0 — 30 секунд.
+func NewAssistantHandler(url string, timeout time.Duration) *AssistantHandler {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &AssistantHandler{
+		N8nWebhookURL: url,
+		// otelhttp: спан вызова webhook и traceparent для n8n
+		client: &http.Client{Timeout: timeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
 }
 
 func (h *AssistantHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var req AssistantRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+	if !shared.DecodeJSON(w, r, &req) {
 		return
 	}
 
@@ -37,21 +52,29 @@ func (h *AssistantHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	bodyBytes, _ := json.Marshal(req)
-	resp, err := http.Post(h.N8nWebhookURL, "application/json", bytes.NewBuffer(bodyBytes))
+	upstream, err := http.NewRequestWithContext(r.Context(), http.MethodPost, h.N8nWebhookURL, bytes.NewReader(bodyBytes))
 	if err != nil {
-		log.Printf("Ошибка вызова n8n: %v", err)
-		http.Error(w, "failed to call n8n", http.StatusInternalServerError)
+		shared.Error(w, r, apperror.Upstream("assistant is unavailable", fmt.Errorf("n8n request: %w", err)))
+		return
+	}
+	upstream.Header.Set("Content-Type", "application/json")
+	logging.Propagate(upstream)
+	resp, err := h.client.Do(upstream)
+	if err != nil {
+		shared.Error(w, r, apperror.Upstream("assistant is unavailable", fmt.Errorf("call n8n: %w", err)))
 		return
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		shared.Error(w, r, apperror.Upstream("assistant is unavailable", fmt.Errorf("n8n responded %s", resp.Status)))
+		return
+	}
 
 	var assistantResp AssistantResponse
 	if err := json.NewDecoder(resp.Body).Decode(&assistantResp); err != nil {
-		log.Printf("Ошибка декодирования ответа n8n: %v", err)
-		http.Error(w, "invalid response from n8n", http.StatusInternalServerError)
+		shared.Error(w, r, apperror.Upstream("assistant is unavailable", fmt.Errorf("decode n8n response: %w", err)))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(assistantResp)
+	shared.WriteJSON(w, http.StatusOK, assistantResp)
 }
@@ -0,0 +1,40 @@
+package brands
+
+import (
+	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/openapi"
+)
+
+// Docs — описание операций BrandHandler для /openapi.json (ключ — имя метода).
+var Docs = openapi.Operations{
+	"List": {
+		Summary:     "List brands",
+		Description: "Without category_slug returns all brands; with it returns brand facets (active listing counts) of the category.",
+		Query:       []openapi.Param{{Name: "category_slug", Description: "Category to build facets for"}},
+		Response:    openapi.OneOf{brandsResp{}, facetsResp{}},
+	},
+	"Get": {
+		Summary:  "Get a brand by slug",
+		Response: models.Brand{},
+	},
+	"Resolve": {
+		Summary:  "Resolve a brand by name or alias",
+		Query:    []openapi.Param{{Name: "q", Description: "Brand name in any spelling", Required: true}},
+		Response: models.Brand{},
+	},
+	"Create": {
+		Summary:  "Create a brand",
+		Request:  brandReq{},
+		Response: models.Brand{},
+		Status:   http.StatusCreated,
+	},
+	"Update": {
+		Summary:     "Update a brand",
+		Description: "Aliases are replaced as a whole.",
+		Request:     brandReq{},
+		Response:    models.Brand{},
+	},
+	"Delete": {Summary: "Delete a brand"},
+}
@@ -0,0 +1,182 @@
+package brands
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/catalog"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+type brandReq struct {
+	Name    string   `json:"name" validate:"required,max=100"`
+	Slug    string   `json:"slug,omitempty" validate:"max=100"`
+	Aliases []string `json:"aliases,omitempty" validate:"max=50"`
+}
+
+type brandsResp struct {
+	Brands []models.Brand `json:"brands"`
+}
+
+// facetsResp — ответ GET /brands?category_slug=...
+type facetsResp struct {
+	Facets []models.BrandFacet `json:"facets"`
+}
+
+// BrandHandler — CRUD брендов, фасеты по категории и нечёткий поиск бренда.
+type BrandHandler struct {
+	repos    repository.RepositorySet
+	resolver *catalog.BrandResolver
+}
+
+func NewBrandHandler(repos repository.RepositorySet) *BrandHandler {
+	return &BrandHandler{repos: repos, resolver: catalog.NewBrandResolver(repos.Brands())}
+}
+
+// List — GET /brands[?category_slug=phones]
+// С category_slug возвращает фасеты (бренд + количество объявлений), без него — все бренды.
+func (h *BrandHandler) List() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if slug := strings.TrimSpace(r.URL.Query().Get("category_slug")); slug != "" {
+			facets, err := h.repos.Brands().FacetsByCategorySlug(ctx, slug)
+			if err != nil {
+				shared.InternalError(w, r, err)
+				return
+			}
+			shared.WriteJSON(w, http.StatusOK, facetsResp{Facets: facets})
+			return
+		}
+
+		list, err := h.repos.Brands().List(ctx)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, brandsResp{Brands: list})
+	})
+}
+
+// Get — GET /brands/{slug}
+func (h *BrandHandler) Get() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := h.repos.Brands().GetBySlug(r.Context(), mux.Vars(r)["slug"])
+		if errors.Is(err, pgx.ErrNoRows) {
+			shared.NotFound(w, r, "brand not found")
+			return
+		}
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, b)
+	})
+}
+
+// Resolve — GET /brands/resolve?q=айфон → бренд, найденный по названию/алиасу.
+func (h *BrandHandler) Resolve() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			shared.BadRequest(w, r, "q is required")
+			return
+		}
+		b, ok, err := h.resolver.Resolve(r.Context(), q)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		if !ok {
+			shared.NotFound(w, r, "brand not found")
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, b)
+	})
+}
+
+// Create — POST /brands
+func (h *BrandHandler) Create() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := decodeBrand(w, r)
+		if !ok {
+			return
+		}
+		created, err := h.repos.Brands().Create(r.Context(), b)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusCreated, created)
+	})
+}
+
+// Update — PUT /brands/{slug} (алиасы заменяются целиком).
+func (h *BrandHandler) Update() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		cur, err := h.repos.Brands().GetBySlug(ctx, mux.Vars(r)["slug"])
+		if errors.Is(err, pgx.ErrNoRows) {
+			shared.NotFound(w, r, "brand not found")
+			return
+		}
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+
+		b, ok := decodeBrand(w, r)
+		if !ok {
+			return
+		}
+		b.ID = cur.ID
+		updated, err := h.repos.Brands().Update(ctx, b)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, updated)
+	})
+}
+
+// Delete — DELETE /brands/{slug}
+func (h *BrandHandler) Delete() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		b, err := h.repos.Brands().GetBySlug(ctx, mux.Vars(r)["slug"])
+		if errors.Is(err, pgx.ErrNoRows) {
+			shared.NotFound(w, r, "brand not found")
+			return
+		}
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		if err := h.repos.Brands().Delete(ctx, b.ID); err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func decodeBrand(w http.ResponseWriter, r *http.Request) (models.Brand, bool) {
+	var req brandReq
+	if !shared.DecodeJSON(w, r, &req) {
+		return models.Brand{}, false
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Slug == "" {
+		req.Slug = catalog.Slugify(req.Name)
+	}
+	if req.Slug == "" {
+		shared.BadRequest(w, r, "slug is required")
+		return models.Brand{}, false
+	}
+	return models.Brand{Name: req.Name, Slug: req.Slug, Aliases: req.Aliases}, true
+}
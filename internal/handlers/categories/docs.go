@@ -0,0 +1,16 @@
+package categories
+
+import (
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/openapi"
+)
+
+// Docs — описание операций пакета для /openapi.json (ключ — имя метода).
+var Docs = openapi.Operations{
+	"List": {
+		Summary:     "List categories",
+		Description: "Root categories, or children of parent_slug.",
+		Query:       []openapi.Param{{Name: "parent_slug", Description: "Parent category slug"}},
+		Response:    []models.Category{},
+	},
+}
@@ -1,72 +1,68 @@
 package categories
 
 import (
-	"html/template"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/models"
 	"github.com/btynybekov/marketplace/internal/repository"
 )
 
 type CategoryHandler struct {
 	repos repository.RepositorySet
-	tmpl  *template.Template // ожидается layout с именем "categories.html"
+	tmpl  shared.Templates // страница "categories.html"
 }
 
-func NewCategoryHandler(repos repository.RepositorySet, tmpl *template.Template) *CategoryHandler {
+func NewCategoryHandler(repos repository.RepositorySet, tmpl shared.Templates) *CategoryHandler {
 	return &CategoryHandler{repos: repos, tmpl: tmpl}
 }
 
-// ServeHTTP позволяет использовать как r.Handle("/categories", h)
-func (h *CategoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		h.handleList(w, r)
-	default:
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-	}
+// List — GET /categories[?parent_slug=transport] (JSON API)
+func (h *CategoryHandler) List() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list, _, ok := h.load(w, r)
+		if !ok {
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, list)
+	})
+}
+
+// Page — GET /categories (веб-роутер): HTML или JSON по Accept.
+func (h *CategoryHandler) Page() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list, parent, ok := h.load(w, r)
+		if !ok {
+			return
+		}
+		shared.Render(w, r, h.tmpl, "categories.html", map[string]any{
+			"Categories": list,
+			"ParentSlug": parent,
+		}, list)
+	})
 }
 
-// handleList — GET /categories[?parent_slug=transport]
-func (h *CategoryHandler) handleList(w http.ResponseWriter, r *http.Request) {
+// load — корневые категории или дочерние для ?parent_slug=.
+func (h *CategoryHandler) load(w http.ResponseWriter, r *http.Request) ([]models.Category, string, bool) {
 	ctx := r.Context()
 	parent := strings.TrimSpace(r.URL.Query().Get("parent_slug"))
 
 	var (
-		data any
+		list []models.Category
 		err  error
 	)
 	if parent == "" {
-		data, err = h.repos.Categories().ListRoots(ctx)
+		list, err = h.repos.Categories().ListRoots(ctx)
 	} else {
-		data, err = h.repos.Categories().ListChildrenBySlug(ctx, parent)
+		list, err = h.repos.Categories().ListChildrenBySlug(ctx, parent)
 	}
 	if err != nil {
-		shared.InternalError(w, err)
-		return
-	}
-
-	// Если клиент просит JSON — отдадим JSON
-	if acceptsJSON(r) || h.tmpl == nil || h.tmpl.Lookup("categories.html") == nil {
-		shared.WriteJSON(w, http.StatusOK, data)
-		return
+		shared.InternalError(w, r, err)
+		return nil, "", false
 	}
-
-	// Иначе отрисуем HTML
-	if err := h.tmpl.ExecuteTemplate(w, "categories.html", map[string]any{
-		"Categories": data,
-		"ParentSlug": parent,
-	}); err != nil {
-		http.Error(w, "template render error: "+err.Error(), http.StatusInternalServerError)
-	}
-}
-
-func acceptsJSON(r *http.Request) bool {
-	accept := r.Header.Get("Accept")
-	return strings.Contains(strings.ToLower(accept), "application/json") ||
-		strings.Contains(strings.ToLower(accept), "json")
+	return list, parent, true
 }
 
 // helper (иногда полезен для query-параметров int)
@@ -0,0 +1,31 @@
+package chat
+
+import "github.com/btynybekov/marketplace/internal/openapi"
+
+var sessionQuery = openapi.Param{Name: "session_id", Required: true}
+
+// Docs — описание операций ChatHandler для /openapi.json (ключ — имя метода).
+var Docs = openapi.Operations{
+	"StartSession": {
+		Summary:     "Start or extend a chat session",
+		Description: "Pass session_id to extend an existing session of the same user.",
+		Request:     startSessionReq{},
+		Response:    startSessionResp{},
+	},
+	"EndSession": {Summary: "End a chat session", Query: []openapi.Param{sessionQuery}},
+	"SendMessage": {
+		Summary:     "Send a message to the assistant",
+		Description: "Consumes LLM tokens: rate limited and counted against the daily quota.",
+		Request:     sendMessageReq{},
+		Response:    sendMessageResp{},
+	},
+	"GetHistory": {
+		Summary: "Get chat history",
+		Query: []openapi.Param{
+			sessionQuery,
+			{Name: "limit", Description: "Page size, 1..200 (default 50)", Type: "integer"},
+			{Name: "cursor", Description: "next_cursor of the previous page (earlier messages)"},
+		},
+		Response: historyResp{},
+	},
+}
@@ -1,9 +1,9 @@
 package chat
 
 import (
-	"html/template"
 	"net/http"
 
+	"github.com/btynybekov/marketplace/internal/apperror"
 	"github.com/btynybekov/marketplace/internal/handlers/shared"
 	"github.com/btynybekov/marketplace/internal/repository"
 )
@@ -11,7 +11,7 @@ import (
 // ChatPageHandler — хендлер страницы чата (GET /chat).
 type ChatPageHandler struct {
 	repos repository.RepositorySet
-	tmpl  *template.Template // ожидается шаблон "chat.html" (если используешь HTML)
+	tmpl  shared.Templates // страница "chat.html"; без шаблонов — только JSON
 }
 
 // NewChatHandler — конструктор страницы чата.
@@ -19,26 +19,17 @@ func NewChatHandler(repos repository.RepositorySet) *ChatPageHandler {
 	return &ChatPageHandler{repos: repos}
 }
 
-// WithTemplate — опционально подвязать html/template.
-func (h *ChatPageHandler) WithTemplate(t *template.Template) *ChatPageHandler {
+// WithTemplate — опционально подвязать HTML-шаблоны (web.Templates).
+func (h *ChatPageHandler) WithTemplate(t shared.Templates) *ChatPageHandler {
 	h.tmpl = t
 	return h
 }
 
 func (h *ChatPageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		shared.Error(w, r, apperror.MethodNotAllowed())
 		return
 	}
 
-	if h.tmpl == nil || h.tmpl.Lookup("chat.html") == nil {
-		shared.WriteJSON(w, http.StatusOK, map[string]any{
-			"message": "chat page",
-		})
-		return
-	}
-
-	if err := h.tmpl.ExecuteTemplate(w, "chat.html", nil); err != nil {
-		http.Error(w, "template render error: "+err.Error(), http.StatusInternalServerError)
-	}
+	shared.Render(w, r, h.tmpl, "chat.html", nil, pageResp{Message: "chat page"})
 }
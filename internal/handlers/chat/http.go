@@ -1,13 +1,14 @@
 package chat
 
 import (
-	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/pagination"
 )
 
 //
@@ -15,17 +16,16 @@ import (
 //
 
 type startSessionReq struct {
-	SessionID string `json:"session_id,omitempty"`
-	UserID    string `json:"user_id,omitempty"`
+	SessionID string `json:"session_id,omitempty" validate:"max=64"` // продлить существующую сессию
 }
 type startSessionResp struct {
 	SessionID string `json:"session_id"`
 }
 
 type sendMessageReq struct {
-	SessionID string            `json:"session_id"`
-	Text      string            `json:"text"`
-	Meta      map[string]string `json:"meta,omitempty"`
+	SessionID string            `json:"session_id" validate:"required,max=64"`
+	Text      string            `json:"text" validate:"required,max=4000"`
+	Meta      map[string]string `json:"meta,omitempty" validate:"max=20"`
 }
 
 // messageDTO используется и в service.go (тот же пакет chat)
@@ -43,9 +43,15 @@ type sendMessageResp struct {
 	NewMessages []messageDTO `json:"new_messages,omitempty"`
 }
 
+// pageResp — GET /chat без HTML-шаблона.
+type pageResp struct {
+	Message string `json:"message"`
+}
+
 type historyResp struct {
-	SessionID string       `json:"session_id"`
-	Messages  []messageDTO `json:"messages"`
+	SessionID  string       `json:"session_id"`
+	Messages   []messageDTO `json:"messages"`
+	NextCursor string       `json:"next_cursor,omitempty"` // для подгрузки более ранних сообщений
 }
 
 //
@@ -54,61 +60,73 @@ type historyResp struct {
 
 // ChatHandler — HTTP-обёртка над сервисом чата (бизнес-логика в service.go).
 type ChatHandler struct {
-	svc Service
+	svc     Service
+	cursors *pagination.Codec
 }
 
 // NewChatHTTP — конструктор HTTP-хендлера для API чата.
-func NewChatHTTP(svc Service) *ChatHandler { return &ChatHandler{svc: svc} }
+func NewChatHTTP(svc Service, cursors *pagination.Codec) *ChatHandler {
+	return &ChatHandler{svc: svc, cursors: cursors}
+}
 
 // StartSession — POST /chat/session
 func (h *ChatHandler) StartSession() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req startSessionReq
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			shared.BadRequest(w, "invalid JSON")
+		if !shared.DecodeJSON(w, r, &req) {
 			return
 		}
 
-		sid, err := h.svc.StartSession(r, req.UserID, req.SessionID)
+		sid, err := h.svc.StartSession(r, req.SessionID)
 		if err != nil {
-			shared.InternalError(w, err)
+			shared.Error(w, r, err)
 			return
 		}
 		shared.WriteJSON(w, http.StatusOK, startSessionResp{SessionID: sid})
 	})
 }
 
+// EndSession — DELETE /chat/session?session_id=...
+func (h *ChatHandler) EndSession() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sid := r.URL.Query().Get("session_id")
+		if sid == "" {
+			shared.BadRequest(w, r, "session_id is required")
+			return
+		}
+		if err := h.svc.EndSession(r, sid); err != nil {
+			shared.Error(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
 // SendMessage — POST /chat/ajax
 // Принимает текст пользователя, сохраняет его (если реализовано в сервисе),
 // генерирует ответ ассистента: обычная беседа или вызов buyer/seller по контексту.
 func (h *ChatHandler) SendMessage() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req sendMessageReq
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			shared.BadRequest(w, "invalid JSON")
-			return
-		}
-		if req.SessionID == "" || req.Text == "" {
-			shared.BadRequest(w, "session_id and text are required")
+		if !shared.DecodeJSON(w, r, &req) {
 			return
 		}
 
 		// сохраняем сообщение пользователя (опционально — внутри сервиса)
 		if _, err := h.svc.AppendUserMessage(r, req.SessionID, req.Text, req.Meta); err != nil {
-			shared.InternalError(w, err)
+			shared.Error(w, r, err)
 			return
 		}
 
 		// генерируем ответ (LLM или buyer/seller ветка)
 		reply, extra, err := h.svc.GenerateAssistantReply(r, req.SessionID)
 		if err != nil {
-			shared.InternalError(w, err)
+			shared.Error(w, r, err)
 			return
 		}
 
 		resp := sendMessageResp{
 			Reply: messageDTO{
-				ID:        "", // если сервис вернёт ID — подставь сюда
 				Role:      "assistant",
 				Text:      reply,
 				CreatedAt: time.Now().UTC(),
@@ -122,6 +140,9 @@ func (h *ChatHandler) SendMessage() http.Handler {
 					resp.Top3 = top
 				}
 			}
+			if v, ok := extra["message_id"].(string); ok {
+				resp.Reply.ID = v
+			}
 			if v, ok := extra["filter_url"]; ok {
 				if s, ok2 := v.(string); ok2 {
 					resp.FilterURL = s
@@ -135,6 +156,7 @@ func (h *ChatHandler) SendMessage() http.Handler {
 }
 
 // GetHistory — GET /chat/history или /chat/history/{session_id}
+// Пагинация: ?limit=50&cursor=<next_cursor из предыдущего ответа> — более ранние сообщения.
 func (h *ChatHandler) GetHistory() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// поддерживаем и query, и path-переменную
@@ -145,18 +167,40 @@ func (h *ChatHandler) GetHistory() http.Handler {
 			}
 		}
 		if sid == "" {
-			shared.BadRequest(w, "session_id is required")
+			shared.BadRequest(w, r, "session_id is required")
 			return
 		}
 
-		msgs, err := h.svc.GetHistory(r, sid, 50)
+		limit := 50
+		if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 200 {
+			limit = v
+		}
+		var before *pagination.Cursor
+		if tok := r.URL.Query().Get("cursor"); tok != "" {
+			c, err := h.cursors.DecodeScope(tok, historyScope(sid))
+			if err != nil {
+				shared.BadRequest(w, r, "invalid cursor")
+				return
+			}
+			before = &c
+		}
+
+		msgs, next, err := h.svc.GetHistory(r, sid, before, limit)
 		if err != nil {
-			shared.InternalError(w, err)
+			shared.Error(w, r, err)
 			return
 		}
-		shared.WriteJSON(w, http.StatusOK, historyResp{
+		resp := historyResp{
 			SessionID: sid,
 			Messages:  msgs,
-		})
+		}
+		if next != nil {
+			next.Scope = historyScope(sid)
+			resp.NextCursor = h.cursors.Encode(*next)
+		}
+		shared.WriteJSON(w, http.StatusOK, resp)
 	})
 }
+
+// historyScope — курсор истории действителен только для своей сессии.
+func historyScope(sid string) string { return "chat:" + sid }
@@ -3,23 +3,54 @@ package chat
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/btynybekov/marketplace/config"
 	"github.com/btynybekov/marketplace/internal/ai"
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/logging"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/realtime"
 	"github.com/btynybekov/marketplace/internal/repository"
+	"github.com/btynybekov/marketplace/internal/tracing"
+)
+
+// tracer — спаны шагов ответа ассистента: классификация → LLM или webhook → БД.
+var tracer = tracing.Tracer("chat")
+
+// Ошибки сессий (для клиента — 404/403).
+var (
+	ErrSessionNotFound  = apperror.NotFound("chat session not found or expired")
+	ErrSessionForbidden = apperror.Forbidden("chat session belongs to another user")
 )
 
 // Service — интерфейс, который использует твой ChatHandler (http.go).
 type Service interface {
-	StartSession(r *http.Request, userID, sessionID string) (string, error)
+	// StartSession — продлевает живую сессию sessionID или создаёт новую.
+	// Пользователь берётся из контекста запроса: анонимная сессия привязывается к нему при логине.
+	StartSession(r *http.Request, sessionID string) (string, error)
+	EndSession(r *http.Request, sessionID string) error
 	AppendUserMessage(r *http.Request, sessionID, text string, meta map[string]string) (string, error)
 	GenerateAssistantReply(r *http.Request, sessionID string) (reply string, extra map[string]any, err error)
-	GetHistory(r *http.Request, sessionID string, limit int) ([]messageDTO, error)
+	// GetHistory — страница истории до курсора before (nil — самые новые);
+	// next — курсор для загрузки более ранних сообщений (nil, если их нет).
+	GetHistory(r *http.Request, sessionID string, before *pagination.Cursor, limit int) (msgs []messageDTO, next *pagination.Cursor, err error)
 }
 
 // service — конкретная реализация Service.
@@ -28,78 +59,264 @@ type service struct {
 	ai         ai.Client
 	httpClient *http.Client
 	cfg        config.EnvConfig
+	events     realtime.Publisher // ответы ассистента дублируются в WebSocket пользователя
 }
 
 // NewService — создаёт новый сервис чата.
-func NewService(repos repository.RepositorySet, aiClient ai.Client, httpClient *http.Client, cfg config.EnvConfig) Service {
+func NewService(repos repository.RepositorySet, aiClient ai.Client, httpClient *http.Client, cfg config.EnvConfig, events realtime.Publisher) Service {
 	if httpClient == nil {
-		httpClient = &http.Client{Timeout: 15 * time.Second}
+		// otelhttp: спан исходящего вызова и traceparent для n8n
+		timeout := cfg.N8NTimeout
+		if timeout <= 0 {
+			timeout = 15 * time.Second
+		}
+		httpClient = &http.Client{Timeout: timeout, Transport: otelhttp.NewTransport(http.DefaultTransport)}
 	}
 	return &service{
 		repos:      repos,
 		ai:         aiClient,
 		httpClient: httpClient,
 		cfg:        cfg,
+		events:     events,
 	}
 }
 
-// StartSession — создаёт (или возвращает) session_id.
-func (s *service) StartSession(_ *http.Request, userID, sessionID string) (string, error) {
+// StartSession — возвращает session_id: прежний, если сессия жива, иначе новый.
+func (s *service) StartSession(r *http.Request, sessionID string) (string, error) {
 	if sessionID != "" {
-		return sessionID, nil
+		_, err := s.session(r, sessionID)
+		if !errors.Is(err, ErrSessionNotFound) {
+			return sessionID, err
+		}
+		// истекла или неизвестна — выдаём новую
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return "", err
+	}
+	sess := models.ChatSession{ID: id, CreatedAt: time.Now().UTC()}
+	sess.ExpiresAt = sess.CreatedAt.Add(s.cfg.ChatSessionTTL)
+	if uid, ok := middleware.UserIDFromContext(r.Context()); ok {
+		sess.UserID = &uid
+	}
+	if err := s.repos.ChatSessions().Create(r.Context(), sess); err != nil {
+		return "", err
 	}
-	return "sess-" + time.Now().Format("20060102150405"), nil
+	return id, nil
 }
 
-// AppendUserMessage — сохраняет сообщение пользователя (если нужно).
-func (s *service) AppendUserMessage(_ *http.Request, sessionID, text string, meta map[string]string) (string, error) {
+// EndSession — завершает сессию; история разговора остаётся у пользователя.
+func (s *service) EndSession(r *http.Request, sessionID string) error {
+	if _, err := s.session(r, sessionID); err != nil {
+		return err
+	}
+	return s.repos.ChatSessions().Delete(r.Context(), sessionID)
+}
+
+// AppendUserMessage — сохраняет сообщение пользователя в историю разговора.
+func (s *service) AppendUserMessage(r *http.Request, sessionID, text string, meta map[string]string) (string, error) {
 	if sessionID == "" || text == "" {
-		return "", errors.New("session_id and text required")
+		return "", apperror.BadRequest("session_id and text are required")
+	}
+	if _, err := s.session(r, sessionID); err != nil {
+		return "", err
+	}
+	ctx := r.Context()
+	var userID *uuid.UUID
+	if uid, ok := middleware.UserIDFromContext(ctx); ok {
+		userID = &uid
 	}
-	// TODO: сохранить в таблицу message (если хочешь вести историю)
-	return "msg-" + time.Now().Format("150405"), nil
+	conv, err := s.repos.Conversations().GetOrCreateBySession(ctx, sessionID, userID)
+	if err != nil {
+		return "", err
+	}
+	id, err := s.repos.Messages().Append(ctx, conv.ID, "user", text, meta)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
 }
 
-// GetHistory — возвращает историю сообщений.
-func (s *service) GetHistory(_ *http.Request, sessionID string, limit int) ([]messageDTO, error) {
-	// TODO: извлечь последние N сообщений из БД
-	return []messageDTO{}, nil
+// GetHistory — возвращает страницу истории сообщений (по возрастанию времени).
+func (s *service) GetHistory(r *http.Request, sessionID string, before *pagination.Cursor, limit int) ([]messageDTO, *pagination.Cursor, error) {
+	if _, err := s.session(r, sessionID); err != nil {
+		return nil, nil, err
+	}
+	ctx := r.Context()
+	conv, err := s.repos.Conversations().GetBySession(ctx, sessionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return []messageDTO{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// берём на одно больше, чтобы понять, есть ли ещё более ранние сообщения
+	msgs, err := s.repos.Messages().ListBefore(ctx, conv.ID, before, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	var next *pagination.Cursor
+	if len(msgs) > limit {
+		msgs = msgs[1:]
+		next = &pagination.Cursor{CreatedAt: msgs[0].CreatedAt, ID: msgs[0].ID}
+	}
+
+	out := make([]messageDTO, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, messageDTO{ID: m.ID.String(), Role: m.Role, Text: m.Text, CreatedAt: m.CreatedAt})
+	}
+	return out, next, nil
 }
 
 // GenerateAssistantReply — решает, что делать: болталка или buyer/seller.
-func (s *service) GenerateAssistantReply(r *http.Request, sessionID string) (string, map[string]any, error) {
-	ctx := r.Context()
-	userText := extractLastUserText(r)
+// Ответ ассистента сохраняется в историю, его ID возвращается в extra["message_id"].
+func (s *service) GenerateAssistantReply(r *http.Request, sessionID string) (reply string, extra map[string]any, err error) {
+	ctx, span := tracer.Start(r.Context(), "chat.generate_reply")
+	defer func() { endSpan(span, err) }()
+	r = r.WithContext(ctx)
+
+	if _, err := s.session(r, sessionID); err != nil {
+		return "", nil, err
+	}
+	conv, err := s.repos.Conversations().GetBySession(ctx, sessionID)
+	if err != nil {
+		return "", nil, err
+	}
+	userText, err := s.lastUserText(ctx, conv.ID)
+	if err != nil {
+		return "", nil, err
+	}
 
 	// определить намерение (buy/sell/chitchat)
 	intent, err := s.classifyIntent(ctx, userText)
 	if err != nil {
 		intent = "chitchat"
 	}
+	span.SetAttributes(attribute.String("chat.intent", intent))
 
 	switch intent {
 	case "buy":
-		return s.handleBuyer(ctx, sessionID, userText)
+		reply, extra, err = s.handleBuyer(ctx, sessionID, userText)
 	case "sell":
-		return s.handleSeller(ctx, sessionID, userText)
+		reply, extra, err = s.handleSeller(ctx, sessionID, userText)
 	default:
 		// просто болталка
-		reply, err := s.ai.Chat(ctx, s.cfg.AIModel, s.cfg.AITemperature, []ai.Message{
+		reply, err = s.ai.Chat(ctx, s.cfg.AIModel, s.cfg.AITemperature, []ai.Message{
 			{Role: "system", Content: "Ты дружелюбный помощник маркетплейса."},
 			{Role: "user", Content: userText},
 		})
-		if err != nil {
-			return "", nil, err
+	}
+	if err != nil {
+		return "", nil, apperror.Upstream("assistant is unavailable", err)
+	}
+
+	id, err := s.repos.Messages().Append(ctx, conv.ID, "assistant", reply, map[string]string{"intent": intent})
+	if err != nil {
+		return "", nil, err
+	}
+	if extra == nil {
+		extra = map[string]any{}
+	}
+	extra["message_id"] = id.String()
+
+	// уведомления из outbox (напр. новые объявления по сохранённым поискам) — дописываем к ответу
+	if conv.UserID != nil {
+		if notes := s.takeNotifications(ctx, *conv.UserID); len(notes) > 0 {
+			texts := make([]string, 0, len(notes))
+			for _, n := range notes {
+				texts = append(texts, n.Text)
+			}
+			reply += "\n\n" + strings.Join(texts, "\n")
+			extra["notifications"] = notes
+		}
+		if s.events != nil {
+			data := map[string]any{
+				"session_id": sessionID,
+				"message":    messageDTO{ID: id.String(), Role: "assistant", Text: reply, CreatedAt: time.Now().UTC()},
+			}
+			if v, ok := extra["filter_url"]; ok {
+				data["filter_url"] = v
+			}
+			s.events.Publish(ctx, *conv.UserID, realtime.Event{Type: realtime.EventAssistantReply, Data: data})
 		}
-		return reply, nil, nil
 	}
+	return reply, extra, nil
 }
 
 //
 // ─── PRIVATE HELPERS ───────────────────────────────────────────────────────────
 //
 
-func (s *service) classifyIntent(ctx context.Context, text string) (string, error) {
+// session — проверяет, что сессия жива и доступна текущему пользователю, и продлевает её.
+// Анонимная сессия при первом запросе с токеном привязывается к пользователю вместе с разговором.
+func (s *service) session(r *http.Request, id string) (models.ChatSession, error) {
+	ctx := r.Context()
+	sess, err := s.repos.ChatSessions().Get(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sess, ErrSessionNotFound
+	}
+	if err != nil {
+		return sess, err
+	}
+	now := time.Now().UTC()
+	if now.After(sess.ExpiresAt) {
+		return sess, ErrSessionNotFound
+	}
+
+	uid, authed := middleware.UserIDFromContext(ctx)
+	switch {
+	case sess.UserID != nil && (!authed || *sess.UserID != uid):
+		return sess, ErrSessionForbidden
+	case sess.UserID == nil && authed:
+		if err := s.repos.ChatSessions().BindUser(ctx, id, uid); err != nil {
+			return sess, err
+		}
+		sess.UserID = &uid
+	}
+
+	sess.ExpiresAt = now.Add(s.cfg.ChatSessionTTL)
+	if err := s.repos.ChatSessions().Touch(ctx, id, sess.ExpiresAt); err != nil {
+		return sess, err
+	}
+	return sess, nil
+}
+
+// newSessionID — 256 бит из crypto/rand: ID нельзя угадать или получить совпадение.
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "sess_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PurgeExpiredSessions — периодически удаляет истёкшие сессии до отмены ctx.
+func PurgeExpiredSessions(ctx context.Context, sessions repository.ChatSessionsRepository, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if n, err := sessions.DeleteExpired(ctx, time.Now().UTC()); err != nil {
+				slog.ErrorContext(ctx, "chat sessions purge failed", logging.Err(err))
+			} else if n > 0 {
+				slog.InfoContext(ctx, "chat sessions purged", slog.Int64("expired", n))
+			}
+		}
+	}
+}
+
+func (s *service) classifyIntent(ctx context.Context, text string) (intent string, err error) {
+	ctx, span := tracer.Start(ctx, "chat.classify_intent")
+	defer func() {
+		span.SetAttributes(attribute.String("chat.intent", intent))
+		endSpan(span, err)
+	}()
+
 	prompt := `Определи намерение пользователя как одно слово из списка [buy, sell, chitchat].
 Текст: ` + text
 
@@ -123,18 +340,21 @@ func (s *service) classifyIntent(ctx context.Context, text string) (string, erro
 }
 
 // handleBuyer — вызывает buyer webhook (n8n или твой /api/search).
-func (s *service) handleBuyer(ctx context.Context, sessionID, text string) (string, map[string]any, error) {
+func (s *service) handleBuyer(ctx context.Context, sessionID, text string) (_ string, _ map[string]any, err error) {
+	ctx, span := tracer.Start(ctx, "chat.buyer_webhook")
+	defer func() { endSpan(span, err) }()
 	url := s.cfg.N8NBuyerWebhookURL
 	if url == "" {
 		return "Buyer webhook не настроен", nil, nil
 	}
 
+	priceMax := 15000.0
 	payload := map[string]any{
 		"intent": "search_listings",
-		"requirements": map[string]any{
-			"category_slug": "phones",
-			"price_max":     15000,
-			"currency":      "KGS",
+		"requirements": models.SearchRequirements{
+			CategorySlug: "phones",
+			PriceMax:     &priceMax,
+			Currency:     "KGS",
 		},
 		"limit": 3,
 	}
@@ -142,6 +362,7 @@ func (s *service) handleBuyer(ctx context.Context, sessionID, text string) (stri
 	b, _ := json.Marshal(payload)
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
 	req.Header.Set("Content-Type", "application/json")
+	logging.Propagate(req)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
@@ -164,7 +385,9 @@ func (s *service) handleBuyer(ctx context.Context, sessionID, text string) (stri
 }
 
 // handleSeller — вызывает seller webhook (n8n).
-func (s *service) handleSeller(ctx context.Context, sessionID, text string) (string, map[string]any, error) {
+func (s *service) handleSeller(ctx context.Context, sessionID, text string) (_ string, _ map[string]any, err error) {
+	ctx, span := tracer.Start(ctx, "chat.seller_webhook")
+	defer func() { endSpan(span, err) }()
 	url := s.cfg.N8NSellerWebhookURL
 	if url == "" {
 		return "Seller webhook не настроен", nil, nil
@@ -177,6 +400,7 @@ func (s *service) handleSeller(ctx context.Context, sessionID, text string) (str
 	b, _ := json.Marshal(payload)
 	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
 	req.Header.Set("Content-Type", "application/json")
+	logging.Propagate(req)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
@@ -191,7 +415,42 @@ func (s *service) handleSeller(ctx context.Context, sessionID, text string) (str
 	return reply, out, nil
 }
 
-func extractLastUserText(_ *http.Request) string {
-	// Пока у тебя нет истории в БД — можно возвращать последний текст из теста.
-	return "Хочу телефон до 15000 сомов"
+// endSpan — завершает спан шага; ошибка шага — статусом спана.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// takeNotifications — забирает недоставленные уведомления пользователя и помечает их доставленными.
+// Ошибки не мешают ответу ассистента — уведомления останутся в outbox до следующего раза.
+func (s *service) takeNotifications(ctx context.Context, userID uuid.UUID) []models.Notification {
+	notes, err := s.repos.Notifications().ListPending(ctx, userID, 5)
+	if err != nil || len(notes) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(notes))
+	for _, n := range notes {
+		ids = append(ids, n.ID)
+	}
+	if err := s.repos.Notifications().MarkDelivered(ctx, userID, ids); err != nil {
+		return nil
+	}
+	return notes
+}
+
+// lastUserText — текст последнего сообщения пользователя в разговоре.
+func (s *service) lastUserText(ctx context.Context, conversationID uuid.UUID) (string, error) {
+	msgs, err := s.repos.Messages().ListLast(ctx, conversationID, 10)
+	if err != nil {
+		return "", err
+	}
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == "user" {
+			return msgs[i].Text, nil
+		}
+	}
+	return "", apperror.BadRequest("no user message in conversation")
 }
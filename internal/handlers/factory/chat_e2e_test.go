@@ -0,0 +1,553 @@
+package factory_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/btynybekov/marketplace/config"
+	"github.com/btynybekov/marketplace/internal/ai"
+	"github.com/btynybekov/marketplace/internal/handlers/factory"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/ratelimit"
+	"github.com/btynybekov/marketplace/internal/realtime"
+	"github.com/btynybekov/marketplace/internal/repository"
+	"github.com/btynybekov/marketplace/internal/repository/memory"
+	"github.com/btynybekov/marketplace/internal/repository/repotest"
+)
+
+// ===== Стенд: роутер фабрики + хранилище в памяти + подставные LLM и webhook-и n8n =====
+
+const jwtSecret = "e2e-secret"
+
+// intents — сценарий подставного LLM: классификатор по ключевым словам, болталка — эхом.
+var intents = ai.Script{Rules: []ai.Rule{
+	{System: "классификатор", Match: `(?i)Текст:.*(ищу|куп)`, Reply: "buy"},
+	{System: "классификатор", Match: `(?i)Текст:.*прода`, Reply: "sell"},
+	{System: "классификатор", Reply: "chitchat"},
+	{Match: `упади`, Error: "model overloaded"},
+	{System: "дружелюбный помощник", Match: `(.+)`, Reply: "эхо: $1"},
+}}
+
+type stand struct {
+	t      *testing.T
+	srv    *httptest.Server
+	repos  repository.RepositorySet
+	llm    *ai.FakeClient // за HTTP-заглушкой локального LLM API
+	buyer  *webhook
+	seller *webhook
+	users  []uuid.UUID // пользователи фикстур repotest
+}
+
+func newStand(t *testing.T) *stand {
+	t.Helper()
+	d := repotest.Data()
+	repos, err := memory.New(d)
+	if err != nil {
+		t.Fatalf("memory.New: %v", err)
+	}
+	llm, err := ai.NewFake(intents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &stand{
+		t: t, repos: repos, llm: llm,
+		buyer: newWebhook(t, map[string]any{
+			"message":    "подобрал три варианта",
+			"filter_url": "/items?category=phones&price_max=15000",
+			"top3":       []any{map[string]any{"title": "iPhone 13"}},
+		}),
+		seller: newWebhook(t, map[string]any{
+			"message": "черновик готов",
+			"draft":   map[string]any{"title": "Велосипед"},
+		}),
+	}
+	for _, u := range d.Users {
+		s.users = append(s.users, u.ID)
+	}
+
+	// LLM — настоящий ai.LocalClient по HTTP к заглушке, ответы — из сценария
+	llmSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model       string       `json:"model"`
+			Temperature float64      `json:"temperature"`
+			Messages    []ai.Message `json:"messages"`
+		}
+		if r.URL.Path != "/chat" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		reply, err := llm.Chat(r.Context(), req.Model, req.Temperature, req.Messages)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"reply": reply})
+	}))
+	t.Cleanup(llmSrv.Close)
+	aiClient, err := ai.NewLocal(llmSrv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Defaults()
+	cfg.JWTSecret = jwtSecret
+	cfg.N8NBuyerWebhookURL = s.buyer.URL
+	cfg.N8NSellerWebhookURL = s.seller.URL
+	cfg.N8NTimeout = 5 * time.Second
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.PerMinute(1000, 100))
+	quota := ratelimit.NewQuota(ratelimit.NewMemoryUsageStore(), 0, 0, false)
+	hub := realtime.NewHub(realtime.NewLocalBroker())
+	f := factory.NewHandlersFactory(repos, nil, cfg, aiClient, hub, limiter, quota)
+
+	r := mux.NewRouter()
+	r.Use(middleware.Authenticate(cfg.JWTSecret))
+	f.RegisterRoutes(r)
+	s.srv = httptest.NewServer(middleware.RequestID(r))
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+// webhook — заглушка n8n: запоминает тела запросов, отвечает reply со статусом status.
+type webhook struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []map[string]any
+	status int
+	reply  any
+}
+
+func newWebhook(t *testing.T, reply any) *webhook {
+	t.Helper()
+	h := &webhook{status: http.StatusOK, reply: reply}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Method != http.MethodPost ||
+			r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		h.mu.Lock()
+		h.bodies = append(h.bodies, body)
+		status := h.status
+		h.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(h.reply)
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+func (h *webhook) calls() []map[string]any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]map[string]any(nil), h.bodies...)
+}
+
+func (h *webhook) fail(status int) {
+	h.mu.Lock()
+	h.status = status
+	h.mu.Unlock()
+}
+
+// token — Bearer-токен пользователя id.
+func token(t *testing.T, id uuid.UUID) string {
+	t.Helper()
+	claims := jwt.RegisteredClaims{Subject: id.String(), ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+// do — запрос к API (путь — без /api/v1); ответ декодируется в out (nil — пропустить), возвращается статус.
+func (s *stand) do(method, path, bearer string, body, out any) int {
+	s.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, s.srv.URL+factory.APIPrefix+path, &buf)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusNoContent && !strings.HasPrefix(ct, "application/json") {
+		s.t.Fatalf("%s %s: Content-Type %q, want JSON", method, path, ct)
+	}
+	if resp.Header.Get("X-Request-ID") == "" {
+		s.t.Errorf("%s %s: no X-Request-ID", method, path)
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			s.t.Fatalf("%s %s: decode: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// ===== DTO ответов (формы, на которые опирается фронтенд) =====
+
+type message struct {
+	ID        string    `json:"id"`
+	Role      string    `json:"role"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type sendResp struct {
+	Reply     message `json:"reply"`
+	Top3      []any   `json:"top3"`
+	FilterURL string  `json:"filter_url"`
+}
+
+type historyResp struct {
+	SessionID  string    `json:"session_id"`
+	Messages   []message `json:"messages"`
+	NextCursor string    `json:"next_cursor"`
+}
+
+type errorResp struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// ===== Шаги сценария =====
+
+func (s *stand) startSession(bearer string) string {
+	s.t.Helper()
+	var out struct {
+		SessionID string `json:"session_id"`
+	}
+	if st := s.do(http.MethodPost, "/chat/session", bearer, map[string]any{}, &out); st != http.StatusOK {
+		s.t.Fatalf("POST /chat/session = %d", st)
+	}
+	if !strings.HasPrefix(out.SessionID, "sess_") {
+		s.t.Fatalf("session_id = %q", out.SessionID)
+	}
+	return out.SessionID
+}
+
+func (s *stand) send(bearer, sid, text string) sendResp {
+	s.t.Helper()
+	var out sendResp
+	if st := s.do(http.MethodPost, "/chat/ajax", bearer, map[string]any{"session_id": sid, "text": text}, &out); st != http.StatusOK {
+		s.t.Fatalf("POST /chat/ajax %q = %d", text, st)
+	}
+	if out.Reply.Role != "assistant" || out.Reply.ID == "" || out.Reply.Text == "" || out.Reply.CreatedAt.IsZero() {
+		s.t.Fatalf("reply = %+v, want an assistant message with id", out.Reply)
+	}
+	return out
+}
+
+func (s *stand) history(bearer, query string) historyResp {
+	s.t.Helper()
+	var out historyResp
+	if st := s.do(http.MethodGet, "/chat/history?"+query, bearer, nil, &out); st != http.StatusOK {
+		s.t.Fatalf("GET /chat/history?%s = %d", query, st)
+	}
+	return out
+}
+
+// persisted — сообщения разговора сессии прямо из хранилища: (role, text, intent) по порядку.
+func (s *stand) persisted(sid string) [][3]string {
+	s.t.Helper()
+	ctx := context.Background()
+	conv, err := s.repos.Conversations().GetBySession(ctx, sid)
+	if err != nil {
+		s.t.Fatalf("conversation of %s: %v", sid, err)
+	}
+	msgs, err := s.repos.Messages().ListLast(ctx, conv.ID, 100)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	var out [][3]string
+	for _, m := range msgs {
+		out = append(out, [3]string{m.Role, m.Text, m.Meta["intent"]})
+	}
+	return out
+}
+
+func wantPersisted(t *testing.T, got [][3]string, want ...[3]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("persisted messages = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("persisted message %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+// ===== Сценарии =====
+
+func TestChatFlowChitchat(t *testing.T) {
+	s := newStand(t)
+	sid := s.startSession("")
+
+	out := s.send("", sid, "привет")
+	if out.Reply.Text != "эхо: привет" || out.Top3 != nil || out.FilterURL != "" {
+		t.Fatalf("chitchat reply = %+v", out)
+	}
+
+	h := s.history("", "session_id="+sid)
+	if h.SessionID != sid || h.NextCursor != "" || len(h.Messages) != 2 {
+		t.Fatalf("history = %+v", h)
+	}
+	if h.Messages[0].Role != "user" || h.Messages[0].Text != "привет" || h.Messages[1].ID != out.Reply.ID {
+		t.Fatalf("history messages = %+v", h.Messages)
+	}
+	wantPersisted(t, s.persisted(sid),
+		[3]string{"user", "привет", ""},
+		[3]string{"assistant", "эхо: привет", "chitchat"})
+
+	// классификатор, затем болталка; webhook-и не вызываются
+	calls := s.llm.Calls()
+	if len(calls) != 2 || !strings.Contains(calls[0].Messages[1].Content, "Текст: привет") ||
+		calls[1].Model != config.Defaults().AIModel {
+		t.Fatalf("LLM calls = %+v", calls)
+	}
+	if len(s.buyer.calls())+len(s.seller.calls()) != 0 {
+		t.Fatal("webhooks called for chitchat")
+	}
+
+	// та же сессия продлевается, а не создаётся заново
+	var again struct {
+		SessionID string `json:"session_id"`
+	}
+	s.do(http.MethodPost, "/chat/session", "", map[string]any{"session_id": sid}, &again)
+	if again.SessionID != sid {
+		t.Fatalf("restart session = %q, want %q", again.SessionID, sid)
+	}
+}
+
+func TestChatFlowBuyer(t *testing.T) {
+	s := newStand(t)
+	sid := s.startSession("")
+
+	out := s.send("", sid, "ищу айфон до 15 000")
+	if out.FilterURL != "/items?category=phones&price_max=15000" || len(out.Top3) != 1 {
+		t.Fatalf("buyer reply = %+v, want filter_url and top3 from the webhook", out)
+	}
+
+	calls := s.buyer.calls()
+	if len(calls) != 1 || calls[0]["intent"] != "search_listings" {
+		t.Fatalf("buyer webhook calls = %v", calls)
+	}
+	req, _ := calls[0]["requirements"].(map[string]any)
+	if req["category_slug"] != "phones" || req["currency"] != "KGS" || calls[0]["limit"] != 3.0 {
+		t.Fatalf("buyer webhook requirements = %v", calls[0])
+	}
+	wantPersisted(t, s.persisted(sid),
+		[3]string{"user", "ищу айфон до 15 000", ""},
+		[3]string{"assistant", out.Reply.Text, "buy"})
+}
+
+func TestChatFlowSeller(t *testing.T) {
+	s := newStand(t)
+	sid := s.startSession("")
+
+	out := s.send("", sid, "хочу продать велосипед")
+	calls := s.seller.calls()
+	if len(calls) != 1 || calls[0]["description"] != "хочу продать велосипед" {
+		t.Fatalf("seller webhook calls = %v", calls)
+	}
+	if len(s.buyer.calls()) != 0 {
+		t.Fatal("buyer webhook called for a seller")
+	}
+	wantPersisted(t, s.persisted(sid),
+		[3]string{"user", "хочу продать велосипед", ""},
+		[3]string{"assistant", out.Reply.Text, "sell"})
+}
+
+func TestChatFlowUpstreamFailures(t *testing.T) {
+	s := newStand(t)
+	sid := s.startSession("")
+
+	// LLM недоступен: 502, сообщение пользователя сохранено, ответа ассистента нет
+	var e errorResp
+	if st := s.do(http.MethodPost, "/chat/ajax", "", map[string]any{"session_id": sid, "text": "упади"}, &e); st != http.StatusBadGateway {
+		t.Fatalf("LLM failure = %d, want 502", st)
+	}
+	if e.Code != "upstream_error" || e.Error == "" {
+		t.Fatalf("LLM failure body = %+v", e)
+	}
+	wantPersisted(t, s.persisted(sid), [3]string{"user", "упади", ""})
+
+	// следующее сообщение обрабатывается как обычно
+	s.send("", sid, "привет")
+	if n := len(s.history("", "session_id="+sid).Messages); n != 3 {
+		t.Fatalf("history after recovery = %d messages, want 3", n)
+	}
+
+	// /assistant/*: ошибка n8n — 502 в общем формате ошибок
+	s.seller.fail(http.StatusInternalServerError)
+	if st := s.do(http.MethodPost, "/assistant/seller", "", map[string]any{"task": "draft"}, &e); st != http.StatusBadGateway || e.Code != "upstream_error" {
+		t.Fatalf("POST /assistant/seller with failing n8n = %d %+v", st, e)
+	}
+}
+
+func TestChatHistoryPagination(t *testing.T) {
+	s := newStand(t)
+	sid := s.startSession("")
+	for _, text := range []string{"раз", "два", "три"} {
+		s.send("", sid, text)
+	}
+
+	var got []message
+	var firstCursor string
+	query := "session_id=" + sid + "&limit=4"
+	for range 3 {
+		page := s.history("", query)
+		got = append(page.Messages, got...)
+		if page.NextCursor == "" {
+			break
+		}
+		if firstCursor == "" {
+			firstCursor = page.NextCursor
+		}
+		query = "session_id=" + sid + "&limit=4&cursor=" + page.NextCursor
+	}
+	if len(got) != 6 {
+		t.Fatalf("paged history = %d messages, want 6", len(got))
+	}
+	for i, m := range got {
+		wantRole := []string{"user", "assistant"}[i%2]
+		if m.Role != wantRole || (i > 0 && m.CreatedAt.Before(got[i-1].CreatedAt)) {
+			t.Fatalf("message %d = %+v: want %s, in chronological order", i, m, wantRole)
+		}
+	}
+	if got[0].Text != "раз" || got[5].Text != "эхо: три" {
+		t.Fatalf("paged history = %+v", got)
+	}
+
+	var e errorResp
+	if st := s.do(http.MethodGet, "/chat/history?session_id="+sid+"&cursor=garbage", "", nil, &e); st != http.StatusBadRequest || e.Code != "bad_request" {
+		t.Fatalf("bad cursor = %d %+v", st, e)
+	}
+	// курсор чужой сессии не принимается
+	other := s.startSession("")
+	if st := s.do(http.MethodGet, "/chat/history?session_id="+other+"&cursor="+firstCursor, "", nil, &e); st != http.StatusBadRequest {
+		t.Fatalf("cursor of another session = %d %+v, want 400", st, e)
+	}
+}
+
+func TestChatSessionOwnership(t *testing.T) {
+	s := newStand(t)
+	alice, bob := token(t, s.users[0]), token(t, s.users[1])
+
+	// анонимная сессия при первом запросе с токеном становится сессией пользователя
+	sid := s.startSession("")
+	s.send("", sid, "привет")
+	s.send(alice, sid, "это снова я")
+	conv, err := s.repos.Conversations().GetBySession(context.Background(), sid)
+	if err != nil || conv.UserID == nil || *conv.UserID != s.users[0] {
+		t.Fatalf("conversation after login = %+v, err %v", conv, err)
+	}
+	if n := len(s.history(alice, "session_id="+sid).Messages); n != 4 {
+		t.Fatalf("alice history = %d messages, want 4", n)
+	}
+
+	var e errorResp
+	for name, bearer := range map[string]string{"anonymous": "", "bob": bob} {
+		if st := s.do(http.MethodGet, "/chat/history?session_id="+sid, bearer, nil, &e); st != http.StatusForbidden || e.Code != "forbidden" {
+			t.Errorf("%s reads alice history = %d %+v, want 403", name, st, e)
+		}
+		if st := s.do(http.MethodPost, "/chat/ajax", bearer, map[string]any{"session_id": sid, "text": "x"}, &e); st != http.StatusForbidden {
+			t.Errorf("%s writes to alice session = %d, want 403", name, st)
+		}
+	}
+	if st := s.do(http.MethodGet, "/chat/history?session_id="+sid, "garbage", nil, &e); st != http.StatusUnauthorized || e.Code != "unauthorized" {
+		t.Errorf("invalid token = %d %+v, want 401", st, e)
+	}
+
+	// завершённая сессия — 404, история остаётся у пользователя в хранилище
+	if st := s.do(http.MethodDelete, "/chat/session?session_id="+sid, alice, nil, nil); st != http.StatusNoContent {
+		t.Fatalf("DELETE /chat/session = %d", st)
+	}
+	if st := s.do(http.MethodGet, "/chat/history?session_id="+sid, alice, nil, &e); st != http.StatusNotFound || e.Code != "not_found" {
+		t.Fatalf("history of ended session = %d %+v, want 404", st, e)
+	}
+	if st := s.do(http.MethodPost, "/chat/ajax", "", map[string]any{"session_id": "sess_unknown", "text": "x"}, &e); st != http.StatusNotFound {
+		t.Fatalf("unknown session = %d, want 404", st)
+	}
+}
+
+func TestAssistantProxy(t *testing.T) {
+	s := newStand(t)
+	alice := s.users[0]
+
+	for _, tc := range []struct {
+		path    string
+		hook    *webhook
+		message string
+	}{
+		{"/assistant/buyer", s.buyer, "подобрал три варианта"},
+		{"/assistant/seller", s.seller, "черновик готов"},
+	} {
+		var out map[string]any
+		body := map[string]any{"userId": alice.String(), "task": "suggest", "data": map[string]any{"q": "iphone"}}
+		if st := s.do(http.MethodPost, tc.path, "", body, &out); st != http.StatusOK {
+			t.Fatalf("POST %s = %d", tc.path, st)
+		}
+		// наружу — только message, служебные поля ответа n8n не протекают
+		if len(out) != 1 || out["message"] != tc.message {
+			t.Fatalf("POST %s = %v, want only message", tc.path, out)
+		}
+		calls := tc.hook.calls()
+		if len(calls) != 1 || calls[0]["userId"] != alice.String() || calls[0]["task"] != "suggest" {
+			t.Fatalf("%s webhook calls = %v", tc.path, calls)
+		}
+		if data, _ := calls[0]["data"].(map[string]any); data["q"] != "iphone" {
+			t.Fatalf("%s webhook data = %v", tc.path, calls[0]["data"])
+		}
+	}
+
+	// без userId — anonymous; без task — 400 до вызова n8n
+	var out map[string]any
+	s.do(http.MethodPost, "/assistant/buyer", "", map[string]any{"task": "suggest"}, &out)
+	if calls := s.buyer.calls(); len(calls) != 2 || calls[1]["userId"] != "anonymous" {
+		t.Fatalf("anonymous call = %v", calls)
+	}
+	var e struct {
+		Code   string `json:"code"`
+		Fields []struct {
+			Field string `json:"field"`
+			Rule  string `json:"rule"`
+		} `json:"fields"`
+	}
+	if st := s.do(http.MethodPost, "/assistant/buyer", "", map[string]any{"data": map[string]any{}}, &e); st != http.StatusBadRequest {
+		t.Fatalf("missing task = %d, want 400", st)
+	}
+	if e.Code != "validation_failed" || len(e.Fields) != 1 || e.Fields[0].Field != "task" || e.Fields[0].Rule != "required" {
+		t.Fatalf("missing task body = %+v", e)
+	}
+	if n := len(s.buyer.calls()); n != 2 {
+		t.Fatalf("invalid request reached n8n: %d calls", n)
+	}
+}
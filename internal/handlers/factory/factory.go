@@ -1,68 +1,248 @@
 package factory
 
 import (
-	"html/template"
 	"net/http"
 
 	"github.com/gorilla/mux"
 
 	"github.com/btynybekov/marketplace/config"
 	"github.com/btynybekov/marketplace/internal/ai"
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/currency"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/ratelimit"
+	"github.com/btynybekov/marketplace/internal/realtime"
 	"github.com/btynybekov/marketplace/internal/repository"
+	"github.com/btynybekov/marketplace/internal/web"
 
+	"github.com/btynybekov/marketplace/internal/handlers/admin"
 	"github.com/btynybekov/marketplace/internal/handlers/assistant"
+	"github.com/btynybekov/marketplace/internal/handlers/brands"
 	"github.com/btynybekov/marketplace/internal/handlers/categories"
 	"github.com/btynybekov/marketplace/internal/handlers/chat"
+	"github.com/btynybekov/marketplace/internal/handlers/favorites"
 	"github.com/btynybekov/marketplace/internal/handlers/homepage"
 	"github.com/btynybekov/marketplace/internal/handlers/items"
+	"github.com/btynybekov/marketplace/internal/handlers/listings"
+	"github.com/btynybekov/marketplace/internal/handlers/messages"
+	"github.com/btynybekov/marketplace/internal/handlers/notifications"
+	"github.com/btynybekov/marketplace/internal/handlers/products"
+	"github.com/btynybekov/marketplace/internal/handlers/rates"
+	"github.com/btynybekov/marketplace/internal/handlers/reports"
+	"github.com/btynybekov/marketplace/internal/handlers/savedsearches"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/handlers/user"
+	"github.com/btynybekov/marketplace/internal/handlers/ws"
 )
 
 type HandlersFactory struct {
 	HomepageHandler   *homepage.HomePageHandler
 	CategoriesHandler *categories.CategoryHandler
 	ItemsHandler      *items.ItemHandler
-	ChatPageHandler   http.Handler
-	ChatHandler       *chat.ChatHandler // методы: StartSession, SendMessage, GetHistory
+	SearchHandler     *items.SearchHandler
+	BrandsHandler     *brands.BrandHandler
+	ProductsHandler   *products.ProductHandler
+	ListingsHandler   *listings.ListingHandler
+	RatesHandler      *rates.RatesHandler
+
+	// избранное, сохранённые поиски, уведомления (только для авторизованных)
+	FavoritesHandler     *favorites.FavoritesHandler
+	SavedSearchHandler   *savedsearches.SavedSearchHandler
+	NotificationsHandler *notifications.NotificationsHandler
+
+	// переписка покупатель ↔ продавец и жалобы
+	MessagesHandler *messages.MessagesHandler
+	ReportsHandler  *reports.ReportsHandler
+
+	// профили, страницы продавцов и отзывы
+	UserHandler    *user.UserHandler
+	ReviewsHandler *user.ReviewsHandler
+
+	// /admin: модерация жалоб и роли
+	AdminHandler *admin.AdminHandler
+
+	ChatPageHandler http.Handler
+	ChatHandler     *chat.ChatHandler // методы: StartSession, SendMessage, GetHistory
+
+	// WebSocket-шлюз: ответы ассистента, переписка, typing, уведомления
+	GatewayHandler *ws.GatewayHandler
 
 	// ассистенты (проксирование в n8n)
 	BuyerAssistant  *assistant.AssistantHandler
 	SellerAssistant *assistant.AssistantHandler
+
+	// guard — rate limit + суточная квота LLM для платных эндпоинтов (чат, ассистенты)
+	guard func(http.Handler) http.Handler
+	// perms — источник прав для middleware.RequirePermission
+	perms middleware.PermissionChecker
+
+	// assets — статика сайта (css, js) под AssetsBaseURL; nil — не раздаётся
+	assets *web.Assets
+
+	// Rates — кэш курсов валют, общий для хендлеров; обновляется currency.Refresher из main.
+	Rates *currency.Converter
 }
 
 // NewHandlersFactory — собирает все зависимости и создаёт хендлеры.
 func NewHandlersFactory(
 	repo repository.RepositorySet,
-	tmpl *template.Template,
+	tmpl shared.Templates,
 	conf config.EnvConfig,
 	aiClient ai.Client,
+	hub *realtime.Hub,
+	limiter *ratelimit.Limiter,
+	quota *ratelimit.Quota,
 ) *HandlersFactory {
 	// Сервис чата: LLM + авто выбор buyer/seller по контексту
-	chatSvc := chat.NewService(repo, aiClient, nil, conf)
+	chatSvc := chat.NewService(repo, aiClient, nil, conf, hub)
+	conv := currency.NewConverter()
+	cursors := pagination.NewCodec(conf.CursorSecret)
+	limitKeys := []ratelimit.KeyFunc{ratelimit.ByIP(conf.TrustProxy), ratelimit.ByUser, ratelimit.BySession}
+	rateLimited := limiter.Middleware(limitKeys...)
 
 	return &HandlersFactory{
 		HomepageHandler:   homepage.NewHomePageHandler(repo, tmpl),
 		CategoriesHandler: categories.NewCategoryHandler(repo, tmpl),
-		ItemsHandler:      items.NewItemHandler(repo, tmpl),
-		ChatPageHandler:   chat.NewChatHandler(repo).WithTemplate(tmpl),
-		ChatHandler:       chat.NewChatHTTP(chatSvc),
+		ItemsHandler:      items.NewItemHandler(repo, tmpl, conv, conf.DisplayCurrency, cursors),
+		SearchHandler:     items.NewSearchHandler(repo, conv, conf.DisplayCurrency, cursors),
+		BrandsHandler:     brands.NewBrandHandler(repo),
+		ProductsHandler:   products.NewProductHandler(repo),
+		ListingsHandler:   listings.NewListingHandler(repo),
+		RatesHandler:      rates.NewRatesHandler(repo),
+
+		FavoritesHandler:     favorites.NewFavoritesHandler(repo),
+		SavedSearchHandler:   savedsearches.NewSavedSearchHandler(repo),
+		NotificationsHandler: notifications.NewNotificationsHandler(repo),
+		MessagesHandler:      messages.NewMessagesHandler(repo, aiClient, conf, cursors, hub),
+		ReportsHandler:       reports.NewReportsHandler(repo),
+		UserHandler:          user.NewUserHandler(repo, cursors),
+		ReviewsHandler:       user.NewReviewsHandler(repo, cursors),
+		AdminHandler:         admin.NewAdminHandler(repo),
+		ChatPageHandler:      chat.NewChatHandler(repo).WithTemplate(tmpl),
+		ChatHandler:          chat.NewChatHTTP(chatSvc, cursors),
+		GatewayHandler:       ws.NewGatewayHandler(hub, repo, chatSvc, limiter, quota, limitKeys...),
 		// Ассистенты (прямые вебхуки n8n)
-		BuyerAssistant:  assistant.NewAssistantHandler(conf.N8NBuyerWebhookURL),
-		SellerAssistant: assistant.NewAssistantHandler(conf.N8NSellerWebhookURL),
+		BuyerAssistant:  assistant.NewAssistantHandler(conf.N8NBuyerWebhookURL, conf.N8NTimeout),
+		SellerAssistant: assistant.NewAssistantHandler(conf.N8NSellerWebhookURL, conf.N8NTimeout),
+		Rates:           conv,
+		perms:           repo.Roles(),
+		guard: func(h http.Handler) http.Handler {
+			return rateLimited(quota.Middleware(h))
+		},
 	}
 }
 
-// RegisterRoutes — регистрирует все маршруты на переданный *mux.Router.
+// WithAssets — раздавать статику сайта в RegisterWeb (путь — assets.Mount()).
+func (f *HandlersFactory) WithAssets(assets *web.Assets) *HandlersFactory {
+	f.assets = assets
+	return f
+}
+
+// APIPrefix — префикс версии JSON API.
+const APIPrefix = "/api/v1"
+
+// RegisterRoutes — веб-страницы в корне и JSON API под APIPrefix.
 func (f *HandlersFactory) RegisterRoutes(r *mux.Router) {
-	// Страницы
+	// Неизвестный маршрут или метод — тем же JSON-форматом, что и остальные ошибки
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		shared.Error(w, req, apperror.NotFound("route not found"))
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		shared.Error(w, req, apperror.MethodNotAllowed())
+	})
+
+	api := r.PathPrefix(APIPrefix).Subrouter()
+	api.Use(middleware.AcceptJSON)
+	f.RegisterAPI(api)
+	f.RegisterWeb(r)
+}
+
+// RegisterWeb — страницы с серверным рендерингом (HTML или JSON по Accept).
+func (f *HandlersFactory) RegisterWeb(r *mux.Router) {
 	r.Handle("/", f.HomepageHandler).Methods(http.MethodGet)
-	r.Handle("/categories", f.CategoriesHandler).Methods(http.MethodGet)
-	r.Handle("/items", f.ItemsHandler).Methods(http.MethodGet)
+	r.Handle("/categories", f.CategoriesHandler.Page()).Methods(http.MethodGet)
+	r.Handle("/items", f.ItemsHandler.Page()).Methods(http.MethodGet)
 	r.Handle("/chat", f.ChatPageHandler).Methods(http.MethodGet)
+	// Спецификация API — и в корне, чтобы её находили без знания версии
+	r.Handle("/openapi.json", specHandler()).Methods(http.MethodGet)
+	// Статика: /static/css/app.<hash>.css (при CDN — origin для него)
+	if f.assets != nil {
+		r.PathPrefix(f.assets.Mount()+"/").
+			Handler(http.StripPrefix(f.assets.Mount(), f.assets)).
+			Methods(http.MethodGet, http.MethodHead)
+	}
+}
+
+// RegisterAPI — JSON API (в RegisterRoutes монтируется под APIPrefix). Описания маршрутов — openapi.go.
+func (f *HandlersFactory) RegisterAPI(r *mux.Router) {
+	r.Handle("/openapi.json", specHandler()).Methods(http.MethodGet)
+	// Каталог, поиск и бренды
+	r.Handle("/categories", f.CategoriesHandler.List()).Methods(http.MethodGet)
+	r.Handle("/items", f.ItemsHandler.List()).Methods(http.MethodGet)
+	r.Handle("/search", f.SearchHandler).Methods(http.MethodGet)
+	r.Handle("/brands", f.BrandsHandler.List()).Methods(http.MethodGet)
+	r.Handle("/brands/resolve", f.BrandsHandler.Resolve()).Methods(http.MethodGet)
+	r.Handle("/brands/{slug}", f.BrandsHandler.Get()).Methods(http.MethodGet)
+	// Каталог моделей и объявления
+	r.Handle("/products/{id}", f.ProductsHandler).Methods(http.MethodGet)
+	authed := middleware.RequireUser
+	can := func(perm string) func(http.Handler) http.Handler {
+		return middleware.RequirePermission(f.perms, perm)
+	}
+	r.Handle("/listings", authed(can(middleware.PermListingsCreate)(f.ListingsHandler.Create()))).Methods(http.MethodPost)
+	r.Handle("/listings/{id}", f.ListingsHandler.Get()).Methods(http.MethodGet)
+	// права на правку проверяет сам хендлер: своё — listings.edit.own, чужое — listings.edit.any
+	r.Handle("/listings/{id}", authed(f.ListingsHandler.Update())).Methods(http.MethodPatch)
+	r.Handle("/listings/{id}", authed(f.ListingsHandler.Delete())).Methods(http.MethodDelete)
+	r.Handle("/rates", f.RatesHandler).Methods(http.MethodGet)
+	// Избранное, сохранённые поиски, уведомления
+	r.Handle("/favorites", authed(f.FavoritesHandler.List())).Methods(http.MethodGet)
+	r.Handle("/favorites/{listing_id}", authed(f.FavoritesHandler.Add())).Methods(http.MethodPut)
+	r.Handle("/favorites/{listing_id}", authed(f.FavoritesHandler.Remove())).Methods(http.MethodDelete)
+	r.Handle("/saved-searches", authed(f.SavedSearchHandler.List())).Methods(http.MethodGet)
+	r.Handle("/saved-searches", authed(f.SavedSearchHandler.Create())).Methods(http.MethodPost)
+	r.Handle("/saved-searches/{id}", authed(f.SavedSearchHandler.Delete())).Methods(http.MethodDelete)
+	r.Handle("/notifications", authed(f.NotificationsHandler.List())).Methods(http.MethodGet)
+	r.Handle("/notifications/ack", authed(f.NotificationsHandler.Ack())).Methods(http.MethodPost)
+
+	// Переписка по объявлениям
+	r.Handle("/threads", authed(f.MessagesHandler.ListThreads())).Methods(http.MethodGet)
+	r.Handle("/threads", authed(f.MessagesHandler.StartThread())).Methods(http.MethodPost)
+	r.Handle("/threads/unread", authed(f.MessagesHandler.Unread())).Methods(http.MethodGet)
+	r.Handle("/threads/{id}/messages", authed(f.MessagesHandler.ListMessages())).Methods(http.MethodGet)
+	r.Handle("/threads/{id}/messages", authed(f.MessagesHandler.Send())).Methods(http.MethodPost)
+	r.Handle("/threads/{id}/read", authed(f.MessagesHandler.MarkRead())).Methods(http.MethodPost)
+	r.Handle("/threads/{id}/suggest-reply", authed(f.guard(f.MessagesHandler.SuggestReply()))).Methods(http.MethodPost)
+	r.Handle("/users/{id}/block", authed(f.MessagesHandler.Block())).Methods(http.MethodPut)
+	r.Handle("/users/{id}/block", authed(f.MessagesHandler.Unblock())).Methods(http.MethodDelete)
+	r.Handle("/reports", authed(f.ReportsHandler)).Methods(http.MethodPost)
+	// Профили и отзывы (/users/me — раньше /users/{id})
+	r.Handle("/users/me", authed(f.UserHandler.Me())).Methods(http.MethodGet)
+	r.Handle("/users/me", authed(f.UserHandler.UpdateMe())).Methods(http.MethodPatch)
+	r.Handle("/users/{id}", f.UserHandler.Profile()).Methods(http.MethodGet)
+	r.Handle("/users/{id}/listings", f.UserHandler.Listings()).Methods(http.MethodGet)
+	r.Handle("/users/{id}/reviews", f.ReviewsHandler.List()).Methods(http.MethodGet)
+	r.Handle("/listings/{id}/review", authed(f.ReviewsHandler.Put())).Methods(http.MethodPut)
+	r.Handle("/listings/{id}/review", authed(f.ReviewsHandler.Delete())).Methods(http.MethodDelete)
 	// API чата
 	r.Handle("/chat/session", f.ChatHandler.StartSession()).Methods(http.MethodPost)
-	r.Handle("/chat/ajax", f.ChatHandler.SendMessage()).Methods(http.MethodPost)
+	r.Handle("/chat/session", f.ChatHandler.EndSession()).Methods(http.MethodDelete)
+	r.Handle("/chat/ajax", f.guard(f.ChatHandler.SendMessage())).Methods(http.MethodPost)
 	r.Handle("/chat/history", f.ChatHandler.GetHistory()).Methods(http.MethodGet)
+	r.Handle("/ws", middleware.RequireUser(f.GatewayHandler)).Methods(http.MethodGet)
+	// Админка: вход — admin.access, дальше — право на конкретный раздел
+	adm := r.PathPrefix("/admin").Subrouter()
+	adm.Use(middleware.RequireUser, can(middleware.PermAdminAccess))
+	adm.Handle("/brands", can(middleware.PermBrandsManage)(f.BrandsHandler.Create())).Methods(http.MethodPost)
+	adm.Handle("/brands/{slug}", can(middleware.PermBrandsManage)(f.BrandsHandler.Update())).Methods(http.MethodPut)
+	adm.Handle("/brands/{slug}", can(middleware.PermBrandsManage)(f.BrandsHandler.Delete())).Methods(http.MethodDelete)
+	adm.Handle("/reports", can(middleware.PermReportsReview)(f.AdminHandler.Reports())).Methods(http.MethodGet)
+	adm.Handle("/reports/{id}", can(middleware.PermReportsReview)(f.AdminHandler.ResolveReport())).Methods(http.MethodPatch)
+	adm.Handle("/users/{id}/roles", can(middleware.PermRolesManage)(f.AdminHandler.UserRoles())).Methods(http.MethodGet)
+	adm.Handle("/users/{id}/roles/{role}", can(middleware.PermRolesManage)(f.AdminHandler.GrantRole())).Methods(http.MethodPut)
+	adm.Handle("/users/{id}/roles/{role}", can(middleware.PermRolesManage)(f.AdminHandler.RevokeRole())).Methods(http.MethodDelete)
 	// Ассистенты из n8n webhook
-	r.Handle("/assistant/buyer", f.BuyerAssistant).Methods(http.MethodPost)
-	r.Handle("/assistant/seller", f.SellerAssistant).Methods(http.MethodPost)
+	r.Handle("/assistant/buyer", f.guard(f.BuyerAssistant)).Methods(http.MethodPost)
+	r.Handle("/assistant/seller", f.guard(f.SellerAssistant)).Methods(http.MethodPost)
 }
@@ -0,0 +1,130 @@
+package factory
+
+import (
+	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/openapi"
+
+	"github.com/btynybekov/marketplace/internal/handlers/admin"
+	"github.com/btynybekov/marketplace/internal/handlers/assistant"
+	"github.com/btynybekov/marketplace/internal/handlers/brands"
+	"github.com/btynybekov/marketplace/internal/handlers/categories"
+	"github.com/btynybekov/marketplace/internal/handlers/chat"
+	"github.com/btynybekov/marketplace/internal/handlers/favorites"
+	"github.com/btynybekov/marketplace/internal/handlers/items"
+	"github.com/btynybekov/marketplace/internal/handlers/listings"
+	"github.com/btynybekov/marketplace/internal/handlers/messages"
+	"github.com/btynybekov/marketplace/internal/handlers/notifications"
+	"github.com/btynybekov/marketplace/internal/handlers/products"
+	"github.com/btynybekov/marketplace/internal/handlers/rates"
+	"github.com/btynybekov/marketplace/internal/handlers/reports"
+	"github.com/btynybekov/marketplace/internal/handlers/savedsearches"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/handlers/user"
+	"github.com/btynybekov/marketplace/internal/handlers/ws"
+)
+
+// apiInfo — заголовок спецификации /openapi.json.
+var apiInfo = openapi.Info{
+	Title:   "Marketplace API",
+	Version: "1.0.0",
+	Description: "Marketplace backend: catalog, listings, search, messaging, reviews and the AI assistant. " +
+		"Errors share one format: {error, code, fields?}; messages follow Accept-Language (en, ru, ky).",
+}
+
+// specDoc — описание самого /openapi.json.
+var specDoc = openapi.Operation{Summary: "OpenAPI specification", Response: map[string]any{}}
+
+// apiRoutes — документация маршрутов RegisterAPI (пути — относительно APIPrefix): метод, путь,
+// авторизация и описание из Docs пакета хендлера. Расхождение с роутером ловит contract-тест (openapi_test.go).
+func apiRoutes() []openapi.Route {
+	const (
+		get, post, put, patch, del = http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete
+	)
+	return []openapi.Route{
+		{Method: get, Path: "/openapi.json", Tag: "meta", Operation: specDoc},
+		// Каталог, поиск и бренды
+		{Method: get, Path: "/categories", Tag: "catalog", Operation: categories.Docs["List"]},
+		{Method: get, Path: "/items", Tag: "catalog", Operation: items.Docs["List"]},
+		{Method: get, Path: "/search", Tag: "catalog", Operation: items.Docs["SearchHandler"]},
+		{Method: get, Path: "/brands", Tag: "catalog", Operation: brands.Docs["List"]},
+		{Method: get, Path: "/brands/resolve", Tag: "catalog", Operation: brands.Docs["Resolve"]},
+		{Method: get, Path: "/brands/{slug}", Tag: "catalog", Operation: brands.Docs["Get"]},
+		// Каталог моделей и объявления
+		{Method: get, Path: "/products/{id}", Tag: "catalog", Operation: products.Docs["ProductHandler"]},
+		{Method: post, Path: "/listings", Tag: "listings", Auth: true, Permission: middleware.PermListingsCreate, Operation: listings.Docs["Create"]},
+		{Method: get, Path: "/listings/{id}", Tag: "listings", Operation: listings.Docs["Get"]},
+		{Method: patch, Path: "/listings/{id}", Tag: "listings", Auth: true, Operation: listings.Docs["Update"]},
+		{Method: del, Path: "/listings/{id}", Tag: "listings", Auth: true, Operation: listings.Docs["Delete"]},
+		{Method: get, Path: "/rates", Tag: "catalog", Operation: rates.Docs["RatesHandler"]},
+		// Избранное, сохранённые поиски, уведомления
+		{Method: get, Path: "/favorites", Tag: "favorites", Auth: true, Operation: favorites.Docs["List"]},
+		{Method: put, Path: "/favorites/{listing_id}", Tag: "favorites", Auth: true, Operation: favorites.Docs["Add"]},
+		{Method: del, Path: "/favorites/{listing_id}", Tag: "favorites", Auth: true, Operation: favorites.Docs["Remove"]},
+		{Method: get, Path: "/saved-searches", Tag: "saved searches", Auth: true, Operation: savedsearches.Docs["List"]},
+		{Method: post, Path: "/saved-searches", Tag: "saved searches", Auth: true, Operation: savedsearches.Docs["Create"]},
+		{Method: del, Path: "/saved-searches/{id}", Tag: "saved searches", Auth: true, Operation: savedsearches.Docs["Delete"]},
+		{Method: get, Path: "/notifications", Tag: "notifications", Auth: true, Operation: notifications.Docs["List"]},
+		{Method: post, Path: "/notifications/ack", Tag: "notifications", Auth: true, Operation: notifications.Docs["Ack"]},
+		// Переписка по объявлениям
+		{Method: get, Path: "/threads", Tag: "messages", Auth: true, Operation: messages.Docs["ListThreads"]},
+		{Method: post, Path: "/threads", Tag: "messages", Auth: true, Operation: messages.Docs["StartThread"]},
+		{Method: get, Path: "/threads/unread", Tag: "messages", Auth: true, Operation: messages.Docs["Unread"]},
+		{Method: get, Path: "/threads/{id}/messages", Tag: "messages", Auth: true, Operation: messages.Docs["ListMessages"]},
+		{Method: post, Path: "/threads/{id}/messages", Tag: "messages", Auth: true, Operation: messages.Docs["Send"]},
+		{Method: post, Path: "/threads/{id}/read", Tag: "messages", Auth: true, Operation: messages.Docs["MarkRead"]},
+		{Method: post, Path: "/threads/{id}/suggest-reply", Tag: "messages", Auth: true, Limited: true, Operation: messages.Docs["SuggestReply"]},
+		{Method: put, Path: "/users/{id}/block", Tag: "messages", Auth: true, Operation: messages.Docs["Block"]},
+		{Method: del, Path: "/users/{id}/block", Tag: "messages", Auth: true, Operation: messages.Docs["Unblock"]},
+		{Method: post, Path: "/reports", Tag: "moderation", Auth: true, Operation: reports.Docs["ReportsHandler"]},
+		// Профили и отзывы
+		{Method: get, Path: "/users/me", Tag: "users", Auth: true, Operation: user.Docs["Me"]},
+		{Method: patch, Path: "/users/me", Tag: "users", Auth: true, Operation: user.Docs["UpdateMe"]},
+		{Method: get, Path: "/users/{id}", Tag: "users", Operation: user.Docs["Profile"]},
+		{Method: get, Path: "/users/{id}/listings", Tag: "users", Operation: user.Docs["Listings"]},
+		{Method: get, Path: "/users/{id}/reviews", Tag: "reviews", Operation: user.Docs["List"]},
+		{Method: put, Path: "/listings/{id}/review", Tag: "reviews", Auth: true, Operation: user.Docs["Put"]},
+		{Method: del, Path: "/listings/{id}/review", Tag: "reviews", Auth: true, Operation: user.Docs["Delete"]},
+		// API чата
+		{Method: post, Path: "/chat/session", Tag: "chat", Operation: chat.Docs["StartSession"]},
+		{Method: del, Path: "/chat/session", Tag: "chat", Operation: chat.Docs["EndSession"]},
+		{Method: post, Path: "/chat/ajax", Tag: "chat", Limited: true, Operation: chat.Docs["SendMessage"]},
+		{Method: get, Path: "/chat/history", Tag: "chat", Operation: chat.Docs["GetHistory"]},
+		{Method: get, Path: "/ws", Tag: "realtime", Auth: true, Operation: ws.Docs["GatewayHandler"]},
+		// Админка
+		{Method: post, Path: "/admin/brands", Tag: "admin", Auth: true, Permission: middleware.PermBrandsManage, Operation: brands.Docs["Create"]},
+		{Method: put, Path: "/admin/brands/{slug}", Tag: "admin", Auth: true, Permission: middleware.PermBrandsManage, Operation: brands.Docs["Update"]},
+		{Method: del, Path: "/admin/brands/{slug}", Tag: "admin", Auth: true, Permission: middleware.PermBrandsManage, Operation: brands.Docs["Delete"]},
+		{Method: get, Path: "/admin/reports", Tag: "admin", Auth: true, Permission: middleware.PermReportsReview, Operation: admin.Docs["Reports"]},
+		{Method: patch, Path: "/admin/reports/{id}", Tag: "admin", Auth: true, Permission: middleware.PermReportsReview, Operation: admin.Docs["ResolveReport"]},
+		{Method: get, Path: "/admin/users/{id}/roles", Tag: "admin", Auth: true, Permission: middleware.PermRolesManage, Operation: admin.Docs["UserRoles"]},
+		{Method: put, Path: "/admin/users/{id}/roles/{role}", Tag: "admin", Auth: true, Permission: middleware.PermRolesManage, Operation: admin.Docs["GrantRole"]},
+		{Method: del, Path: "/admin/users/{id}/roles/{role}", Tag: "admin", Auth: true, Permission: middleware.PermRolesManage, Operation: admin.Docs["RevokeRole"]},
+		// Ассистенты из n8n webhook
+		{Method: post, Path: "/assistant/buyer", Tag: "assistant", Limited: true, Operation: assistant.Docs["Buyer"]},
+		{Method: post, Path: "/assistant/seller", Tag: "assistant", Limited: true, Operation: assistant.Docs["Seller"]},
+	}
+}
+
+// OpenAPI — спецификация API, собранная из описаний хендлеров и типов их DTO.
+func OpenAPI() (*openapi.Document, error) {
+	doc, err := openapi.Build(apiInfo, shared.ErrorResp{}, apiRoutes())
+	if err != nil {
+		return nil, err
+	}
+	doc.Servers = []openapi.Server{{URL: APIPrefix}}
+	return doc, nil
+}
+
+// specHandler — GET /openapi.json. Ошибка сборки — баг описаний (её ловит contract-тест).
+func specHandler() http.Handler {
+	doc, err := OpenAPI()
+	if err != nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			shared.Error(w, r, apperror.Internal(err))
+		})
+	}
+	return openapi.Handler(doc)
+}
@@ -0,0 +1,154 @@
+package factory
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/btynybekov/marketplace/config"
+	"github.com/btynybekov/marketplace/internal/ratelimit"
+	"github.com/btynybekov/marketplace/internal/realtime"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// specFile — спецификация в репозитории; обновить: go test ./internal/handlers/factory -run OpenAPI -update
+var (
+	specFile = filepath.Join("..", "..", "..", "api", "openapi.json")
+	update   = flag.Bool("update", false, "rewrite api/openapi.json from the handlers")
+)
+
+// newTestFactory — фабрика без БД: маршруты регистрируются, хендлеры в хранилище не ходят.
+func newTestFactory(t *testing.T) *HandlersFactory {
+	t.Helper()
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.PerMinute(60, 10))
+	quota := ratelimit.NewQuota(ratelimit.NewMemoryUsageStore(), 0, 0, false)
+	hub := realtime.NewHub(realtime.NewLocalBroker())
+	return NewHandlersFactory(repository.New(nil), nil, config.EnvConfig{}, nil, hub, limiter, quota)
+}
+
+// registeredRoutes — "METHOD path" всех маршрутов роутера (включая подроутеры).
+func registeredRoutes(t *testing.T, r *mux.Router) []string {
+	t.Helper()
+	var out []string
+	err := r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // PathPrefix подроутера — не конечный маршрут
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		for _, m := range methods {
+			out = append(out, m+" "+path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestOpenAPICoversRegisteredRoutes(t *testing.T) {
+	doc, err := OpenAPI()
+	if err != nil {
+		t.Fatalf("build spec: %v", err)
+	}
+	api := mux.NewRouter()
+	newTestFactory(t).RegisterAPI(api)
+	registered := registeredRoutes(t, api)
+	documented := doc.Operations()
+
+	inSpec := map[string]bool{}
+	for _, op := range documented {
+		inSpec[op] = true
+	}
+	inRouter := map[string]bool{}
+	for _, op := range registered {
+		inRouter[op] = true
+		if !inSpec[op] {
+			t.Errorf("route %s is registered but missing from the spec (add it to apiRoutes)", op)
+		}
+	}
+	for _, op := range documented {
+		if !inRouter[op] {
+			t.Errorf("route %s is in the spec but not registered in RegisterAPI", op)
+		}
+	}
+}
+
+func TestOpenAPIMatchesSpecFile(t *testing.T) {
+	doc, err := OpenAPI()
+	if err != nil {
+		t.Fatalf("build spec: %v", err)
+	}
+	got, err := doc.JSON()
+	if err != nil {
+		t.Fatalf("marshal spec: %v", err)
+	}
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(specFile), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(specFile, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(specFile)
+	if err != nil {
+		t.Fatalf("read %s: %v", specFile, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("handlers or DTOs diverge from %s:\n%s\nif the change is intended, run: go test ./internal/handlers/factory -run OpenAPI -update",
+			specFile, firstDiff(string(want), string(got)))
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	r := mux.NewRouter()
+	newTestFactory(t).RegisterRoutes(r)
+	want, err := os.ReadFile(specFile)
+	if err != nil {
+		t.Fatalf("read %s: %v", specFile, err)
+	}
+	for _, path := range []string{APIPrefix + "/openapi.json", "/openapi.json"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", path, rec.Code, rec.Body)
+		}
+		if !bytes.Equal(rec.Body.Bytes(), want) {
+			t.Errorf("GET %s differs from %s", path, specFile)
+		}
+	}
+}
+
+// firstDiff — первая отличающаяся строка (полный diff спецификации нечитаем).
+func firstDiff(want, got string) string {
+	wl, gl := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  spec: %s\n  code: %s", i+1, w, g)
+		}
+	}
+	return ""
+}
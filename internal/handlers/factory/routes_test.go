@@ -0,0 +1,53 @@
+package factory
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/btynybekov/marketplace/internal/middleware"
+)
+
+// publicWrites — изменяющие маршруты, доступные без входа: чат и ассистенты работают
+// для анонимов (доступ к сессии проверяет сервис чата, расход — лимиты и квоты).
+var publicWrites = map[string]bool{
+	"POST /chat/session":     true,
+	"DELETE /chat/session":   true,
+	"POST /chat/ajax":        true,
+	"POST /assistant/buyer":  true,
+	"POST /assistant/seller": true,
+}
+
+var pathVar = regexp.MustCompile(`\{[^}]+\}`)
+
+// TestWriteRoutesRequireAuth — любой новый POST/PUT/PATCH/DELETE без авторизации
+// (как бывшие /brands) должен попасть в publicWrites осознанно, а не по забывчивости.
+func TestWriteRoutesRequireAuth(t *testing.T) {
+	api := mux.NewRouter()
+	api.Use(middleware.Authenticate("test-secret"))
+	newTestFactory(t).RegisterAPI(api)
+
+	checked := 0
+	for _, route := range registeredRoutes(t, api) {
+		method, path, _ := strings.Cut(route, " ")
+		if method == http.MethodGet || method == http.MethodHead || publicWrites[route] {
+			continue
+		}
+		target := pathVar.ReplaceAllString(path, "00000000-0000-4000-8000-000000000001")
+		req := httptest.NewRequest(method, target, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		checked++
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req) // хендлеры ходят в хранилище без пула — до них запрос дойти не должен
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s without token = %d, want 401", route, rec.Code)
+		}
+	}
+	if checked == 0 {
+		t.Fatal("no write routes checked")
+	}
+}
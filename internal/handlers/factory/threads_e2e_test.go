@@ -0,0 +1,166 @@
+package factory_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// Объявления набора repotest.Data: L1 — активное объявление alice, L6 — bob, L7 — проданное alice.
+var (
+	aliceListing  = uuid.MustParse("00000000-0000-4000-8000-000000000501")
+	activeListing = uuid.MustParse("00000000-0000-4000-8000-000000000506")
+	soldListing   = uuid.MustParse("00000000-0000-4000-8000-000000000507")
+)
+
+func TestStartThreadOnlyOnActiveListings(t *testing.T) {
+	s := newStand(t)
+	bob, carol := token(t, s.users[1]), token(t, s.users[2])
+
+	var e errorResp
+	if st := s.do(http.MethodPost, "/threads", bob, map[string]any{"listing_id": soldListing}, &e); st != http.StatusConflict {
+		t.Fatalf("thread on a sold listing = %d %+v, want 409", st, e)
+	}
+	var th struct {
+		ID uuid.UUID `json:"id"`
+	}
+	if st := s.do(http.MethodPost, "/threads", carol, map[string]any{"listing_id": activeListing, "text": "ещё продаёте?"}, &th); st != http.StatusCreated {
+		t.Fatalf("thread on an active listing = %d", st)
+	}
+}
+
+func TestReportTargetMustExist(t *testing.T) {
+	s := newStand(t)
+	carol := token(t, s.users[2])
+
+	for _, tc := range []struct {
+		target string
+		id     uuid.UUID
+		want   int
+	}{
+		{"listing", activeListing, http.StatusCreated},
+		{"user", s.users[1], http.StatusCreated},
+		{"listing", uuid.New(), http.StatusNotFound},
+		{"listing", s.users[1], http.StatusNotFound}, // id пользователя — не объявление
+		{"message", uuid.New(), http.StatusNotFound},
+		{"review", uuid.New(), http.StatusNotFound},
+	} {
+		var e errorResp
+		st := s.do(http.MethodPost, "/reports", carol, map[string]any{"target_type": tc.target, "target_id": tc.id, "reason": "spam"}, &e)
+		if st != tc.want || (st == http.StatusNotFound && e.Code != "not_found") {
+			t.Errorf("report on %s %s = %d %+v, want %d", tc.target, tc.id, st, e, tc.want)
+		}
+	}
+}
+
+func TestReviewOnlyByBuyer(t *testing.T) {
+	s := newStand(t)
+	alice, carol, dave := token(t, s.users[0]), token(t, s.users[2]), token(t, s.users[3])
+	listing := "/listings/" + aliceListing.String()
+
+	if st := s.do(http.MethodPost, "/threads", carol, map[string]any{"listing_id": aliceListing, "text": "беру"}, nil); st != http.StatusCreated {
+		t.Fatalf("POST /threads = %d", st)
+	}
+
+	var e errorResp
+	// dave продавцу не писал — покупателем быть не может
+	if st := s.do(http.MethodPatch, listing, alice, map[string]any{"status": "sold", "buyer_id": s.users[3]}, &e); st != http.StatusBadRequest || e.Code != "validation_failed" {
+		t.Fatalf("sold to a user without a thread = %d %+v", st, e)
+	}
+	if st := s.do(http.MethodPatch, listing, alice, map[string]any{"buyer_id": s.users[2]}, &e); st != http.StatusBadRequest {
+		t.Fatalf("buyer of an active listing = %d %+v", st, e)
+	}
+	var l struct {
+		Status  string     `json:"status"`
+		BuyerID *uuid.UUID `json:"buyer_id"`
+	}
+	if st := s.do(http.MethodPatch, listing, alice, map[string]any{"status": "sold", "buyer_id": s.users[2]}, &l); st != http.StatusOK ||
+		l.Status != "sold" || l.BuyerID == nil || *l.BuyerID != s.users[2] {
+		t.Fatalf("sold to carol = %d %+v", st, l)
+	}
+
+	review := map[string]any{"rating": 5}
+	if st := s.do(http.MethodPut, listing+"/review", dave, review, &e); st != http.StatusForbidden {
+		t.Fatalf("review by a non-buyer = %d %+v", st, e)
+	}
+	if st := s.do(http.MethodPut, listing+"/review", carol, review, nil); st != http.StatusOK {
+		t.Fatalf("review by the buyer = %d", st)
+	}
+	// с отзывом покупателя не сменить: объявление не вернуть в продажу
+	if st := s.do(http.MethodPatch, listing, alice, map[string]any{"status": "active"}, &e); st != http.StatusConflict ||
+		e.Error != "the buyer has left a review: the listing cannot be relisted or sold to someone else" {
+		t.Fatalf("relist a reviewed listing = %d %+v", st, e)
+	}
+}
+
+func TestUpdateListingRejectsEmptyFields(t *testing.T) {
+	s := newStand(t)
+	alice := token(t, s.users[0])
+	listing := "/listings/" + aliceListing.String()
+
+	for _, body := range []map[string]any{
+		{"currency_code": ""},
+		{"currency_code": "   "},
+		{"condition": ""},
+		{"status": ""},
+		{"price_amount": 0},
+		{"title": " "},
+	} {
+		var e errorResp
+		if st := s.do(http.MethodPatch, listing, alice, body, &e); st != http.StatusBadRequest || e.Code != "validation_failed" {
+			t.Errorf("PATCH %v = %d %+v, want 400 validation_failed", body, st, e)
+		}
+	}
+	if st := s.do(http.MethodPatch, listing, alice, map[string]any{"currency_code": "usd"}, nil); st != http.StatusOK {
+		t.Errorf("PATCH currency_code=usd = %d, want 200", st)
+	}
+}
+
+func TestSuggestReplyUpstreamFailure(t *testing.T) {
+	s := newStand(t)
+	bob, carol := token(t, s.users[1]), token(t, s.users[2])
+
+	var th struct {
+		ID uuid.UUID `json:"id"`
+	}
+	// последнее сообщение покупателя сценарий LLM превращает в ошибку модели
+	if st := s.do(http.MethodPost, "/threads", carol, map[string]any{"listing_id": activeListing, "text": "упади"}, &th); st != http.StatusCreated {
+		t.Fatalf("POST /threads = %d", st)
+	}
+	var e errorResp
+	if st := s.do(http.MethodPost, "/threads/"+th.ID.String()+"/suggest-reply", bob, nil, &e); st != http.StatusBadGateway || e.Code != "upstream_error" {
+		t.Fatalf("suggest-reply with failing LLM = %d %+v, want 502 upstream_error", st, e)
+	}
+}
+
+func TestSellerListingsCursorIsScoped(t *testing.T) {
+	s := newStand(t)
+	var page struct {
+		Items      []struct{ ID uuid.UUID } `json:"items"`
+		NextCursor string                   `json:"next_cursor"`
+	}
+	alice, bob := "/users/"+s.users[0].String()+"/listings", "/users/"+s.users[1].String()+"/listings"
+
+	if st := s.do(http.MethodGet, alice+"?limit=1", "", nil, &page); st != http.StatusOK || page.NextCursor == "" {
+		t.Fatalf("first page of alice's listings = %d %+v", st, page)
+	}
+	aliceCursor := page.NextCursor
+	if st := s.do(http.MethodGet, "/items?category_slug=phones&limit=1", "", nil, &page); st != http.StatusOK || page.NextCursor == "" {
+		t.Fatalf("first page of the catalog = %d %+v", st, page)
+	}
+	catalogCursor := page.NextCursor
+
+	for _, tc := range []struct {
+		path, cursor string
+		want         int
+	}{
+		{alice, aliceCursor, http.StatusOK},
+		{bob, aliceCursor, http.StatusBadRequest},     // курсор другого продавца
+		{alice, catalogCursor, http.StatusBadRequest}, // курсор каталога
+	} {
+		if st := s.do(http.MethodGet, tc.path+"?limit=1&cursor="+tc.cursor, "", nil, nil); st != tc.want {
+			t.Errorf("GET %s with cursor = %d, want %d", tc.path, st, tc.want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package favorites
+
+import "github.com/btynybekov/marketplace/internal/openapi"
+
+// Docs — описание операций FavoritesHandler для /openapi.json (ключ — имя метода).
+var Docs = openapi.Operations{
+	"List":   {Summary: "List favorite listings", Response: favoritesResp{}},
+	"Add":    {Summary: "Add a listing to favorites", Description: "Idempotent."},
+	"Remove": {Summary: "Remove a listing from favorites"},
+}
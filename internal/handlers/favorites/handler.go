@@ -0,0 +1,77 @@
+package favorites
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+type favoritesResp struct {
+	Items []models.Product `json:"items"`
+	Count int              `json:"count"`
+}
+
+// FavoritesHandler — избранные объявления пользователя (маршруты под RequireUser).
+type FavoritesHandler struct {
+	repos repository.RepositorySet
+}
+
+func NewFavoritesHandler(repos repository.RepositorySet) *FavoritesHandler {
+	return &FavoritesHandler{repos: repos}
+}
+
+// List — GET /favorites
+func (h *FavoritesHandler) List() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		items, err := h.repos.Favorites().List(r.Context(), uid)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		if items == nil {
+			items = []models.Product{}
+		}
+		shared.WriteJSON(w, http.StatusOK, favoritesResp{Items: items, Count: len(items)})
+	})
+}
+
+// Add — PUT /favorites/{listing_id} (идемпотентно)
+func (h *FavoritesHandler) Add() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		lid, err := uuid.Parse(mux.Vars(r)["listing_id"])
+		if err != nil {
+			shared.BadRequest(w, r, "invalid listing id")
+			return
+		}
+		if err := h.repos.Favorites().Add(r.Context(), uid, lid); err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// Remove — DELETE /favorites/{listing_id}
+func (h *FavoritesHandler) Remove() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		lid, err := uuid.Parse(mux.Vars(r)["listing_id"])
+		if err != nil {
+			shared.BadRequest(w, r, "invalid listing id")
+			return
+		}
+		if err := h.repos.Favorites().Remove(r.Context(), uid, lid); err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
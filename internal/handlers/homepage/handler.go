@@ -1,43 +1,40 @@
 package homepage
 
 import (
-	"html/template"
 	"net/http"
 
+	"github.com/btynybekov/marketplace/internal/apperror"
 	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/models"
 	"github.com/btynybekov/marketplace/internal/repository"
 )
 
+type homepageResp struct {
+	Categories []models.Category `json:"categories"`
+}
+
 type HomePageHandler struct {
 	repos repository.RepositorySet
-	tmpl  *template.Template
+	tmpl  shared.Templates
 }
 
-func NewHomePageHandler(repos repository.RepositorySet, tmpl *template.Template) *HomePageHandler {
+func NewHomePageHandler(repos repository.RepositorySet, tmpl shared.Templates) *HomePageHandler {
 	return &HomePageHandler{repos: repos, tmpl: tmpl}
 }
 
+// ServeHTTP — GET / (веб-роутер): HTML или JSON по Accept.
 func (h *HomePageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		shared.Error(w, r, apperror.MethodNotAllowed())
 		return
 	}
 
 	ctx := r.Context()
 	roots, err := h.repos.Categories().ListRoots(ctx)
 	if err != nil {
-		shared.InternalError(w, err)
-		return
-	}
-
-	if h.tmpl == nil || h.tmpl.Lookup("homepage.html") == nil {
-		shared.WriteJSON(w, http.StatusOK, map[string]any{"categories": roots})
+		shared.InternalError(w, r, err)
 		return
 	}
 
-	if err := h.tmpl.ExecuteTemplate(w, "homepage.html", map[string]any{
-		"Categories": roots,
-	}); err != nil {
-		http.Error(w, "template render error: "+err.Error(), http.StatusInternalServerError)
-	}
+	shared.Render(w, r, h.tmpl, "homepage.html", map[string]any{"Categories": roots}, homepageResp{Categories: roots})
 }
@@ -0,0 +1,31 @@
+package items
+
+import "github.com/btynybekov/marketplace/internal/openapi"
+
+// listQuery — общие параметры выдачи /items и /search (см. parsePriceQuery, decodeCursor).
+var listQuery = []openapi.Param{
+	{Name: "brand", Description: "Brand slug or free spelling"},
+	{Name: "price_min", Description: "Lower price bound in price_currency", Type: "number"},
+	{Name: "price_max", Description: "Upper price bound in price_currency", Type: "number"},
+	{Name: "price_currency", Description: "Currency of price bounds (default KGS)"},
+	{Name: "currency", Description: "Display currency"},
+	{Name: "sort", Enum: []string{"new", "price_asc", "price_desc"}},
+	{Name: "limit", Description: "Page size, 1..50 (default 20)", Type: "integer"},
+	{Name: "offset", Type: "integer"},
+	{Name: "cursor", Description: "next_cursor of the previous page; takes precedence over offset"},
+}
+
+// Docs — описание операций пакета для /openapi.json (ключ — метод ItemHandler или имя хендлера).
+var Docs = openapi.Operations{
+	"List": {
+		Summary:  "List listings of a category",
+		Query:    append([]openapi.Param{{Name: "category_slug", Required: true}}, listQuery...),
+		Response: itemsResp{},
+	},
+	"SearchHandler": {
+		Summary:     "Search listings",
+		Description: "At least one of q, brand or category_slug is required.",
+		Query:       append([]openapi.Param{{Name: "q", Description: "Substring of the title"}, {Name: "category_slug"}}, listQuery...),
+		Response:    searchResp{},
+	},
+}
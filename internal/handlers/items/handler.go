@@ -1,69 +1,142 @@
 package items
 
 import (
-	"html/template"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/btynybekov/marketplace/internal/catalog"
+	"github.com/btynybekov/marketplace/internal/currency"
 	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
 	"github.com/btynybekov/marketplace/internal/repository"
 )
 
 type ItemHandler struct {
-	repos repository.RepositorySet
-	tmpl  *template.Template // ожидается "items.html"
+	repos   repository.RepositorySet
+	tmpl    shared.Templates // страница "items.html"
+	brands  *catalog.BrandResolver
+	rates   *currency.Converter
+	display string // валюта отображения по умолчанию
+	cursors *pagination.Codec
 }
 
-func NewItemHandler(repos repository.RepositorySet, tmpl *template.Template) *ItemHandler {
-	return &ItemHandler{repos: repos, tmpl: tmpl}
+func NewItemHandler(repos repository.RepositorySet, tmpl shared.Templates, rates *currency.Converter, displayCurrency string, cursors *pagination.Codec) *ItemHandler {
+	return &ItemHandler{
+		repos:   repos,
+		tmpl:    tmpl,
+		brands:  catalog.NewBrandResolver(repos.Brands()),
+		rates:   rates,
+		display: displayCurrency,
+		cursors: cursors,
+	}
 }
 
-// GET /items?category_slug=cars&limit=20&offset=0
-func (h *ItemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+// List — GET /items?category_slug=cars&brand=toyota&price_max=15000&price_currency=KGS&sort=price_asc&currency=USD&limit=20&offset=0 (JSON API)
+func (h *ItemHandler) List() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, _, ok := h.load(w, r)
+		if !ok {
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, resp)
+	})
+}
 
+// Page — GET /items (веб-роутер): те же параметры, HTML или JSON по Accept.
+func (h *ItemHandler) Page() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, category, ok := h.load(w, r)
+		if !ok {
+			return
+		}
+		shared.Render(w, r, h.tmpl, "items.html", map[string]any{
+			"Items":        resp.Items,
+			"CategorySlug": category,
+			"BrandSlug":    resp.Brand,
+			"Sort":         resp.Sort,
+			"Currency":     resp.Currency,
+			"NextCursor":   resp.NextCursor,
+		}, resp)
+	})
+}
+
+// load — страница выдачи категории; при ошибке сам отвечает клиенту.
+func (h *ItemHandler) load(w http.ResponseWriter, r *http.Request) (itemsResp, string, bool) {
 	ctx := r.Context()
 	category := strings.TrimSpace(r.URL.Query().Get("category_slug"))
 	if category == "" {
-		shared.BadRequest(w, "category_slug is required")
-		return
+		shared.BadRequest(w, r, "category_slug is required")
+		return itemsResp{}, "", false
 	}
 
 	limit := parseInt(r.URL.Query().Get("limit"), 20, 1, 50)
 	offset := parseInt(r.URL.Query().Get("offset"), 0, 0, 1000000)
+	pq, err := parsePriceQuery(r, h.rates, h.display)
+	if err != nil {
+		shared.Error(w, r, err)
+		return itemsResp{}, "", false
+	}
+	after, ok := decodeCursor(r, h.cursors, pq.Sort)
+	if !ok {
+		shared.BadRequest(w, r, "invalid cursor")
+		return itemsResp{}, "", false
+	}
+	if after != nil {
+		offset = 0
+	}
 
-	products, err := h.repos.Products().ListByCategorySlug(ctx, category, limit, offset)
+	brandSlug, found, err := resolveBrand(r, h.brands)
 	if err != nil {
-		shared.InternalError(w, err)
-		return
+		shared.InternalError(w, r, err)
+		return itemsResp{}, "", false
 	}
 
-	if acceptsJSON(r) || h.tmpl == nil || h.tmpl.Lookup("items.html") == nil {
-		shared.WriteJSON(w, http.StatusOK, map[string]any{
-			"items":  products,
-			"count":  len(products),
-			"limit":  limit,
-			"offset": offset,
+	products := []models.Product{}
+	if found {
+		products, err = h.repos.Products().List(ctx, repository.ProductFilter{
+			CategorySlug: category,
+			BrandSlug:    brandSlug,
+			PriceMinKGS:  pq.MinKGS,
+			PriceMaxKGS:  pq.MaxKGS,
+			Sort:         pq.Sort,
+			After:        after,
+			Limit:        limit + 1,
+			Offset:       offset,
 		})
-		return
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return itemsResp{}, "", false
+		}
 	}
+	products, nextCursor := nextPage(products, limit, pq.Sort, h.cursors)
+	withDisplayPrice(products, h.rates, pq.Display)
 
-	if err := h.tmpl.ExecuteTemplate(w, "items.html", map[string]any{
-		"Items":        products,
-		"CategorySlug": category,
-	}); err != nil {
-		http.Error(w, "template render error: "+err.Error(), http.StatusInternalServerError)
-	}
+	return itemsResp{
+		Items:      products,
+		Count:      len(products),
+		Brand:      brandSlug,
+		Sort:       pq.Sort,
+		Currency:   pq.Display,
+		NextCursor: nextCursor,
+		Limit:      limit,
+		Offset:     offset,
+	}, category, true
 }
 
-func acceptsJSON(r *http.Request) bool {
-	accept := r.Header.Get("Accept")
-	return strings.Contains(strings.ToLower(accept), "application/json") ||
-		strings.Contains(strings.ToLower(accept), "json")
+// resolveBrand — разбирает ?brand=: принимает slug или свободное написание ("айфон").
+// found=false означает, что бренд указан, но не распознан — выдача должна быть пустой.
+func resolveBrand(r *http.Request, brands *catalog.BrandResolver) (slug string, found bool, err error) {
+	q := strings.TrimSpace(r.URL.Query().Get("brand"))
+	if q == "" {
+		return "", true, nil
+	}
+	b, ok, err := brands.Resolve(r.Context(), q)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	return b.Slug, true, nil
 }
 
 func parseInt(s string, def, min, max int) int {
@@ -0,0 +1,50 @@
+package items
+
+import (
+	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// itemsResp — страница выдачи GET /items и GET /search.
+type itemsResp struct {
+	Items      []models.Product `json:"items"`
+	Count      int              `json:"count"`
+	Brand      string           `json:"brand"`
+	Sort       string           `json:"sort"`
+	Currency   string           `json:"currency"`
+	NextCursor string           `json:"next_cursor"`
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+}
+
+// searchResp — GET /search: та же страница и исходный запрос.
+type searchResp struct {
+	Query string `json:"query"`
+	itemsResp
+}
+
+// decodeCursor — ?cursor= (keyset) имеет приоритет над ?offset=.
+// Курсор должен быть выдан для той же сортировки, иначе ok=false.
+func decodeCursor(r *http.Request, codec *pagination.Codec, sort string) (cur *pagination.Cursor, ok bool) {
+	tok := r.URL.Query().Get("cursor")
+	if tok == "" {
+		return nil, true
+	}
+	c, err := codec.Decode(tok, sort)
+	if err != nil {
+		return nil, false
+	}
+	return &c, true
+}
+
+// nextPage — отрезает лишний элемент (запрашивали limit+1) и строит next_cursor.
+func nextPage(products []models.Product, limit int, sort string, codec *pagination.Codec) ([]models.Product, string) {
+	if len(products) <= limit {
+		return products, ""
+	}
+	products = products[:limit]
+	return products, codec.Encode(repository.ProductCursor(products[limit-1], sort))
+}
@@ -0,0 +1,83 @@
+package items
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/currency"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// priceQuery — разобранные ?price_min=&price_max=&price_currency=&sort=&currency=.
+type priceQuery struct {
+	MinKGS   *float64
+	MaxKGS   *float64
+	Sort     string
+	Display  string // валюта отображения
+	Currency string // валюта, в которой заданы price_min/price_max
+}
+
+// parsePriceQuery — границы цены переводятся в KGS, чтобы фильтр "до 15000 сом"
+// находил и объявления в USD/RUB/KZT. Неизвестная валюта → *apperror.Error (400).
+func parsePriceQuery(r *http.Request, conv *currency.Converter, displayDefault string) (pq priceQuery, err error) {
+	q := r.URL.Query()
+	pq.Currency = strings.ToUpper(strings.TrimSpace(q.Get("price_currency")))
+	if pq.Currency == "" {
+		pq.Currency = currency.Base
+	}
+	pq.Display = strings.ToUpper(strings.TrimSpace(q.Get("currency")))
+	if pq.Display == "" {
+		pq.Display = displayDefault
+	}
+	if _, ok := conv.Rate(pq.Currency); !ok {
+		return pq, apperror.BadRequest("unknown price_currency")
+	}
+	if _, ok := conv.Rate(pq.Display); !ok {
+		return pq, apperror.BadRequest("unknown currency")
+	}
+
+	for _, p := range []struct {
+		key string
+		dst **float64
+	}{{"price_min", &pq.MinKGS}, {"price_max", &pq.MaxKGS}} {
+		s := q.Get(p.key)
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+			return pq, apperror.BadRequest("%s must be a non-negative number", p.key)
+		}
+		kgs, _ := conv.Convert(v, pq.Currency, currency.Base)
+		*p.dst = &kgs
+	}
+
+	switch s := q.Get("sort"); s {
+	case "", repository.SortNewest:
+		pq.Sort = repository.SortNewest
+	case repository.SortPriceAsc, repository.SortPriceDesc:
+		pq.Sort = s
+	default:
+		return pq, apperror.BadRequest("sort must be one of new, price_asc, price_desc")
+	}
+	return pq, nil
+}
+
+// withDisplayPrice — добавляет цену в валюте отображения (исходная цена остаётся как есть).
+func withDisplayPrice(products []models.Product, conv *currency.Converter, display string) {
+	for i := range products {
+		p := &products[i]
+		switch {
+		case strings.EqualFold(p.CurrencyCode, display):
+			p.DisplayPrice = &models.Money{Amount: p.PriceAmount, CurrencyCode: display}
+		case p.PriceKGS != nil:
+			if v, ok := conv.Convert(*p.PriceKGS, currency.Base, display); ok {
+				p.DisplayPrice = &models.Money{Amount: v, CurrencyCode: display}
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package items
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/currency"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+func TestParsePriceQuery(t *testing.T) {
+	conv := currency.NewConverter()
+	conv.Set(map[string]float64{"USD": 87.45})
+	kgs := func(v float64) *float64 { return &v }
+
+	for _, tc := range []struct {
+		query    string
+		min, max *float64
+		sort     string
+		display  string
+		bad      bool
+	}{
+		{"", nil, nil, repository.SortNewest, "KGS", false},
+		{"price_min=100&price_max=15000", kgs(100), kgs(15000), repository.SortNewest, "KGS", false},
+		{"price_max=10&price_currency=usd", nil, kgs(874.5), repository.SortNewest, "KGS", false},
+		{"currency=usd&sort=price_desc", nil, nil, repository.SortPriceDesc, "USD", false},
+		{"sort=price_asc", nil, nil, repository.SortPriceAsc, "KGS", false},
+		{"price_min=0", kgs(0), nil, repository.SortNewest, "KGS", false},
+		{"price_min=-1", nil, nil, "", "", true},
+		{"price_min=abc", nil, nil, "", "", true},
+		{"price_max=NaN", nil, nil, "", "", true},
+		{"price_max=Inf", nil, nil, "", "", true},
+		{"price_currency=EUR", nil, nil, "", "", true},
+		{"currency=EUR", nil, nil, "", "", true},
+		{"sort=cheap", nil, nil, "", "", true},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/items?"+tc.query, nil)
+		pq, err := parsePriceQuery(r, conv, "KGS")
+		if tc.bad {
+			var ae *apperror.Error
+			if !errors.As(err, &ae) || ae.Status != http.StatusBadRequest {
+				t.Errorf("%q: error %v, want 400", tc.query, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tc.query, err)
+			continue
+		}
+		if !samePtr(pq.MinKGS, tc.min) || !samePtr(pq.MaxKGS, tc.max) || pq.Sort != tc.sort || pq.Display != tc.display {
+			t.Errorf("%q: %+v", tc.query, pq)
+		}
+	}
+}
+
+func samePtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return math.Abs(*a-*b) < 1e-9
+}
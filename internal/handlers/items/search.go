@@ -0,0 +1,99 @@
+package items
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/btynybekov/marketplace/internal/catalog"
+	"github.com/btynybekov/marketplace/internal/currency"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// SearchHandler — полнотекстовый (по заголовку) поиск объявлений с фильтрами.
+type SearchHandler struct {
+	repos   repository.RepositorySet
+	brands  *catalog.BrandResolver
+	rates   *currency.Converter
+	display string
+	cursors *pagination.Codec
+}
+
+func NewSearchHandler(repos repository.RepositorySet, rates *currency.Converter, displayCurrency string, cursors *pagination.Codec) *SearchHandler {
+	return &SearchHandler{
+		repos:   repos,
+		brands:  catalog.NewBrandResolver(repos.Brands()),
+		rates:   rates,
+		display: displayCurrency,
+		cursors: cursors,
+	}
+}
+
+// GET /search?q=iphone&brand=apple&category_slug=phones&price_max=15000&sort=price_asc&limit=20&offset=0
+func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	category := strings.TrimSpace(r.URL.Query().Get("category_slug"))
+	if q == "" && category == "" && r.URL.Query().Get("brand") == "" {
+		shared.BadRequest(w, r, "q, brand or category_slug is required")
+		return
+	}
+
+	limit := parseInt(r.URL.Query().Get("limit"), 20, 1, 50)
+	offset := parseInt(r.URL.Query().Get("offset"), 0, 0, 1000000)
+	pq, err := parsePriceQuery(r, h.rates, h.display)
+	if err != nil {
+		shared.Error(w, r, err)
+		return
+	}
+	after, ok := decodeCursor(r, h.cursors, pq.Sort)
+	if !ok {
+		shared.BadRequest(w, r, "invalid cursor")
+		return
+	}
+	if after != nil {
+		offset = 0
+	}
+
+	brandSlug, found, err := resolveBrand(r, h.brands)
+	if err != nil {
+		shared.InternalError(w, r, err)
+		return
+	}
+
+	products := []models.Product{}
+	if found {
+		products, err = h.repos.Products().List(r.Context(), repository.ProductFilter{
+			CategorySlug: category,
+			BrandSlug:    brandSlug,
+			Query:        q,
+			PriceMinKGS:  pq.MinKGS,
+			PriceMaxKGS:  pq.MaxKGS,
+			Sort:         pq.Sort,
+			After:        after,
+			Limit:        limit + 1,
+			Offset:       offset,
+		})
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+	}
+	products, nextCursor := nextPage(products, limit, pq.Sort, h.cursors)
+	withDisplayPrice(products, h.rates, pq.Display)
+
+	shared.WriteJSON(w, http.StatusOK, searchResp{
+		Query: q,
+		itemsResp: itemsResp{
+			Items:      products,
+			Count:      len(products),
+			Brand:      brandSlug,
+			Sort:       pq.Sort,
+			Currency:   pq.Display,
+			NextCursor: nextCursor,
+			Limit:      limit,
+			Offset:     offset,
+		},
+	})
+}
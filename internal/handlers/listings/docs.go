@@ -0,0 +1,27 @@
+package listings
+
+import (
+	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/openapi"
+)
+
+// Docs — описание операций ListingHandler для /openapi.json (ключ — имя метода).
+var Docs = openapi.Operations{
+	"Create": {
+		Summary:     "Create a listing",
+		Description: "Without product_id the listing is matched to a catalog product automatically.",
+		Request:     createListingReq{},
+		Response:    createListingResp{},
+		Status:      http.StatusCreated,
+	},
+	"Get": {Summary: "Get a listing", Response: models.Listing{}},
+	"Update": {
+		Summary:     "Update a listing",
+		Description: "Only passed fields change. Own listings need listings.edit.own, others' — listings.edit.any. With status sold, buyer_id names the buyer (a user with a thread about the listing) who may leave a review; it cannot change once reviewed (409).",
+		Request:     updateListingReq{},
+		Response:    models.Listing{},
+	},
+	"Delete": {Summary: "Delete a listing", Description: "Soft delete; permissions as for update."},
+}
@@ -0,0 +1,315 @@
+package listings
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/catalog"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/logging"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+type createListingReq struct {
+	CategorySlug string         `json:"category_slug" validate:"required,max=100"`
+	ProductID    string         `json:"product_id,omitempty" validate:"uuid"` // если продавец сам выбрал модель
+	Title        string         `json:"title" validate:"required,max=200"`
+	Description  string         `json:"description" validate:"max=10000"`
+	PriceAmount  float64        `json:"price_amount" validate:"required,gt=0"`
+	CurrencyCode string         `json:"currency_code,omitempty" validate:"len=3"`
+	Condition    string         `json:"condition" validate:"required,enum=new|used"`
+	LocationText string         `json:"location_text,omitempty" validate:"max=200"`
+	Attrs        map[string]any `json:"attrs,omitempty" validate:"max=50"`
+}
+
+// updateListingReq — PATCH: меняются только переданные поля.
+type updateListingReq struct {
+	Title        *string         `json:"title,omitempty" validate:"max=200"`
+	Description  *string         `json:"description,omitempty" validate:"max=10000"`
+	PriceAmount  *float64        `json:"price_amount,omitempty" validate:"gt=0"`
+	CurrencyCode *string         `json:"currency_code,omitempty" validate:"len=3"`
+	Condition    *string         `json:"condition,omitempty" validate:"enum=new|used"`
+	LocationText *string         `json:"location_text,omitempty" validate:"max=200"`
+	Attrs        *map[string]any `json:"attrs,omitempty" validate:"max=50"`
+	Status       *string         `json:"status,omitempty" validate:"enum=active|paused|sold"`
+	BuyerID      *uuid.UUID      `json:"buyer_id,omitempty"` // при status=sold: покупатель из переписки по объявлению
+}
+
+type createListingResp struct {
+	Listing models.Listing `json:"listing"`
+	Match   *catalog.Match `json:"match,omitempty"` // к какой модели каталога привязали автоматически
+}
+
+// ListingHandler — создание и просмотр объявлений.
+type ListingHandler struct {
+	repos   repository.RepositorySet
+	matcher *catalog.Matcher
+}
+
+func NewListingHandler(repos repository.RepositorySet) *ListingHandler {
+	return &ListingHandler{
+		repos:   repos,
+		matcher: catalog.NewMatcher(repos.Brands(), repos.Catalog()),
+	}
+}
+
+// Create — POST /listings (только авторизованный продавец).
+// Если product_id не указан, объявление сопоставляется с моделью каталога автоматически.
+func (h *ListingHandler) Create() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		sellerID, ok := middleware.UserIDFromContext(ctx)
+		if !ok {
+			shared.Unauthorized(w, r, "unauthorized")
+			return
+		}
+
+		var req createListingReq
+		if !shared.DecodeJSON(w, r, &req) {
+			return
+		}
+		req.Title = strings.TrimSpace(req.Title)
+		if req.CurrencyCode == "" {
+			req.CurrencyCode = "KGS"
+		}
+
+		cat, err := h.repos.Categories().GetBySlug(ctx, req.CategorySlug)
+		if errors.Is(err, pgx.ErrNoRows) {
+			shared.BadRequest(w, r, "unknown category_slug")
+			return
+		}
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+
+		l := models.Listing{
+			SellerID:     sellerID,
+			CategoryID:   cat.ID,
+			Title:        req.Title,
+			Description:  req.Description,
+			PriceAmount:  req.PriceAmount,
+			CurrencyCode: strings.ToUpper(req.CurrencyCode),
+			Condition:    req.Condition,
+			LocationText: req.LocationText,
+			Attrs:        req.Attrs,
+		}
+		if req.ProductID != "" {
+			pid, err := uuid.Parse(req.ProductID)
+			if err != nil {
+				shared.BadRequest(w, r, "invalid product_id")
+				return
+			}
+			l.ProductID = &pid
+		}
+
+		created, err := h.repos.Listings().Create(ctx, l)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+
+		resp := createListingResp{Listing: created}
+		if created.ProductID == nil {
+			// сопоставление — best effort: объявление уже создано, ошибка не должна его терять
+			m, ok, err := h.matcher.Match(ctx, created)
+			switch {
+			case err != nil:
+				slog.WarnContext(ctx, "listing product match failed", slog.String("listing_id", created.ID.String()), logging.Err(err))
+			case ok:
+				if err := h.repos.Listings().SetProduct(ctx, created.ID, m.Product.ID); err != nil {
+					slog.WarnContext(ctx, "listing set product failed", slog.String("listing_id", created.ID.String()), logging.Err(err))
+					break
+				}
+				resp.Listing.ProductID = &m.Product.ID
+				resp.Match = &m
+			}
+		}
+
+		shared.WriteJSON(w, http.StatusCreated, resp)
+	})
+}
+
+// Get — GET /listings/{id}
+func (h *ListingHandler) Get() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			shared.BadRequest(w, r, "invalid listing id")
+			return
+		}
+		l, err := h.repos.Listings().GetByID(r.Context(), id)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && l.Status == "deleted") {
+			shared.NotFound(w, r, "listing not found")
+			return
+		}
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, l)
+	})
+}
+
+// Update — PATCH /listings/{id}: владелец (listings.edit.own) или модератор (listings.edit.any).
+func (h *ListingHandler) Update() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l, ok := h.editable(w, r)
+		if !ok {
+			return
+		}
+		var req updateListingReq
+		if !shared.DecodeJSON(w, r, &req) {
+			return
+		}
+		prevBuyer := l.BuyerID
+		if req.Title != nil {
+			l.Title = strings.TrimSpace(*req.Title)
+		}
+		if req.Description != nil {
+			l.Description = *req.Description
+		}
+		if req.PriceAmount != nil {
+			l.PriceAmount = *req.PriceAmount
+		}
+		if req.CurrencyCode != nil {
+			l.CurrencyCode = strings.ToUpper(strings.TrimSpace(*req.CurrencyCode))
+		}
+		if req.Condition != nil {
+			l.Condition = *req.Condition
+		}
+		if req.LocationText != nil {
+			l.LocationText = *req.LocationText
+		}
+		if req.Attrs != nil {
+			l.Attrs = *req.Attrs
+		}
+		if req.Status != nil {
+			l.Status = *req.Status
+		}
+		switch {
+		case req.BuyerID != nil:
+			l.BuyerID = req.BuyerID
+		case l.Status != "sold":
+			l.BuyerID = nil // снова в продаже — покупателя нет
+		}
+
+		// теги проверяют только переданные поля — обязательные проверяем после слияния
+		// (заголовок и код валюты — после TrimSpace: строка из пробелов тег max/len проходит)
+		var fields []apperror.FieldError
+		if l.Title == "" {
+			fields = append(fields, apperror.FieldError{Field: "title", Rule: "required", Message: "is required"})
+		}
+		if len(l.CurrencyCode) != 3 {
+			fields = append(fields, apperror.FieldError{Field: "currency_code", Rule: "len", Message: "must be exactly %s characters", Args: []any{"3"}})
+		}
+		if l.PriceAmount <= 0 {
+			fields = append(fields, apperror.FieldError{Field: "price_amount", Rule: "gt", Message: "must be greater than %s", Args: []any{"0"}})
+		}
+		if req.BuyerID != nil {
+			// покупатель — тот, кто писал продавцу по этому объявлению
+			_, err := h.repos.Threads().Find(r.Context(), l.ID, *req.BuyerID)
+			switch {
+			case l.Status != "sold":
+				fields = append(fields, apperror.FieldError{Field: "buyer_id", Rule: "sold", Message: "is allowed only with status sold"})
+			case errors.Is(err, pgx.ErrNoRows):
+				fields = append(fields, apperror.FieldError{Field: "buyer_id", Rule: "thread", Message: "must have a thread about this listing"})
+			case err != nil:
+				shared.InternalError(w, r, err)
+				return
+			}
+		}
+		if len(fields) > 0 {
+			shared.Error(w, r, apperror.Validation(fields...))
+			return
+		}
+		// отзыв привязан к покупателю: пока он есть, объявление не вернуть в продажу и не продать другому
+		if prevBuyer != nil && (l.BuyerID == nil || *l.BuyerID != *prevBuyer) {
+			reviewed, err := h.repos.Reviews().Exists(r.Context(), l.ID)
+			if err != nil {
+				shared.InternalError(w, r, err)
+				return
+			}
+			if reviewed {
+				shared.Conflict(w, r, "the buyer has left a review: the listing cannot be relisted or sold to someone else")
+				return
+			}
+		}
+
+		updated, err := h.repos.Listings().Update(r.Context(), l)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, updated)
+	})
+}
+
+// Delete — DELETE /listings/{id}: мягкое удаление (status = deleted), права как у Update.
+func (h *ListingHandler) Delete() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l, ok := h.editable(w, r)
+		if !ok {
+			return
+		}
+		l.Status = "deleted"
+		if _, err := h.repos.Listings().Update(r.Context(), l); err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// editable — загружает объявление {id} и проверяет право на его изменение:
+// своё — при listings.edit.own, чужое — только при listings.edit.any.
+func (h *ListingHandler) editable(w http.ResponseWriter, r *http.Request) (models.Listing, bool) {
+	ctx := r.Context()
+	uid, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		shared.Unauthorized(w, r, "unauthorized")
+		return models.Listing{}, false
+	}
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		shared.BadRequest(w, r, "invalid listing id")
+		return models.Listing{}, false
+	}
+	l, err := h.repos.Listings().GetByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && l.Status == "deleted") {
+		shared.NotFound(w, r, "listing not found")
+		return models.Listing{}, false
+	}
+	if err != nil {
+		shared.InternalError(w, r, err)
+		return models.Listing{}, false
+	}
+
+	perm := middleware.PermListingsEditAny
+	if l.SellerID == uid {
+		perm = middleware.PermListingsEditOwn
+	}
+	allowed, err := h.repos.Roles().HasPermission(ctx, uid, perm)
+	if err == nil && !allowed && perm == middleware.PermListingsEditOwn {
+		// модератор может править и свои объявления, даже без роли продавца
+		allowed, err = h.repos.Roles().HasPermission(ctx, uid, middleware.PermListingsEditAny)
+	}
+	if err != nil {
+		shared.InternalError(w, r, err)
+		return models.Listing{}, false
+	}
+	if !allowed {
+		shared.Forbidden(w, r, "you can only edit your own listings")
+		return models.Listing{}, false
+	}
+	return l, true
+}
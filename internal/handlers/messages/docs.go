@@ -0,0 +1,39 @@
+package messages
+
+import (
+	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/openapi"
+)
+
+// Docs — описание операций MessagesHandler для /openapi.json (ключ — имя метода).
+var Docs = openapi.Operations{
+	"StartThread": {
+		Summary:     "Start a thread with the seller of a listing",
+		Description: "Returns the existing thread if the buyer already wrote about this listing. A new thread can be started only on an active listing (409 otherwise).",
+		Request:     startThreadReq{},
+		Response:    models.Thread{},
+		Status:      http.StatusCreated,
+	},
+	"ListThreads": {Summary: "List threads", Response: threadsResp{}},
+	"Unread":      {Summary: "Count unread messages", Response: unreadResp{}},
+	"ListMessages": {
+		Summary: "List messages of a thread",
+		Query: []openapi.Param{
+			{Name: "limit", Description: "Page size, 1..200 (default 50)", Type: "integer"},
+			{Name: "cursor", Description: "next_cursor of the previous page (earlier messages)"},
+		},
+		Response: messagesResp{},
+	},
+	"Send": {
+		Summary:  "Send a message",
+		Request:  sendReq{},
+		Response: models.DirectMessage{},
+		Status:   http.StatusCreated,
+	},
+	"MarkRead":     {Summary: "Mark counterpart's messages as read", Response: markedResp{}},
+	"SuggestReply": {Summary: "Suggest a reply to the buyer (seller only)", Response: suggestionResp{}},
+	"Block":        {Summary: "Block a user"},
+	"Unblock":      {Summary: "Unblock a user"},
+}
@@ -0,0 +1,390 @@
+package messages
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/config"
+	"github.com/btynybekov/marketplace/internal/ai"
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/realtime"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+type startThreadReq struct {
+	ListingID uuid.UUID `json:"listing_id" validate:"required"`
+	Text      string    `json:"text,omitempty" validate:"max=4000"` // первое сообщение (опционально)
+}
+
+type sendReq struct {
+	Text string `json:"text" validate:"required,max=4000"`
+}
+
+type messagesResp struct {
+	Thread     models.Thread          `json:"thread"`
+	Messages   []models.DirectMessage `json:"messages"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+type threadsResp struct {
+	Threads []models.Thread `json:"threads"`
+}
+
+type unreadResp struct {
+	Unread int `json:"unread"`
+}
+
+type markedResp struct {
+	Marked int `json:"marked"`
+}
+
+type suggestionResp struct {
+	Suggestion string `json:"suggestion"`
+}
+
+// MessagesHandler — переписка покупатель ↔ продавец по объявлению
+// (все маршруты под RequireUser).
+type MessagesHandler struct {
+	repos   repository.RepositorySet
+	ai      ai.Client
+	cfg     config.EnvConfig
+	cursors *pagination.Codec
+	events  realtime.Publisher
+}
+
+func NewMessagesHandler(repos repository.RepositorySet, aiClient ai.Client, cfg config.EnvConfig, cursors *pagination.Codec, events realtime.Publisher) *MessagesHandler {
+	return &MessagesHandler{repos: repos, ai: aiClient, cfg: cfg, cursors: cursors, events: events}
+}
+
+// StartThread — POST /threads {listing_id, text?} — покупатель пишет продавцу.
+// Повторный вызов по тому же объявлению возвращает существующую переписку.
+func (h *MessagesHandler) StartThread() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uid, _ := middleware.UserIDFromContext(ctx)
+
+		var req startThreadReq
+		if !shared.DecodeJSON(w, r, &req) {
+			return
+		}
+
+		l, err := h.repos.Listings().GetByID(ctx, req.ListingID)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && l.Status == "deleted") {
+			shared.NotFound(w, r, "listing not found")
+			return
+		}
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		if l.SellerID == uid {
+			shared.BadRequest(w, r, "cannot message yourself")
+			return
+		}
+		if !h.allowed(w, r, uid, l.SellerID) {
+			return
+		}
+		// новую переписку можно начать только по активному объявлению и если продавец принимает сообщения
+		if _, err := h.repos.Threads().Find(ctx, l.ID, uid); errors.Is(err, pgx.ErrNoRows) {
+			if l.Status != "active" {
+				shared.Conflict(w, r, "threads can be started only on active listings")
+				return
+			}
+			p, err := h.repos.Users().GetProfile(ctx, l.SellerID)
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				shared.InternalError(w, r, err)
+				return
+			}
+			if err == nil && !p.Contact.AllowMessages {
+				shared.Forbidden(w, r, "seller does not accept messages")
+				return
+			}
+		} else if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+
+		t, err := h.repos.Threads().GetOrCreate(ctx, l.ID, uid, l.SellerID)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		if text := strings.TrimSpace(req.Text); text != "" {
+			m, err := h.repos.Threads().AppendMessage(ctx, t.ID, uid, text)
+			if err != nil {
+				shared.InternalError(w, r, err)
+				return
+			}
+			h.publishMessage(r, t, m)
+		}
+		shared.WriteJSON(w, http.StatusCreated, t)
+	})
+}
+
+// ListThreads — GET /threads
+func (h *MessagesHandler) ListThreads() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		list, err := h.repos.Threads().ListByUser(r.Context(), uid)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		if list == nil {
+			list = []models.Thread{}
+		}
+		shared.WriteJSON(w, http.StatusOK, threadsResp{Threads: list})
+	})
+}
+
+// Unread — GET /threads/unread — общее число непрочитанных сообщений.
+func (h *MessagesHandler) Unread() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		n, err := h.repos.Threads().UnreadCount(r.Context(), uid)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, unreadResp{Unread: n})
+	})
+}
+
+// ListMessages — GET /threads/{id}/messages?limit=50&cursor=...
+func (h *MessagesHandler) ListMessages() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t, _, ok := h.thread(w, r)
+		if !ok {
+			return
+		}
+		limit := 50
+		if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 200 {
+			limit = v
+		}
+		var before *pagination.Cursor
+		if tok := r.URL.Query().Get("cursor"); tok != "" {
+			c, err := h.cursors.DecodeScope(tok, "thread:"+t.ID.String())
+			if err != nil {
+				shared.BadRequest(w, r, "invalid cursor")
+				return
+			}
+			before = &c
+		}
+
+		msgs, err := h.repos.Threads().ListMessages(r.Context(), t.ID, before, limit+1)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		resp := messagesResp{Thread: t, Messages: msgs}
+		if len(msgs) > limit {
+			resp.Messages = msgs[1:]
+			first := resp.Messages[0]
+			resp.NextCursor = h.cursors.Encode(pagination.Cursor{Scope: "thread:" + t.ID.String(), CreatedAt: first.CreatedAt, ID: first.ID})
+		}
+		if resp.Messages == nil {
+			resp.Messages = []models.DirectMessage{}
+		}
+		shared.WriteJSON(w, http.StatusOK, resp)
+	})
+}
+
+// Send — POST /threads/{id}/messages {text}
+func (h *MessagesHandler) Send() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t, uid, ok := h.thread(w, r)
+		if !ok {
+			return
+		}
+		var req sendReq
+		if !shared.DecodeJSON(w, r, &req) {
+			return
+		}
+		text := strings.TrimSpace(req.Text)
+		if !h.allowed(w, r, uid, counterpart(t, uid)) {
+			return
+		}
+
+		m, err := h.repos.Threads().AppendMessage(r.Context(), t.ID, uid, text)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		h.publishMessage(r, t, m)
+		shared.WriteJSON(w, http.StatusCreated, m)
+	})
+}
+
+// MarkRead — POST /threads/{id}/read — read receipt для сообщений собеседника.
+func (h *MessagesHandler) MarkRead() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t, uid, ok := h.thread(w, r)
+		if !ok {
+			return
+		}
+		n, err := h.repos.Threads().MarkRead(r.Context(), t.ID, uid)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		if n > 0 && h.events != nil {
+			h.events.Publish(r.Context(), counterpart(t, uid), realtime.Event{
+				Type: realtime.EventDirectRead,
+				Data: map[string]any{"thread_id": t.ID, "reader_id": uid, "count": n},
+			})
+		}
+		shared.WriteJSON(w, http.StatusOK, markedResp{Marked: n})
+	})
+}
+
+// SuggestReply — POST /threads/{id}/suggest-reply — черновик ответа продавцу от LLM.
+// Ничего не отправляет: продавец сам решает, использовать ли подсказку.
+func (h *MessagesHandler) SuggestReply() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t, uid, ok := h.thread(w, r)
+		if !ok {
+			return
+		}
+		if t.SellerID != uid {
+			shared.Forbidden(w, r, "only the seller can request reply suggestions")
+			return
+		}
+		if h.ai == nil {
+			shared.Error(w, r, apperror.NotImplemented("AI is not configured"))
+			return
+		}
+
+		ctx := r.Context()
+		l, err := h.repos.Listings().GetByID(ctx, t.ListingID)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		history, err := h.repos.Threads().ListMessages(ctx, t.ID, nil, 10)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+
+		msgs := []ai.Message{{
+			Role: "system",
+			Content: fmt.Sprintf("Ты помогаешь продавцу на маркетплейсе вежливо и кратко ответить покупателю. "+
+				"Объявление: %q, цена %.0f %s, состояние: %s. Описание: %s. "+
+				"Не обещай того, чего нет в описании. Ответь одним сообщением от лица продавца.",
+				l.Title, l.PriceAmount, l.CurrencyCode, l.Condition, l.Description),
+		}}
+		for _, m := range history {
+			role := "user" // покупатель
+			if m.SenderID == t.SellerID {
+				role = "assistant" // продавец
+			}
+			msgs = append(msgs, ai.Message{Role: role, Content: m.Body})
+		}
+
+		suggestion, err := h.ai.Chat(ctx, h.cfg.AIModel, h.cfg.AITemperature, msgs)
+		if err != nil {
+			// сбой LLM — 502, как в чате: это недоступность внешнего сервиса, а не ошибка сервера
+			shared.Error(w, r, apperror.Upstream("assistant is unavailable", err))
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, suggestionResp{Suggestion: strings.TrimSpace(suggestion)})
+	})
+}
+
+// Block — PUT /users/{id}/block
+func (h *MessagesHandler) Block() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		other, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil || other == uid {
+			shared.BadRequest(w, r, "invalid user id")
+			return
+		}
+		if err := h.repos.Blocks().Block(r.Context(), uid, other); err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// Unblock — DELETE /users/{id}/block
+func (h *MessagesHandler) Unblock() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		other, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			shared.BadRequest(w, r, "invalid user id")
+			return
+		}
+		if err := h.repos.Blocks().Unblock(r.Context(), uid, other); err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// thread — загружает переписку из {id} и проверяет, что текущий пользователь — её участник.
+// Чужая переписка отдаётся как 404, чтобы не раскрывать её существование.
+func (h *MessagesHandler) thread(w http.ResponseWriter, r *http.Request) (models.Thread, uuid.UUID, bool) {
+	uid, _ := middleware.UserIDFromContext(r.Context())
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		shared.BadRequest(w, r, "invalid thread id")
+		return models.Thread{}, uid, false
+	}
+	t, err := h.repos.Threads().Get(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && t.BuyerID != uid && t.SellerID != uid) {
+		shared.NotFound(w, r, "thread not found")
+		return models.Thread{}, uid, false
+	}
+	if err != nil {
+		shared.InternalError(w, r, err)
+		return models.Thread{}, uid, false
+	}
+	return t, uid, true
+}
+
+// allowed — запрещает переписку, если один из участников заблокировал другого.
+func (h *MessagesHandler) allowed(w http.ResponseWriter, r *http.Request, a, b uuid.UUID) bool {
+	blocked, err := h.repos.Blocks().IsBlocked(r.Context(), a, b)
+	if err != nil {
+		shared.InternalError(w, r, err)
+		return false
+	}
+	if blocked {
+		shared.Forbidden(w, r, "messaging is blocked between these users")
+		return false
+	}
+	return true
+}
+
+// publishMessage — новое сообщение уходит обоим участникам (у отправителя могут быть другие вкладки).
+func (h *MessagesHandler) publishMessage(r *http.Request, t models.Thread, m models.DirectMessage) {
+	if h.events == nil {
+		return
+	}
+	ev := realtime.Event{Type: realtime.EventDirectMessage, Data: m}
+	h.events.Publish(r.Context(), t.BuyerID, ev)
+	h.events.Publish(r.Context(), t.SellerID, ev)
+}
+
+// counterpart — второй участник переписки.
+func counterpart(t models.Thread, userID uuid.UUID) uuid.UUID {
+	if t.BuyerID == userID {
+		return t.SellerID
+	}
+	return t.BuyerID
+}
@@ -0,0 +1,9 @@
+package notifications
+
+import "github.com/btynybekov/marketplace/internal/openapi"
+
+// Docs — описание операций NotificationsHandler для /openapi.json (ключ — имя метода).
+var Docs = openapi.Operations{
+	"List": {Summary: "List undelivered notifications", Response: notificationsResp{}},
+	"Ack":  {Summary: "Mark notifications as delivered", Request: ackReq{}},
+}
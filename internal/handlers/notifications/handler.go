@@ -0,0 +1,61 @@
+package notifications
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+type ackReq struct {
+	IDs []uuid.UUID `json:"ids" validate:"required,max=500"`
+}
+
+type notificationsResp struct {
+	Notifications []models.Notification `json:"notifications"`
+}
+
+// NotificationsHandler — outbox уведомлений пользователя (маршруты под RequireUser).
+type NotificationsHandler struct {
+	repos repository.RepositorySet
+}
+
+func NewNotificationsHandler(repos repository.RepositorySet) *NotificationsHandler {
+	return &NotificationsHandler{repos: repos}
+}
+
+// List — GET /notifications — недоставленные уведомления.
+func (h *NotificationsHandler) List() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		list, err := h.repos.Notifications().ListPending(r.Context(), uid, 100)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		if list == nil {
+			list = []models.Notification{}
+		}
+		shared.WriteJSON(w, http.StatusOK, notificationsResp{Notifications: list})
+	})
+}
+
+// Ack — POST /notifications/ack {ids} — отметить доставленными.
+func (h *NotificationsHandler) Ack() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		var req ackReq
+		if !shared.DecodeJSON(w, r, &req) {
+			return
+		}
+		if err := h.repos.Notifications().MarkDelivered(r.Context(), uid, req.IDs); err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
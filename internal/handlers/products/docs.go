@@ -0,0 +1,15 @@
+package products
+
+import (
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/openapi"
+)
+
+// Docs — описание операций пакета для /openapi.json (ключ — имя хендлера).
+var Docs = openapi.Operations{
+	"ProductHandler": {
+		Summary:     "Get a catalog product",
+		Description: "Specs, media and price statistics of active listings.",
+		Response:    models.ProductDetails{},
+	},
+}
@@ -0,0 +1,57 @@
+package products
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// ProductHandler — страница модели каталога: характеристики, медиа, сводка по предложениям.
+type ProductHandler struct {
+	repos repository.RepositorySet
+}
+
+func NewProductHandler(repos repository.RepositorySet) *ProductHandler {
+	return &ProductHandler{repos: repos}
+}
+
+// GET /products/{id}
+func (h *ProductHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		shared.BadRequest(w, r, "invalid product id")
+		return
+	}
+
+	ctx := r.Context()
+	d, err := h.repos.Catalog().GetProduct(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		shared.NotFound(w, r, "product not found")
+		return
+	}
+	if err != nil {
+		shared.InternalError(w, r, err)
+		return
+	}
+
+	if d.Media, err = h.repos.ProductMedia().ListByProductIDs(ctx, []uuid.UUID{id}); err != nil {
+		shared.InternalError(w, r, err)
+		return
+	}
+	if d.Offers, err = h.repos.Catalog().OfferStats(ctx, id); err != nil {
+		shared.InternalError(w, r, err)
+		return
+	}
+	if d.Media == nil {
+		d.Media = []models.ProductMedia{}
+	}
+
+	shared.WriteJSON(w, http.StatusOK, d)
+}
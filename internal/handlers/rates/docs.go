@@ -0,0 +1,8 @@
+package rates
+
+import "github.com/btynybekov/marketplace/internal/openapi"
+
+// Docs — описание операций пакета для /openapi.json (ключ — имя хендлера).
+var Docs = openapi.Operations{
+	"RatesHandler": {Summary: "Current exchange rates to KGS", Response: ratesResp{}},
+}
@@ -0,0 +1,33 @@
+package rates
+
+import (
+	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+type ratesResp struct {
+	Base  string                `json:"base"`
+	Rates []models.ExchangeRate `json:"rates"`
+}
+
+// RatesHandler — текущие курсы валют к KGS.
+type RatesHandler struct {
+	repos repository.RepositorySet
+}
+
+func NewRatesHandler(repos repository.RepositorySet) *RatesHandler {
+	return &RatesHandler{repos: repos}
+}
+
+// GET /rates
+func (h *RatesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	list, err := h.repos.ExchangeRates().List(r.Context())
+	if err != nil {
+		shared.InternalError(w, r, err)
+		return
+	}
+	shared.WriteJSON(w, http.StatusOK, ratesResp{Base: "KGS", Rates: list})
+}
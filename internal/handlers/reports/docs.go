@@ -0,0 +1,19 @@
+package reports
+
+import (
+	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/openapi"
+)
+
+// Docs — описание операций пакета для /openapi.json (ключ — имя хендлера).
+var Docs = openapi.Operations{
+	"ReportsHandler": {
+		Summary:     "Report a user, listing, thread, message or review",
+		Description: "404 if there is no target_type object with id target_id.",
+		Request:     createReportReq{},
+		Response:    models.AbuseReport{},
+		Status:      http.StatusCreated,
+	},
+}
@@ -0,0 +1,58 @@
+package reports
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// createReportReq — target_type совпадает с CHECK в abuse_report.
+type createReportReq struct {
+	TargetType string    `json:"target_type" validate:"required,enum=user|listing|thread|message|review"`
+	TargetID   uuid.UUID `json:"target_id" validate:"required"`
+	Reason     string    `json:"reason" validate:"required,max=2000"`
+}
+
+// ReportsHandler — жалобы пользователей для модерации (маршруты под RequireUser).
+type ReportsHandler struct {
+	repos repository.RepositorySet
+}
+
+func NewReportsHandler(repos repository.RepositorySet) *ReportsHandler {
+	return &ReportsHandler{repos: repos}
+}
+
+// ServeHTTP — POST /reports {target_type, target_id, reason}; 404, если объекта жалобы нет.
+func (h *ReportsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	uid, _ := middleware.UserIDFromContext(r.Context())
+
+	var req createReportReq
+	if !shared.DecodeJSON(w, r, &req) {
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	rep, err := h.repos.Reports().Create(r.Context(), models.AbuseReport{
+		ReporterID: uid,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Reason:     req.Reason,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		shared.NotFound(w, r, "report target not found")
+		return
+	}
+	if err != nil {
+		shared.InternalError(w, r, err)
+		return
+	}
+	shared.WriteJSON(w, http.StatusCreated, rep)
+}
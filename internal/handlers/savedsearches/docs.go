@@ -0,0 +1,21 @@
+package savedsearches
+
+import (
+	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/openapi"
+)
+
+// Docs — описание операций SavedSearchHandler для /openapi.json (ключ — имя метода).
+var Docs = openapi.Operations{
+	"List": {Summary: "List saved searches", Response: savedSearchesResp{}},
+	"Create": {
+		Summary:     "Save a search",
+		Description: "New matching listings are delivered as notifications. Requirements must include category_slug, brand or query.",
+		Request:     createSavedSearchReq{},
+		Response:    models.SavedSearch{},
+		Status:      http.StatusCreated,
+	},
+	"Delete": {Summary: "Delete a saved search"},
+}
@@ -0,0 +1,106 @@
+package savedsearches
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+type createSavedSearchReq struct {
+	Name         string                    `json:"name" validate:"required,max=100"`
+	Requirements models.SearchRequirements `json:"requirements"`
+}
+
+type savedSearchesResp struct {
+	SavedSearches []models.SavedSearch `json:"saved_searches"`
+}
+
+func (req createSavedSearchReq) Validate() []apperror.FieldError {
+	rq := req.Requirements
+	if rq.CategorySlug == "" && rq.Brand == "" && rq.Query == "" {
+		return []apperror.FieldError{{Field: "requirements", Rule: "required_one", Message: "must include category_slug, brand or query"}}
+	}
+	return nil
+}
+
+// SavedSearchHandler — сохранённые поиски пользователя (маршруты под RequireUser).
+// Новые совпадения находит alerts.Matcher и кладёт в outbox уведомлений.
+type SavedSearchHandler struct {
+	repos repository.RepositorySet
+}
+
+func NewSavedSearchHandler(repos repository.RepositorySet) *SavedSearchHandler {
+	return &SavedSearchHandler{repos: repos}
+}
+
+// List — GET /saved-searches
+func (h *SavedSearchHandler) List() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		list, err := h.repos.SavedSearches().ListByUser(r.Context(), uid)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		if list == nil {
+			list = []models.SavedSearch{}
+		}
+		shared.WriteJSON(w, http.StatusOK, savedSearchesResp{SavedSearches: list})
+	})
+}
+
+// Create — POST /saved-searches {name, requirements}
+func (h *SavedSearchHandler) Create() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		var req createSavedSearchReq
+		if !shared.DecodeJSON(w, r, &req) {
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		rq := req.Requirements
+
+		ss, err := h.repos.SavedSearches().Create(r.Context(), models.SavedSearch{
+			UserID:       uid,
+			Name:         req.Name,
+			Requirements: rq,
+		})
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusCreated, ss)
+	})
+}
+
+// Delete — DELETE /saved-searches/{id}
+func (h *SavedSearchHandler) Delete() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			shared.BadRequest(w, r, "invalid id")
+			return
+		}
+		err = h.repos.SavedSearches().Delete(r.Context(), uid, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			shared.NotFound(w, r, "saved search not found")
+			return
+		}
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,104 @@
+package shared
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/httpheader"
+)
+
+const (
+	MediaJSON = "application/json"
+	MediaHTML = "text/html"
+)
+
+// mediaRange — элемент заголовка Accept: type/subtype (возможно с *) и его q.
+type mediaRange struct {
+	typ, sub string
+	q        float64
+}
+
+func parseAccept(header string) []mediaRange {
+	var out []mediaRange
+	for _, it := range httpheader.ParseList(header) {
+		typ, sub, ok := strings.Cut(it.Value, "/")
+		if !ok || typ == "" || sub == "" {
+			continue
+		}
+		out = append(out, mediaRange{typ: typ, sub: sub, q: it.Q})
+	}
+	return out
+}
+
+// quality — q наиболее точного диапазона, подходящего под offer
+// (type/subtype важнее type/*, тот — важнее */*); -1, если ни один не подходит.
+func quality(ranges []mediaRange, offer string) float64 {
+	typ, sub, _ := strings.Cut(offer, "/")
+	q, best := -1.0, -1
+	for _, mr := range ranges {
+		spec := -1
+		switch {
+		case mr.typ == typ && mr.sub == sub:
+			spec = 2
+		case mr.typ == typ && mr.sub == "*":
+			spec = 1
+		case mr.typ == "*" && mr.sub == "*":
+			spec = 0
+		}
+		if spec > best {
+			q, best = mr.q, spec
+		}
+	}
+	return q
+}
+
+// Negotiate — выбирает из offers (в порядке предпочтения сервера) тип ответа по Accept
+// с учётом q-значений. Без Accept — первый из offers; "" — клиенту не подходит ни один.
+func Negotiate(r *http.Request, offers ...string) string {
+	header := r.Header.Get("Accept")
+	if strings.TrimSpace(header) == "" {
+		if len(offers) == 0 {
+			return ""
+		}
+		return offers[0]
+	}
+	ranges := parseAccept(header)
+	best, bestQ := "", 0.0
+	for _, o := range offers {
+		if q := quality(ranges, o); q > bestQ {
+			best, bestQ = o, q
+		}
+	}
+	return best
+}
+
+// Templates — HTML-страницы для Render (реализация — web.Templates).
+type Templates interface {
+	Has(name string) bool
+	Execute(w io.Writer, name string, data any) error
+}
+
+// Render — страница веб-роутера: text/html — шаблон name с page, application/json — data.
+// Без шаблона страница отдаётся только в JSON.
+func Render(w http.ResponseWriter, r *http.Request, tmpl Templates, name string, page, data any) {
+	offers := []string{MediaJSON}
+	if tmpl != nil && tmpl.Has(name) {
+		offers = []string{MediaHTML, MediaJSON}
+	}
+	w.Header().Add("Vary", "Accept")
+
+	switch Negotiate(r, offers...) {
+	case MediaHTML:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := tmpl.Execute(w, name, page); err != nil {
+			Error(w, r, apperror.Internal(fmt.Errorf("template render: %w", err)))
+		}
+	case MediaJSON:
+		WriteJSON(w, http.StatusOK, data)
+	default:
+		Error(w, r, apperror.NotAcceptable(offers...))
+	}
+}
@@ -2,11 +2,26 @@ package shared
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/i18n"
+	"github.com/btynybekov/marketplace/internal/logging"
 )
 
+// ErrorResp — тело ответа с ошибкой: сообщение на языке клиента и код apperror.
 type ErrorResp struct {
-	Error string `json:"error"`
+	Error  string           `json:"error"`
+	Code   string           `json:"code,omitempty"`
+	Fields []FieldErrorResp `json:"fields,omitempty"`
+}
+
+// FieldErrorResp — ошибка отдельного поля (для code=validation_failed).
+type FieldErrorResp struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
 }
 
 func WriteJSON(w http.ResponseWriter, status int, v any) {
@@ -15,14 +30,49 @@ func WriteJSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-func BadRequest(w http.ResponseWriter, msg string) {
-	WriteJSON(w, http.StatusBadRequest, ErrorResp{Error: msg})
+// Error — единая точка ответа ошибкой: err приводится к *apperror.Error,
+// сообщение переводится по Accept-Language. Причина 5xx пишется в лог и клиенту не отдаётся.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	e := apperror.From(err)
+	if e.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed", slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.String("code", string(e.Code)), logging.Err(e))
+	}
+	lang := i18n.FromRequest(r)
+	w.Header().Set("Content-Language", lang)
+	WriteJSON(w, e.Status, NewErrorResp(lang, e))
+}
+
+// NewErrorResp — тело ошибки на языке lang (HTTP-ответы и error-кадры WebSocket).
+func NewErrorResp(lang string, e *apperror.Error) ErrorResp {
+	resp := ErrorResp{Error: i18n.T(lang, e.Message, e.Args...), Code: string(e.Code)}
+	for _, f := range e.Fields {
+		resp.Fields = append(resp.Fields, FieldErrorResp{Field: f.Field, Rule: f.Rule, Message: i18n.T(lang, f.Message, f.Args...)})
+	}
+	return resp
+}
+
+func BadRequest(w http.ResponseWriter, r *http.Request, msg string, args ...any) {
+	Error(w, r, apperror.BadRequest(msg, args...))
+}
+
+func Unauthorized(w http.ResponseWriter, r *http.Request, msg string, args ...any) {
+	Error(w, r, apperror.Unauthorized(msg, args...))
+}
+
+func Forbidden(w http.ResponseWriter, r *http.Request, msg string, args ...any) {
+	Error(w, r, apperror.Forbidden(msg, args...))
+}
+
+func NotFound(w http.ResponseWriter, r *http.Request, msg string, args ...any) {
+	Error(w, r, apperror.NotFound(msg, args...))
 }
 
-func InternalError(w http.ResponseWriter, err error) {
-	WriteJSON(w, http.StatusInternalServerError, ErrorResp{Error: err.Error()})
+func Conflict(w http.ResponseWriter, r *http.Request, msg string, args ...any) {
+	Error(w, r, apperror.Conflict(msg, args...))
 }
 
-func NotFound(w http.ResponseWriter, msg string) {
-	WriteJSON(w, http.StatusNotFound, ErrorResp{Error: msg})
+// InternalError — ошибка хранилища/сервиса: ErrNoRows и нарушения ограничений
+// превращаются в 404/409, остальное — 500 без текста причины.
+func InternalError(w http.ResponseWriter, r *http.Request, err error) {
+	Error(w, r, err)
 }
@@ -0,0 +1,287 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+)
+
+// MaxBodyBytes — лимит тела JSON-запроса по умолчанию.
+const MaxBodyBytes = 1 << 20
+
+// Validator — DTO с проверками, которые не выражаются тегами (зависимости между полями).
+type Validator interface {
+	Validate() []apperror.FieldError
+}
+
+// DecodeJSON — читает JSON-тело в dst (не больше MaxBodyBytes, неизвестные поля запрещены)
+// и проверяет его правилами из тегов `validate` (см. Validate).
+// При ошибке сам отвечает клиенту и возвращает false.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	return DecodeJSONLimit(w, r, dst, MaxBodyBytes)
+}
+
+// DecodeJSONLimit — DecodeJSON с собственным лимитом тела в байтах.
+func DecodeJSONLimit(w http.ResponseWriter, r *http.Request, dst any, limit int64) bool {
+	if err := decodeJSON(w, r, dst, limit); err != nil {
+		Error(w, r, err)
+		return false
+	}
+	if err := Validate(dst); err != nil {
+		Error(w, r, err)
+		return false
+	}
+	return true
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any, limit int64) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return apperror.BadRequest("request body is required")
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err == nil {
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			return apperror.BadRequest("request body must contain a single JSON object")
+		}
+		return nil
+	}
+
+	var (
+		tooBig  *http.MaxBytesError
+		typeErr *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &tooBig):
+		return apperror.TooLarge("request body must not exceed %d bytes", limit)
+	case errors.Is(err, io.EOF):
+		return apperror.BadRequest("request body is required")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return apperror.Validation(apperror.FieldError{
+			Field: typeErr.Field, Rule: "type", Message: "must be of type %s", Args: []any{jsonType(typeErr.Type)},
+		})
+	}
+	// encoding/json не экспортирует тип ошибки для DisallowUnknownFields
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return apperror.Validation(apperror.FieldError{
+			Field: strings.Trim(field, `"`), Rule: "unknown", Message: "unknown field",
+		})
+	}
+	return apperror.BadRequest("invalid JSON").Wrap(err)
+}
+
+// Validate — проверяет структуру по тегам `validate:"rule,rule=param"`:
+//
+//	required      — поле задано (строка — не из одних пробелов)
+//	min=N, max=N  — длина строки в символах, число элементов или значение числа
+//	len=N         — точная длина строки в символах
+//	gt=N          — число строго больше N
+//	enum=a|b|c    — одно из значений
+//	uuid          — строка в формате UUID
+//	url           — абсолютный http(s) URL
+//	omitempty     — пустое значение допустимо (очистка поля), остальные правила к нему не применяются
+//
+// Правила, кроме required, не применяются к отсутствующим полям: nil-указателю и пустому
+// значению поля без указателя. Переданный указатель проверяется всегда — PATCH с
+// {"currency_code": ""} должен получить ошибку поля, а не записать пустое значение.
+// Вложенные структуры проверяются рекурсивно; после тегов вызывается Validator, если DTO его реализует.
+func Validate(v any) error {
+	var fields []apperror.FieldError
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		validateStruct(rv, "", &fields)
+	}
+	if val, ok := v.(Validator); ok {
+		fields = append(fields, val.Validate()...)
+	}
+	if len(fields) > 0 {
+		return apperror.Validation(fields...)
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func validateStruct(rv reflect.Value, prefix string, out *[]apperror.FieldError) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		path := prefix + name
+
+		fv := rv.Field(i)
+		set := false // указатель передан: правила применяются и к пустому значению
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				if hasRule(sf.Tag.Get("validate"), "required") {
+					*out = append(*out, apperror.FieldError{Field: path, Rule: "required", Message: "is required"})
+				}
+				continue
+			}
+			fv, set = fv.Elem(), true
+		}
+		if fe, ok := checkRules(path, fv, sf.Tag.Get("validate"), set); !ok {
+			*out = append(*out, fe)
+			continue
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+			validateStruct(fv, path+".", out)
+		}
+	}
+}
+
+func hasRule(tag, rule string) bool {
+	for _, r := range strings.Split(tag, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRules — первое нарушенное правило поля. set — значение пришло через указатель:
+// пустое проверяется наравне с непустым (кроме omitempty).
+func checkRules(path string, fv reflect.Value, tag string, set bool) (apperror.FieldError, bool) {
+	if tag == "" {
+		return apperror.FieldError{}, true
+	}
+	empty := isEmpty(fv)
+	skipEmpty := empty && (!set || hasRule(tag, "omitempty"))
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		fail := func(msg string, args ...any) (apperror.FieldError, bool) {
+			return apperror.FieldError{Field: path, Rule: name, Message: msg, Args: args}, false
+		}
+		if name == "required" {
+			if empty {
+				return fail("is required")
+			}
+			continue
+		}
+		if skipEmpty {
+			continue
+		}
+		switch name {
+		case "min", "max", "len", "gt":
+			n, _ := strconv.ParseFloat(param, 64)
+			switch fv.Kind() {
+			case reflect.String:
+				l := float64(utf8.RuneCountInString(fv.String()))
+				switch {
+				case name == "min" && l < n:
+					return fail("must be at least %s characters", param)
+				case name == "max" && l > n:
+					return fail("must be at most %s characters", param)
+				case name == "len" && l != n:
+					return fail("must be exactly %s characters", param)
+				}
+			case reflect.Slice, reflect.Map:
+				l := float64(fv.Len())
+				switch {
+				case name == "min" && l < n:
+					return fail("must contain at least %s items", param)
+				case name == "max" && l > n:
+					return fail("must contain at most %s items", param)
+				}
+			default:
+				x, ok := number(fv)
+				if !ok {
+					continue
+				}
+				switch {
+				case name == "min" && x < n:
+					return fail("must be at least %s", param)
+				case name == "max" && x > n:
+					return fail("must be at most %s", param)
+				case name == "gt" && x <= n:
+					return fail("must be greater than %s", param)
+				}
+			}
+		case "enum":
+			if fv.Kind() == reflect.String && !hasRule(strings.ReplaceAll(param, "|", ","), fv.String()) {
+				return fail("must be one of: %s", strings.ReplaceAll(param, "|", ", "))
+			}
+		case "uuid":
+			if fv.Kind() == reflect.String {
+				if _, err := uuid.Parse(fv.String()); err != nil {
+					return fail("must be a valid UUID")
+				}
+			}
+		case "url":
+			if fv.Kind() == reflect.String {
+				u, err := url.Parse(fv.String())
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fail("must be an absolute http(s) URL")
+				}
+			}
+		}
+	}
+	return apperror.FieldError{}, true
+}
+
+func isEmpty(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(fv.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return fv.Len() == 0
+	default:
+		return fv.IsZero()
+	}
+}
+
+func number(fv reflect.Value) (float64, bool) {
+	switch {
+	case fv.CanInt():
+		return float64(fv.Int()), true
+	case fv.CanUint():
+		return float64(fv.Uint()), true
+	case fv.CanFloat():
+		return fv.Float(), true
+	}
+	return 0, false
+}
+
+// jsonType — тип поля в терминах JSON (для сообщения об ошибке типа).
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return t.String()
+}
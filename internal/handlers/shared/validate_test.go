@@ -0,0 +1,123 @@
+package shared
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+)
+
+type validateDTO struct {
+	Name     string         `json:"name" validate:"required,min=2,max=5"`
+	Code     *string        `json:"code,omitempty" validate:"len=3"`
+	Kind     *string        `json:"kind,omitempty" validate:"enum=new|used"`
+	Price    *float64       `json:"price,omitempty" validate:"gt=0"`
+	Count    int            `json:"count" validate:"min=1,max=10"`
+	Tags     []string       `json:"tags" validate:"max=2"`
+	Attrs    map[string]any `json:"attrs" validate:"min=1"`
+	ID       string         `json:"id" validate:"uuid"`
+	Link     string         `json:"link" validate:"url"`
+	Avatar   *string        `json:"avatar,omitempty" validate:"omitempty,url"`
+	Owner    *string        `json:"owner,omitempty" validate:"required"`
+	Nested   validateNested `json:"nested"`
+	Internal string         `json:"-" validate:"required"`
+}
+
+type validateNested struct {
+	Title string `json:"title" validate:"max=3"`
+}
+
+// validDTO — проходит все правила; кейсы меняют одно поле.
+func validDTO() validateDTO {
+	return validateDTO{Name: "abc", Count: 1, Attrs: map[string]any{"a": 1}, Owner: ptr("me")}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		edit  func(*validateDTO)
+		field string // "" — ошибок нет
+		rule  string
+	}{
+		{"valid", func(*validateDTO) {}, "", ""},
+		{"required: empty", func(d *validateDTO) { d.Name = "" }, "name", "required"},
+		{"required: spaces", func(d *validateDTO) { d.Name = "   " }, "name", "required"},
+		{"required: nil pointer", func(d *validateDTO) { d.Owner = nil }, "owner", "required"},
+		{"required: empty pointer", func(d *validateDTO) { d.Owner = ptr("") }, "owner", "required"},
+		{"min: string", func(d *validateDTO) { d.Name = "a" }, "name", "min"},
+		{"max: string in runes", func(d *validateDTO) { d.Name = "айфон" }, "", ""},
+		{"max: string", func(d *validateDTO) { d.Name = "abcdef" }, "name", "max"},
+		{"min: number", func(d *validateDTO) { d.Count = -1 }, "count", "min"},
+		{"zero number is absent", func(d *validateDTO) { d.Count = 0 }, "", ""},
+		{"max: number", func(d *validateDTO) { d.Count = 11 }, "count", "max"},
+		{"max: slice", func(d *validateDTO) { d.Tags = []string{"a", "b", "c"} }, "tags", "max"},
+		{"empty map is absent", func(d *validateDTO) { d.Attrs = nil }, "", ""},
+		{"len: ok", func(d *validateDTO) { d.Code = ptr("KGS") }, "", ""},
+		{"len: short", func(d *validateDTO) { d.Code = ptr("KG") }, "code", "len"},
+		{"len: empty pointer", func(d *validateDTO) { d.Code = ptr("") }, "code", "len"},
+		{"len: nil pointer", func(d *validateDTO) { d.Code = nil }, "", ""},
+		{"gt: ok", func(d *validateDTO) { d.Price = ptr(0.5) }, "", ""},
+		{"gt: zero pointer", func(d *validateDTO) { d.Price = ptr(0.0) }, "price", "gt"},
+		{"enum: ok", func(d *validateDTO) { d.Kind = ptr("used") }, "", ""},
+		{"enum: unknown", func(d *validateDTO) { d.Kind = ptr("broken") }, "kind", "enum"},
+		{"enum: empty pointer", func(d *validateDTO) { d.Kind = ptr("") }, "kind", "enum"},
+		{"uuid: ok", func(d *validateDTO) { d.ID = "00000000-0000-4000-8000-000000000001" }, "", ""},
+		{"uuid: bad", func(d *validateDTO) { d.ID = "42" }, "id", "uuid"},
+		{"url: ok", func(d *validateDTO) { d.Link = "https://example.com/a" }, "", ""},
+		{"url: relative", func(d *validateDTO) { d.Link = "/a" }, "link", "url"},
+		{"url: other scheme", func(d *validateDTO) { d.Link = "ftp://example.com" }, "link", "url"},
+		{"omitempty: empty pointer clears", func(d *validateDTO) { d.Avatar = ptr("") }, "", ""},
+		{"omitempty: value still checked", func(d *validateDTO) { d.Avatar = ptr("avatar.png") }, "avatar", "url"},
+		{"nested", func(d *validateDTO) { d.Nested.Title = "long" }, "nested.title", "max"},
+		{"json:- skipped", func(d *validateDTO) { d.Internal = "" }, "", ""},
+	} {
+		d := validDTO()
+		tc.edit(&d)
+		err := Validate(&d)
+		if tc.field == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tc.name, err)
+			}
+			continue
+		}
+		var ae *apperror.Error
+		if !errors.As(err, &ae) || len(ae.Fields) != 1 {
+			t.Errorf("%s: error %v, want one field error", tc.name, err)
+			continue
+		}
+		if fe := ae.Fields[0]; fe.Field != tc.field || fe.Rule != tc.rule {
+			t.Errorf("%s: %s/%s, want %s/%s", tc.name, fe.Field, fe.Rule, tc.field, tc.rule)
+		}
+	}
+}
+
+// validatorDTO — проверки между полями дописываются к ошибкам тегов.
+type validatorDTO struct {
+	Min int `json:"min" validate:"min=0"`
+	Max int `json:"max"`
+}
+
+func (d validatorDTO) Validate() []apperror.FieldError {
+	if d.Max < d.Min {
+		return []apperror.FieldError{{Field: "max", Rule: "gte_min", Message: "must not be less than min"}}
+	}
+	return nil
+}
+
+func TestValidateCallsValidator(t *testing.T) {
+	var ae *apperror.Error
+	err := Validate(validatorDTO{Min: -1, Max: -2})
+	if !errors.As(err, &ae) || len(ae.Fields) != 2 {
+		t.Fatalf("error %v, want tag and Validator field errors", err)
+	}
+	var got []string
+	for _, fe := range ae.Fields {
+		got = append(got, fe.Field+"/"+fe.Rule)
+	}
+	if s := strings.Join(got, " "); s != "min/min max/gte_min" {
+		t.Errorf("fields = %s", s)
+	}
+}
@@ -0,0 +1,32 @@
+package user
+
+import (
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/openapi"
+)
+
+var pageQuery = []openapi.Param{
+	{Name: "limit", Description: "Page size", Type: "integer"},
+	{Name: "cursor", Description: "next_cursor of the previous page"},
+}
+
+// Docs — описание операций UserHandler и ReviewsHandler для /openapi.json (ключ — имя метода).
+var Docs = openapi.Operations{
+	"Me":       {Summary: "Get own profile", Response: models.UserProfile{}},
+	"UpdateMe": {Summary: "Update own profile", Description: "Only passed fields change.", Request: updateProfileReq{}, Response: models.UserProfile{}},
+	"Profile": {
+		Summary:     "Get a public profile with rating",
+		Description: "Phone is shown only if the user allows it.",
+		Response:    publicProfileResp{},
+	},
+	"Listings": {Summary: "List active listings of a seller", Query: pageQuery, Response: listingsResp{}},
+
+	"Put": {
+		Summary:     "Create or update own review of a sold listing",
+		Description: "Only the buyer named by the seller when marking the listing sold (buyer_id) can review it.",
+		Request:     reviewReq{},
+		Response:    models.Review{},
+	},
+	"Delete": {Summary: "Delete own review of a listing"},
+	"List":   {Summary: "List reviews of a seller", Query: pageQuery, Response: reviewsResp{}},
+}
@@ -0,0 +1,194 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// updateProfileReq — PATCH /users/me: меняются только переданные поля.
+type updateProfileReq struct {
+	DisplayName  *string              `json:"display_name,omitempty" validate:"max=100"`
+	AvatarURL    *string              `json:"avatar_url,omitempty" validate:"omitempty,url"`
+	LocationText *string              `json:"location_text,omitempty" validate:"max=200"`
+	Contact      *models.ContactPrefs `json:"contact,omitempty"`
+}
+
+type publicProfileResp struct {
+	Profile models.UserProfile `json:"profile"`
+	Rating  models.RatingStats `json:"rating"`
+}
+
+type listingsResp struct {
+	Items      []models.Product `json:"items"`
+	Count      int              `json:"count"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+// UserHandler — профили пользователей и публичная страница продавца.
+type UserHandler struct {
+	repos   repository.RepositorySet
+	cursors *pagination.Codec
+}
+
+func NewUserHandler(repos repository.RepositorySet, cursors *pagination.Codec) *UserHandler {
+	return &UserHandler{repos: repos, cursors: cursors}
+}
+
+// Me — GET /users/me: свой профиль (с телефоном).
+func (h *UserHandler) Me() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		p, err := h.own(r, uid)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, p)
+	})
+}
+
+// UpdateMe — PATCH /users/me {display_name?, avatar_url?, location_text?, contact?}
+func (h *UserHandler) UpdateMe() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		var req updateProfileReq
+		if !shared.DecodeJSON(w, r, &req) {
+			return
+		}
+		p, err := h.own(r, uid)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+
+		if req.DisplayName != nil {
+			p.DisplayName = strings.TrimSpace(*req.DisplayName)
+		}
+		if req.AvatarURL != nil {
+			p.AvatarURL = strings.TrimSpace(*req.AvatarURL)
+		}
+		if req.LocationText != nil {
+			p.LocationText = strings.TrimSpace(*req.LocationText)
+		}
+		if req.Contact != nil {
+			p.Contact = *req.Contact
+		}
+
+		saved, err := h.repos.Users().SaveProfile(r.Context(), p)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, saved)
+	})
+}
+
+// Profile — GET /users/{id}: публичный профиль и рейтинг продавца.
+func (h *UserHandler) Profile() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := userID(w, r)
+		if !ok {
+			return
+		}
+		p, err := h.repos.Users().GetProfile(r.Context(), id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			shared.NotFound(w, r, "user not found")
+			return
+		}
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		if !p.Contact.ShowPhone {
+			p.Phone = ""
+		}
+		stats, err := h.repos.Reviews().Stats(r.Context(), id)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, publicProfileResp{Profile: p, Rating: stats})
+	})
+}
+
+// Listings — GET /users/{id}/listings?limit=20&cursor=...: активные объявления продавца, новые первыми.
+func (h *UserHandler) Listings() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := userID(w, r)
+		if !ok {
+			return
+		}
+		limit := 20
+		if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 50 {
+			limit = v
+		}
+		scope := "seller:" + id.String() // курсор чужой выдачи (другого продавца, каталога) — 400
+		after, ok := h.cursor(w, r, scope)
+		if !ok {
+			return
+		}
+
+		items, err := h.repos.Products().List(r.Context(), repository.ProductFilter{
+			SellerID: &id,
+			Sort:     repository.SortNewest,
+			After:    after,
+			Limit:    limit + 1,
+		})
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		next := ""
+		if len(items) > limit {
+			items = items[:limit]
+			cur := repository.ProductCursor(items[limit-1], repository.SortNewest)
+			cur.Scope = scope
+			next = h.cursors.Encode(cur)
+		}
+		shared.WriteJSON(w, http.StatusOK, listingsResp{Items: items, Count: len(items), NextCursor: next})
+	})
+}
+
+// own — профиль текущего пользователя; если записи ещё нет — профиль по умолчанию.
+func (h *UserHandler) own(r *http.Request, uid uuid.UUID) (models.UserProfile, error) {
+	p, err := h.repos.Users().GetProfile(r.Context(), uid)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.UserProfile{ID: uid, Contact: models.ContactPrefs{AllowMessages: true}}, nil
+	}
+	return p, err
+}
+
+// cursor — курсор выдачи scope из ?cursor= (nil, если не передан); при ошибке отвечает 400.
+func (h *UserHandler) cursor(w http.ResponseWriter, r *http.Request, scope string) (*pagination.Cursor, bool) {
+	tok := r.URL.Query().Get("cursor")
+	if tok == "" {
+		return nil, true
+	}
+	c, err := h.cursors.DecodeScope(tok, scope)
+	if err != nil {
+		shared.BadRequest(w, r, "invalid cursor")
+		return nil, false
+	}
+	return &c, true
+}
+
+func userID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		shared.BadRequest(w, r, "invalid user id")
+		return uuid.Nil, false
+	}
+	return id, true
+}
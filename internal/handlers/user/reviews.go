@@ -0,0 +1,162 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+type reviewReq struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Body   string `json:"body,omitempty" validate:"max=2000"`
+}
+
+type reviewsResp struct {
+	Items      []models.Review    `json:"items"`
+	Rating     models.RatingStats `json:"rating"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// ReviewsHandler — отзывы покупателей о продавцах. Оставить отзыв можно только
+// по проданному объявлению и только его покупателю (buyer_id, указывается при переводе в sold).
+// Жалобы на отзывы — через POST /reports с target_type=review.
+type ReviewsHandler struct {
+	repos   repository.RepositorySet
+	cursors *pagination.Codec
+}
+
+func NewReviewsHandler(repos repository.RepositorySet, cursors *pagination.Codec) *ReviewsHandler {
+	return &ReviewsHandler{repos: repos, cursors: cursors}
+}
+
+// Put — PUT /listings/{id}/review {rating, body?}: создать или изменить свой отзыв.
+func (h *ReviewsHandler) Put() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		uid, _ := middleware.UserIDFromContext(ctx)
+
+		var req reviewReq
+		if !shared.DecodeJSON(w, r, &req) {
+			return
+		}
+		req.Body = strings.TrimSpace(req.Body)
+
+		l, ok := h.listing(w, r)
+		if !ok {
+			return
+		}
+		if l.SellerID == uid {
+			shared.BadRequest(w, r, "cannot review yourself")
+			return
+		}
+		if l.Status != "sold" {
+			shared.Conflict(w, r, "reviews are allowed only for sold listings")
+			return
+		}
+		// покупателя указывает продавец (PATCH /listings/{id}); в базе — review_listing_buyer_fkey
+		if l.BuyerID == nil || *l.BuyerID != uid {
+			shared.Forbidden(w, r, "only the buyer of the listing can leave a review")
+			return
+		}
+
+		rv, err := h.repos.Reviews().Upsert(ctx, models.Review{
+			ListingID:  l.ID,
+			ReviewerID: uid,
+			SellerID:   l.SellerID,
+			Rating:     req.Rating,
+			Body:       req.Body,
+		})
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		shared.WriteJSON(w, http.StatusOK, rv)
+	})
+}
+
+// Delete — DELETE /listings/{id}/review: удалить свой отзыв.
+func (h *ReviewsHandler) Delete() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := middleware.UserIDFromContext(r.Context())
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			shared.BadRequest(w, r, "invalid listing id")
+			return
+		}
+		if err := h.repos.Reviews().Delete(r.Context(), id, uid); err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// List — GET /users/{id}/reviews?limit=20&cursor=...: отзывы о продавце и сводка оценок.
+func (h *ReviewsHandler) List() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := userID(w, r)
+		if !ok {
+			return
+		}
+		limit := 20
+		if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 100 {
+			limit = v
+		}
+		var after *pagination.Cursor
+		if tok := r.URL.Query().Get("cursor"); tok != "" {
+			c, err := h.cursors.DecodeScope(tok, "reviews:"+id.String())
+			if err != nil {
+				shared.BadRequest(w, r, "invalid cursor")
+				return
+			}
+			after = &c
+		}
+
+		items, err := h.repos.Reviews().ListBySeller(r.Context(), id, after, limit+1)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		stats, err := h.repos.Reviews().Stats(r.Context(), id)
+		if err != nil {
+			shared.InternalError(w, r, err)
+			return
+		}
+		resp := reviewsResp{Items: items, Rating: stats}
+		if len(items) > limit {
+			resp.Items = items[:limit]
+			last := resp.Items[limit-1]
+			resp.NextCursor = h.cursors.Encode(pagination.Cursor{Scope: "reviews:" + id.String(), CreatedAt: last.CreatedAt, ID: last.ID})
+		}
+		shared.WriteJSON(w, http.StatusOK, resp)
+	})
+}
+
+func (h *ReviewsHandler) listing(w http.ResponseWriter, r *http.Request) (models.Listing, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		shared.BadRequest(w, r, "invalid listing id")
+		return models.Listing{}, false
+	}
+	l, err := h.repos.Listings().GetByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && l.Status == "deleted") {
+		shared.NotFound(w, r, "listing not found")
+		return models.Listing{}, false
+	}
+	if err != nil {
+		shared.InternalError(w, r, err)
+		return models.Listing{}, false
+	}
+	return l, true
+}
@@ -0,0 +1,20 @@
+package ws
+
+import (
+	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/openapi"
+)
+
+// Docs — описание операций пакета для /openapi.json (ключ — имя хендлера).
+var Docs = openapi.Operations{
+	"GatewayHandler": {
+		Summary: "WebSocket gateway",
+		Description: "Upgrades to WebSocket. Inbound frames: typing {thread_id}, chat.send {session_id, text, meta?}. " +
+			"One chat.send is processed at a time per connection (another one meanwhile gets an error event), " +
+			"typing frames more often than every 2 seconds are dropped. " +
+			"Outbound events: assistant replies, direct messages, read receipts, typing, notifications and errors. " +
+			"The token may be passed as ?access_token=.",
+		Status: http.StatusSwitchingProtocols,
+	},
+}
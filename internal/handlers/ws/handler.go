@@ -0,0 +1,194 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/handlers/chat"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/i18n"
+	"github.com/btynybekov/marketplace/internal/logging"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/ratelimit"
+	"github.com/btynybekov/marketplace/internal/realtime"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+// Входящие кадры от клиента.
+const (
+	inTyping   = "typing"    // {"thread_id": "..."} — пользователь печатает в переписке
+	inChatSend = "chat.send" // {"session_id": "...", "text": "..."} — сообщение AI-ассистенту
+)
+
+// typingInterval — не чаще одного typing на подключение: каждый — запрос к переписке и pg_notify,
+// а клиенту хватает одного события в несколько секунд. Лишние кадры молча отбрасываются.
+const typingInterval = 2 * time.Second
+
+// connState — состояние одного подключения (handle вызывается последовательно в горутине чтения).
+type connState struct {
+	sending    atomic.Bool // chat.send в обработке: следующий — ошибка, а не ещё один параллельный вызов LLM
+	lastTyping time.Time
+}
+
+type typingData struct {
+	ThreadID uuid.UUID `json:"thread_id"`
+}
+
+type chatSendData struct {
+	SessionID string            `json:"session_id" validate:"required,max=64"`
+	Text      string            `json:"text" validate:"required,max=4000"`
+	Meta      map[string]string `json:"meta,omitempty" validate:"max=20"`
+}
+
+// GatewayHandler — GET /ws: одно WebSocket-подключение на вкладку, в котором
+// мультиплексируются ответы ассистента, сообщения переписки, typing и уведомления.
+// Авторизация — Bearer-токен или ?access_token= (см. middleware.Authenticate).
+type GatewayHandler struct {
+	hub      *realtime.Hub
+	repos    repository.RepositorySet
+	chat     chat.Service
+	upgrader websocket.Upgrader
+
+	// chat.send тратит LLM-токены: те же лимиты, что у POST /chat/ajax
+	limiter *ratelimit.Limiter
+	quota   *ratelimit.Quota
+	keys    []ratelimit.KeyFunc
+}
+
+func NewGatewayHandler(hub *realtime.Hub, repos repository.RepositorySet, chatSvc chat.Service, limiter *ratelimit.Limiter, quota *ratelimit.Quota, keys ...ratelimit.KeyFunc) *GatewayHandler {
+	return &GatewayHandler{
+		hub:     hub,
+		repos:   repos,
+		chat:    chatSvc,
+		limiter: limiter,
+		quota:   quota,
+		keys:    keys,
+		// CheckOrigin по умолчанию: только тот же Origin, что и Host
+		upgrader: websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 4096},
+	}
+}
+
+func (h *GatewayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	uid, _ := middleware.UserIDFromContext(r.Context())
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade уже ответил клиенту ошибкой
+	}
+	st := &connState{}
+	h.hub.Serve(r.Context(), conn, uid, func(ctx context.Context, userID uuid.UUID, in realtime.Inbound, reply func(realtime.Event)) {
+		h.handle(ctx, r, st, userID, in, reply)
+	})
+}
+
+func (h *GatewayHandler) handle(ctx context.Context, r *http.Request, st *connState, userID uuid.UUID, in realtime.Inbound, reply func(realtime.Event)) {
+	// ошибки кадров — в том же формате, что и HTTP (shared.Error): код + сообщение на языке клиента
+	lang := i18n.FromRequest(r)
+	fail := func(err error) {
+		e := apperror.From(err)
+		if e.Status >= http.StatusInternalServerError {
+			slog.ErrorContext(ctx, "ws message failed", slog.String("type", in.Type), logging.Err(e))
+		}
+		resp := shared.NewErrorResp(lang, e)
+		data := map[string]any{"frame": in.Type, "code": resp.Code, "error": resp.Error}
+		if len(resp.Fields) > 0 {
+			data["fields"] = resp.Fields
+		}
+		reply(realtime.Event{Type: realtime.EventError, Data: data})
+	}
+
+	switch in.Type {
+	case inTyping:
+		now := time.Now()
+		if now.Sub(st.lastTyping) < typingInterval {
+			return
+		}
+		st.lastTyping = now
+		var d typingData
+		if err := json.Unmarshal(in.Data, &d); err != nil {
+			fail(apperror.BadRequest("invalid JSON"))
+			return
+		}
+		t, err := h.repos.Threads().Get(ctx, d.ThreadID)
+		if err != nil || (t.BuyerID != userID && t.SellerID != userID) {
+			fail(apperror.NotFound("thread not found"))
+			return
+		}
+		to := t.BuyerID
+		if to == userID {
+			to = t.SellerID
+		}
+		h.hub.Publish(ctx, to, realtime.Event{
+			Type: realtime.EventTyping,
+			Data: map[string]any{"thread_id": t.ID, "user_id": userID},
+		})
+
+	case inChatSend:
+		var d chatSendData
+		if err := json.Unmarshal(in.Data, &d); err != nil {
+			fail(apperror.BadRequest("invalid JSON"))
+			return
+		}
+		if err := shared.Validate(&d); err != nil {
+			fail(err)
+			return
+		}
+		// один chat.send на подключение: лимитер ограничивает частоту, но не число одновременных вызовов LLM
+		if !st.sending.CompareAndSwap(false, true) {
+			fail(apperror.TooManyRequests("previous message is still being processed"))
+			return
+		}
+		if retry, e := h.allow(ctx, r, d.SessionID); e != nil {
+			st.sending.Store(false)
+			reply(realtime.Event{Type: realtime.EventError, Data: map[string]any{
+				"frame": in.Type, "code": e.Code, "error": i18n.T(lang, e.Message),
+				"retry_after": int(retry.Seconds()) + 1,
+			}})
+			return
+		}
+		ctx := ratelimit.WithSubject(ctx, h.quota.Subject(r))
+		// ответ LLM может занять секунды — не блокируем чтение (и pong) подключения;
+		// сам ответ придёт событием assistant.reply из chat.Service
+		go func() {
+			defer st.sending.Store(false)
+			req := r.WithContext(ctx)
+			if _, err := h.chat.AppendUserMessage(req, d.SessionID, d.Text, d.Meta); err != nil {
+				fail(err)
+				return
+			}
+			if _, _, err := h.chat.GenerateAssistantReply(req, d.SessionID); err != nil {
+				fail(err)
+			}
+		}()
+
+	default:
+		fail(apperror.BadRequest("unknown frame type"))
+	}
+}
+
+// allow — rate limit и суточная квота для chat.send (ключи — по upgrade-запросу + сессия).
+// nil — кадр можно обрабатывать.
+func (h *GatewayHandler) allow(ctx context.Context, r *http.Request, sessionID string) (time.Duration, *apperror.Error) {
+	keys := []string{"session:" + sessionID}
+	for _, kf := range h.keys {
+		if k, ok := kf(r); ok {
+			keys = append(keys, k)
+		}
+	}
+	ok, retry, err := h.limiter.Allow(ctx, keys...)
+	if err == nil && !ok {
+		return retry, apperror.TooManyRequests("rate limit exceeded")
+	}
+	over, retry, err := h.quota.Exceeded(ctx, h.quota.Subject(r))
+	if err == nil && over {
+		return retry, apperror.QuotaExceeded("daily AI quota exceeded")
+	}
+	return 0, nil
+}
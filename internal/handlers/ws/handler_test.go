@@ -0,0 +1,218 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/btynybekov/marketplace/internal/handlers/chat"
+	"github.com/btynybekov/marketplace/internal/middleware"
+	"github.com/btynybekov/marketplace/internal/ratelimit"
+	"github.com/btynybekov/marketplace/internal/realtime"
+	"github.com/btynybekov/marketplace/internal/repository/memory"
+	"github.com/btynybekov/marketplace/internal/repository/repotest"
+)
+
+// slowChat — chat.Service, у которого AppendUserMessage ждёт release.
+type slowChat struct {
+	chat.Service
+	started chan string
+	release chan struct{}
+}
+
+func (c *slowChat) AppendUserMessage(_ *http.Request, _, text string, _ map[string]string) (string, error) {
+	c.started <- text
+	<-c.release
+	return "", nil
+}
+
+func (c *slowChat) GenerateAssistantReply(*http.Request, string) (string, map[string]any, error) {
+	return "", nil, nil
+}
+
+type gateway struct {
+	t     *testing.T
+	srv   *httptest.Server
+	hub   *realtime.Hub
+	repos *memory.Repos
+	chat  *slowChat
+}
+
+func newGateway(t *testing.T) *gateway {
+	t.Helper()
+	repos, err := memory.New(repotest.Data())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &gateway{t: t, hub: realtime.NewHub(realtime.NewLocalBroker()), repos: repos,
+		chat: &slowChat{started: make(chan string, 4), release: make(chan struct{})}}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go g.hub.Run(ctx)
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.PerMinute(1000, 100))
+	quota := ratelimit.NewQuota(ratelimit.NewMemoryUsageStore(), 0, 0, false)
+	h := NewGatewayHandler(g.hub, repos, g.chat, limiter, quota)
+	g.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uid := uuid.MustParse(r.URL.Query().Get("user"))
+		h.ServeHTTP(w, r.WithContext(middleware.WithUserID(r.Context(), uid)))
+	}))
+	t.Cleanup(g.srv.Close)
+	return g
+}
+
+type frame struct {
+	Type string         `json:"type"`
+	Data map[string]any `json:"data"`
+}
+
+// conn — подключение пользователя; входящие события читаются в канал.
+type conn struct {
+	t      *testing.T
+	ws     *websocket.Conn
+	frames chan frame
+}
+
+func (g *gateway) connect(user uuid.UUID) *conn {
+	g.t.Helper()
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(g.srv.URL, "http")+"?user="+user.String(), nil)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	g.t.Cleanup(func() { ws.Close() })
+	c := &conn{t: g.t, ws: ws, frames: make(chan frame, 16)}
+	go func() {
+		defer close(c.frames)
+		for {
+			var f frame
+			if ws.ReadJSON(&f) != nil {
+				return
+			}
+			c.frames <- f
+		}
+	}()
+	return c
+}
+
+// waitDelivery — хаб слушает брокер и подключение user зарегистрировано: пробное событие дошло.
+func (g *gateway) waitDelivery(c *conn, user uuid.UUID) {
+	g.t.Helper()
+	for i := 0; i < 100; i++ {
+		g.hub.Publish(context.Background(), user, realtime.Event{Type: "probe"})
+		if f, ok := c.next(20 * time.Millisecond); ok && f.Type == "probe" {
+			for ok { // лишние пробы, отправленные до первой доставки
+				_, ok = c.next(50 * time.Millisecond)
+			}
+			return
+		}
+	}
+	g.t.Fatal("events are not delivered to the connection")
+}
+
+func (c *conn) send(typ string, data any) {
+	c.t.Helper()
+	if err := c.ws.WriteJSON(map[string]any{"type": typ, "data": data}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// next — следующее событие (или ok=false, если за wait его нет).
+func (c *conn) next(wait time.Duration) (frame, bool) {
+	select {
+	case f, ok := <-c.frames:
+		return f, ok
+	case <-time.After(wait):
+		return frame{}, false
+	}
+}
+
+func waitStarted(t *testing.T, ch chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		if got != want {
+			t.Fatalf("chat got %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("chat.send %q was not processed", want)
+	}
+}
+
+func TestChatSendOneAtATime(t *testing.T) {
+	g := newGateway(t)
+	c := g.connect(uuid.New())
+	msg := func(text string) map[string]any { return map[string]any{"session_id": "s1", "text": text} }
+
+	c.send(inChatSend, msg("первое"))
+	waitStarted(t, g.chat.started, "первое")
+
+	c.send(inChatSend, msg("второе"))
+	f, ok := c.next(5 * time.Second)
+	if !ok || f.Type != realtime.EventError || f.Data["code"] != "rate_limited" || f.Data["frame"] != inChatSend {
+		t.Fatalf("chat.send while busy = %+v, want a rate_limited error", f)
+	}
+	select {
+	case text := <-g.chat.started:
+		t.Fatalf("%q was processed in parallel", text)
+	default:
+	}
+
+	// первое обработано — подключение снова принимает chat.send
+	close(g.chat.release)
+	for i := 0; ; i++ {
+		c.send(inChatSend, msg("третье"))
+		select {
+		case got := <-g.chat.started:
+			if got != "третье" {
+				t.Fatalf("chat got %q", got)
+			}
+			return
+		case f := <-c.frames:
+			if f.Data["code"] != "rate_limited" || i == 100 {
+				t.Fatalf("chat.send after the first finished = %+v", f)
+			}
+			time.Sleep(10 * time.Millisecond) // горутина первого ещё не сняла флаг
+		}
+	}
+}
+
+func TestTypingThrottled(t *testing.T) {
+	g := newGateway(t)
+	// bob продаёт L6 (repotest.Data), carol — покупатель
+	bob, carol := uuid.MustParse("00000000-0000-4000-8000-000000000302"), uuid.MustParse("00000000-0000-4000-8000-000000000303")
+	th, err := g.repos.Threads().GetOrCreate(context.Background(), uuid.MustParse("00000000-0000-4000-8000-000000000506"), carol, bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seller, buyer := g.connect(bob), g.connect(carol)
+	g.waitDelivery(seller, bob)
+
+	for i := 0; i < 5; i++ {
+		buyer.send(inTyping, map[string]any{"thread_id": th.ID})
+	}
+	// кадры обрабатываются по порядку: ответ на неизвестный кадр — все typing уже разобраны
+	buyer.send("noop", nil)
+	if f, ok := buyer.next(5 * time.Second); !ok || f.Type != realtime.EventError || f.Data["frame"] != "noop" {
+		t.Fatalf("reply to noop = %+v", f)
+	}
+
+	typing := 0
+	for {
+		f, ok := seller.next(200 * time.Millisecond)
+		if !ok {
+			break
+		}
+		if f.Type == realtime.EventTyping {
+			typing++
+		}
+	}
+	if typing != 1 {
+		t.Fatalf("seller got %d typing events for 5 frames, want 1", typing)
+	}
+}
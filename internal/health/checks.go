@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres — пул отвечает на ping.
+func Postgres(pool *pgxpool.Pool) CheckFunc {
+	return func(ctx context.Context) error {
+		return pool.Ping(ctx)
+	}
+}
+
+// migrationName — NNNN_name.up.sql (golang-migrate).
+var migrationName = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+
+// LatestMigration — номер последней миграции в fsys (обычно migrations.FS).
+func LatestMigration(fsys fs.FS) (uint64, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return 0, err
+	}
+	var latest uint64
+	for _, e := range entries {
+		m := migrationName.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		v, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+		latest = max(latest, v)
+	}
+	return latest, nil
+}
+
+// Migrations — схема БД не старее той, что ждёт этот бинарник (schema_migrations golang-migrate),
+// и последняя миграция не упала на середине (dirty). Версия новее — норма при раскатке:
+// новые миграции накатываются до того, как сменятся все инстансы.
+func Migrations(pool *pgxpool.Pool, want uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		var (
+			version uint64
+			dirty   bool
+		)
+		err := pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return fmt.Errorf("migrations are not applied, want version %d", want)
+		case err != nil:
+			return fmt.Errorf("read schema version: %w", err)
+		case dirty:
+			return fmt.Errorf("migration %d is dirty", version)
+		case version < want:
+			return fmt.Errorf("schema version %d, want %d", version, want)
+		}
+		return nil
+	}
+}
+
+// HTTP — сервис по url отвечает (статус ниже 500: у webhook-ов n8n на GET — 404,
+// и это значит, что n8n жив). 401/403 — тоже сбой: ключ из header не принят.
+func HTTP(client *http.Client, url string, header http.Header) CheckFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		if resp.StatusCode >= http.StatusInternalServerError ||
+			resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("responded %s", resp.Status)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,164 @@
+// Package health — пробы для оркестратора: /healthz (процесс жив) и /readyz
+// (готов принимать трафик: Postgres, версия схемы, при желании — LLM и n8n).
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/btynybekov/marketplace/internal/logging"
+)
+
+// checkTimeout — сколько ждём одну проверку: проба не должна висеть дольше таймаута оркестратора.
+const checkTimeout = 2 * time.Second
+
+// CheckFunc — проверка зависимости; nil — зависимость в порядке.
+type CheckFunc func(ctx context.Context) error
+
+// Check — именованная проверка.
+type Check struct {
+	Name string
+	Fn   CheckFunc
+	// Optional — сбой не снимает инстанс с трафика (статус "degraded"): внешний сервис
+	// упал у всех инстансов сразу, и выводить их из балансировки бессмысленно.
+	Optional bool
+	// TTL — сколько держать результат: частые пробы не долбят внешние сервисы. 0 — проверять каждый раз.
+	TTL time.Duration
+}
+
+// result — последний результат проверки.
+type result struct {
+	err error
+	at  time.Time
+}
+
+// Health — состояние готовности инстанса и режим drain.
+type Health struct {
+	checks   []Check
+	draining atomic.Bool
+
+	mu    sync.Mutex
+	cache map[string]result
+}
+
+// New — набор проверок для /readyz.
+func New(checks ...Check) *Health {
+	return &Health{checks: checks, cache: map[string]result{}}
+}
+
+// Drain — инстанс останавливается: /readyz сразу начинает отвечать 503, чтобы балансировщик
+// перестал слать новые запросы до того, как сервер закроет соединения. Вызывается до srv.Shutdown.
+func (h *Health) Drain() { h.draining.Store(true) }
+
+// Draining — включён ли drain.
+func (h *Health) Draining() bool { return h.draining.Load() }
+
+// CheckStatus — результат одной проверки в ответе /readyz. Причина сбоя — только в логе:
+// /readyz открыт, а текст ошибки раскрывает адреса, имена и версии зависимостей.
+type CheckStatus struct {
+	Status    string    `json:"status"` // ok | fail
+	Optional  bool      `json:"optional,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report — ответ /readyz.
+type Report struct {
+	Status string                 `json:"status"` // ok | degraded | fail | draining
+	Checks map[string]CheckStatus `json:"checks,omitempty"`
+}
+
+// Ready — прогоняет проверки (параллельно, с учётом TTL) и собирает отчёт.
+// ok == false — инстанс не готов: drain или упала обязательная проверка.
+func (h *Health) Ready(ctx context.Context) (rep Report, ok bool) {
+	if h.Draining() {
+		return Report{Status: "draining"}, false
+	}
+
+	results := make([]result, len(h.checks))
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = h.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	rep = Report{Status: "ok", Checks: make(map[string]CheckStatus, len(h.checks))}
+	ok = true
+	for i, c := range h.checks {
+		st := CheckStatus{Status: "ok", Optional: c.Optional, CheckedAt: results[i].at}
+		if results[i].err != nil {
+			st.Status = "fail"
+			if c.Optional {
+				if rep.Status == "ok" {
+					rep.Status = "degraded"
+				}
+			} else {
+				rep.Status, ok = "fail", false
+			}
+		}
+		rep.Checks[c.Name] = st
+	}
+	return rep, ok
+}
+
+// run — результат проверки из кэша (если не старше TTL) или новый.
+func (h *Health) run(ctx context.Context, c Check) result {
+	if c.TTL > 0 {
+		h.mu.Lock()
+		res, ok := h.cache[c.Name]
+		h.mu.Unlock()
+		if ok && time.Since(res.at) < c.TTL {
+			return res
+		}
+	}
+
+	// проба не должна зависеть от отмены запроса оркестратором на середине — своё время жизни
+	cctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), checkTimeout)
+	defer cancel()
+	res := result{err: c.Fn(cctx), at: time.Now()}
+	if res.err != nil {
+		slog.WarnContext(ctx, "health check failed", slog.String("check", c.Name), logging.Err(res.err))
+	}
+
+	if c.TTL > 0 {
+		h.mu.Lock()
+		h.cache[c.Name] = res
+		h.mu.Unlock()
+	}
+	return res
+}
+
+// Liveness — GET /healthz: процесс жив и обслуживает HTTP. Зависимости не проверяет —
+// иначе сбой Postgres привёл бы к перезапуску всех инстансов, который ничего не чинит.
+func (h *Health) Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: "ok"})
+	})
+}
+
+// Readiness — GET /readyz: 200, если инстанс готов (в т. ч. "degraded"), иначе 503.
+func (h *Health) Readiness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rep, ok := h.Ready(r.Context())
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusServiceUnavailable
+		}
+		writeReport(w, status, rep)
+	})
+}
+
+func writeReport(w http.ResponseWriter, status int, rep Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(rep)
+}
@@ -0,0 +1,29 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadinessHidesCheckErrors(t *testing.T) {
+	h := New(Check{Name: "postgres", Fn: func(context.Context) error {
+		return errors.New("dial tcp 10.0.3.17:5432: connection refused")
+	}})
+	rec := httptest.NewRecorder()
+	h.Readiness().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `"postgres":{"status":"fail"`) {
+		t.Errorf("body = %s, want the failed postgres check", body)
+	}
+	if strings.Contains(body, "10.0.3.17") || strings.Contains(body, "error") {
+		t.Errorf("body = %s, leaks the check error", body)
+	}
+}
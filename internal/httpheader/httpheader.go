@@ -0,0 +1,46 @@
+// Package httpheader — разбор заголовков-списков с q-значениями (RFC 9110, 12.4.2):
+// Accept, Accept-Encoding, Accept-Language.
+package httpheader
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Item — элемент списка: значение в нижнем регистре без параметров и его вес.
+type Item struct {
+	Value string
+	Q     float64
+}
+
+// ParseList — элементы заголовка в исходном порядке, пустые пропускаются.
+// q ищется среди всех параметров элемента ("text/html;level=1;q=0.5"), имя — без учёта
+// регистра; без q или с неразобранным q вес — 1, значения вне [0, 1] прижимаются к границам.
+func ParseList(header string) []Item {
+	var out []Item
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		v := strings.ToLower(strings.TrimSpace(params[0]))
+		if v == "" {
+			continue
+		}
+		out = append(out, Item{Value: v, Q: quality(params[1:])})
+	}
+	return out
+}
+
+func quality(params []string) float64 {
+	for _, p := range params {
+		name, val, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil || math.IsNaN(q) {
+			return 1
+		}
+		return min(max(q, 0), 1)
+	}
+	return 1
+}
@@ -0,0 +1,29 @@
+package httpheader
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		want   []Item
+	}{
+		{"", nil},
+		{" , ,", nil},
+		{"gzip", []Item{{"gzip", 1}}},
+		{"GZip;q=0.5, br", []Item{{"gzip", 0.5}, {"br", 1}}},
+		{"text/html;level=1;q=0.3, */*;q=0", []Item{{"text/html", 0.3}, {"*/*", 0}}},
+		{"ru-RU ; Q = 0.8", []Item{{"ru-ru", 0.8}}},
+		{"en;q=abc", []Item{{"en", 1}}},     // неразобранный q — как без него
+		{"en;q=NaN", []Item{{"en", 1}}},     // тоже
+		{"en;q=7", []Item{{"en", 1}}},       // вне диапазона — к границе
+		{"en;q=-1", []Item{{"en", 0}}},      // тоже
+		{"ky;charset=x", []Item{{"ky", 1}}}, // другие параметры не влияют
+	} {
+		if got := ParseList(tc.header); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseList(%q) = %v, want %v", tc.header, got, tc.want)
+		}
+	}
+}
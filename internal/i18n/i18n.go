@@ -0,0 +1,87 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/btynybekov/marketplace/internal/httpheader"
+)
+
+// Поддерживаемые языки. Ключи каталогов — английские сообщения (шаблоны fmt),
+// поэтому для английского каталог не нужен.
+const (
+	EN = "en"
+	RU = "ru"
+	KY = "ky"
+
+	Default = EN
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// catalogs — язык → (английский шаблон → перевод); загружаются один раз при старте.
+var catalogs = mustLoad(RU, KY)
+
+func mustLoad(langs ...string) map[string]map[string]string {
+	out := make(map[string]map[string]string, len(langs))
+	for _, lang := range langs {
+		raw, err := locales.ReadFile("locales/" + lang + ".json")
+		if err != nil {
+			panic("i18n: " + err.Error())
+		}
+		m := map[string]string{}
+		if err := json.Unmarshal(raw, &m); err != nil {
+			panic("i18n: locales/" + lang + ".json: " + err.Error())
+		}
+		out[lang] = m
+	}
+	return out
+}
+
+// Supported — есть ли каталог для языка.
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok || lang == EN
+}
+
+// T — перевод сообщения; без перевода возвращается английский текст.
+func T(lang, msg string, args ...any) string {
+	if tr, ok := catalogs[lang][msg]; ok {
+		msg = tr
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// FromRequest — язык ответа по заголовку Accept-Language.
+func FromRequest(r *http.Request) string {
+	return Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// Negotiate — первый поддерживаемый язык из Accept-Language с учётом q
+// ("ky-KG,ru;q=0.9,en;q=0.5" → ky). Ничего подходящего — Default.
+func Negotiate(header string) string {
+	type pref struct {
+		lang string
+		q    float64
+	}
+	var prefs []pref
+	for _, it := range httpheader.ParseList(header) {
+		base, _, _ := strings.Cut(it.Value, "-")
+		if it.Q > 0 && Supported(base) {
+			prefs = append(prefs, pref{lang: base, q: it.Q})
+		}
+	}
+	if len(prefs) == 0 {
+		return Default
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	return prefs[0].lang
+}
@@ -0,0 +1,99 @@
+// Package logging — структурные логи на log/slog и request ID в контексте запроса.
+//
+// Setup делает JSON-логгер логгером по умолчанию: slog.InfoContext(ctx, ...) в любом пакете
+// сам добавляет request_id (и trace_id) из ctx, а оставшиеся log.Printf уходят в тот же поток на уровне INFO.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader — заголовок с ID запроса: принимается от клиента/прокси,
+// возвращается в ответе и передаётся во внешние вызовы (LLM, n8n).
+const RequestIDHeader = "X-Request-ID"
+
+type ctxKey struct{}
+
+// WithRequestID — контекст с ID запроса.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// RequestID — ID запроса из контекста ("" — вне HTTP-запроса: фоновые задачи).
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Propagate — проставляет X-Request-ID исходящему запросу из его контекста.
+func Propagate(req *http.Request) {
+	if id := RequestID(req.Context()); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+}
+
+// ParseLevel — LOG_LEVEL: debug | info | warn | error (неизвестное — info).
+func ParseLevel(s string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// Setup — JSON-логи в stdout с уровнем LOG_LEVEL; debug=true (DEBUG) опускает уровень до debug.
+// Логгер становится slog.Default (и приёмником пакета log).
+func Setup(level string, debug bool) *slog.Logger {
+	lvl := ParseLevel(level)
+	if debug {
+		lvl = slog.LevelDebug
+	}
+	logger := New(os.Stdout, lvl)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// New — логгер с request_id из контекста в каждой записи.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+// contextHandler — добавляет к записи атрибуты из контекста: request_id и, если запрос
+// трейсится, trace_id/span_id (по ним запись находится рядом со спаном в трейсе).
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Err — атрибут ошибки (единое имя ключа во всех логах).
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryTracer — pgx.QueryTracer: запросы к БД в логах с request_id вызывающего HTTP-запроса.
+// Ошибки и медленные запросы — WARN, остальные — DEBUG (видны с LOG_LEVEL=debug).
+type QueryTracer struct {
+	Slow time.Duration // порог медленного запроса (0 — не выделять)
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql   string
+	start time.Time
+}
+
+func (t QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, start: time.Now()})
+}
+
+func (t QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	qs, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(qs.start)
+	failed := data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) && !errors.Is(data.Err, context.Canceled)
+	slow := t.Slow > 0 && elapsed >= t.Slow
+	if !failed && !slow && !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return // горячий путь: SQL не форматируем
+	}
+
+	attrs := []any{slog.String("sql", CompactSQL(qs.sql)), slog.Duration("duration", elapsed)}
+	switch {
+	case failed:
+		slog.WarnContext(ctx, "db query failed", append(attrs, Err(data.Err))...)
+	case slow:
+		slog.WarnContext(ctx, "db query slow", attrs...)
+	default:
+		slog.DebugContext(ctx, "db query", append(attrs, slog.String("command", data.CommandTag.String()))...)
+	}
+}
+
+// CompactSQL — SQL в одну строку и не длиннее 500 символов (многострочные запросы репозиториев).
+func CompactSQL(sql string) string {
+	out := make([]byte, 0, len(sql))
+	space := false
+	for i := 0; i < len(sql) && len(out) < 500; i++ {
+		c := sql[i]
+		if c == ' ' || c == '\n' || c == '\t' || c == '\r' {
+			space = len(out) > 0
+			continue
+		}
+		if space {
+			out = append(out, ' ')
+			space = false
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
@@ -0,0 +1,104 @@
+// Package metrics — метрики Prometheus: HTTP по маршрутам, пул соединений Postgres, вызовы LLM.
+// Отдаются на GET /metrics.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/btynybekov/marketplace/internal/ai"
+)
+
+const namespace = "marketplace"
+
+// Metrics — реестр метрик процесса. Реестр свой, а не глобальный prometheus.DefaultRegisterer:
+// тесты и несколько экземпляров в одном процессе не конфликтуют.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpDuration *prometheus.HistogramVec // method, route, status
+
+	llmRequests *prometheus.CounterVec   // provider, model, outcome
+	llmDuration *prometheus.HistogramVec // provider, model
+	llmTokens   *prometheus.CounterVec   // provider, model, kind
+}
+
+// New — реестр с метриками процесса и Go runtime.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template, method and status.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"method", "route", "status"}),
+		llmRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "llm_requests_total",
+			Help:      "LLM chat calls by provider, model and outcome (ok, error, timeout, canceled).",
+		}, []string{"provider", "model", "outcome"}),
+		llmDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "llm_request_duration_seconds",
+			Help:      "LLM chat call latency by provider and model.",
+			Buckets:   []float64{.25, .5, 1, 2, 4, 8, 15, 30, 60},
+		}, []string{"provider", "model"}),
+		llmTokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "llm_tokens_total",
+			Help:      "LLM tokens by provider, model and kind (prompt, completion).",
+		}, []string{"provider", "model", "kind"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration, m.llmRequests, m.llmDuration, m.llmTokens,
+	)
+	return m
+}
+
+// Register — дополнительный коллектор (напр. NewPoolCollector).
+func (m *Metrics) Register(c prometheus.Collector) error {
+	return m.registry.Register(c)
+}
+
+// Handler — GET /metrics в формате Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTP — реализация middleware.HTTPObserver.
+func (m *Metrics) ObserveHTTP(method, route string, status int, elapsed time.Duration) {
+	m.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(elapsed.Seconds())
+}
+
+// ObserveLLM — реализация ai.Observer.
+func (m *Metrics) ObserveLLM(provider, model string, elapsed time.Duration, err error) {
+	outcome := "ok"
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		outcome = "timeout"
+	case errors.Is(err, context.Canceled):
+		outcome = "canceled"
+	case err != nil:
+		outcome = "error"
+	}
+	m.llmRequests.WithLabelValues(provider, model, outcome).Inc()
+	m.llmDuration.WithLabelValues(provider, model).Observe(elapsed.Seconds())
+}
+
+// LLMUsage — ai.UsageFunc, считающая токены провайдера provider (цепляется к OnUsage через ai.ChainUsage).
+func (m *Metrics) LLMUsage(provider string) ai.UsageFunc {
+	return func(_ context.Context, model string, u ai.Usage) {
+		m.llmTokens.WithLabelValues(provider, model, "prompt").Add(float64(u.PromptTokens))
+		m.llmTokens.WithLabelValues(provider, model, "completion").Add(float64(u.CompletionTokens))
+	}
+}
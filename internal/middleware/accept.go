@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+)
+
+// AcceptJSON — API отвечает только JSON: клиент, чей Accept исключает application/json
+// (напр. "text/html" или "application/json;q=0"), получает 406.
+func AcceptJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if shared.Negotiate(r, shared.MediaJSON) == "" {
+			shared.Error(w, r, apperror.NotAcceptable(shared.MediaJSON))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
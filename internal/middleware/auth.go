@@ -1,15 +1,88 @@
 package middleware
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+	"strings"
 
-// AuthMiddleware проверяет заголовок Authorization
-func AuthMiddleware(next http.Handler) http.Handler {
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+)
+
+type ctxKey int
+
+const userIDKey ctxKey = iota
+
+// WithUserID — кладёт ID пользователя в контекст (используется Authenticate и тестами).
+func WithUserID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// UserIDFromContext — ID авторизованного пользователя, если он есть.
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(userIDKey).(uuid.UUID)
+	return id, ok
+}
+
+// Authenticate разбирает "Authorization: Bearer <JWT>" (HS256, sub = UUID пользователя,
+// exp обязателен: токен без срока действия не отзывается ничем, кроме смены секрета).
+// Запросы без заголовка проходят анонимно; невалидный токен — 401. Без секрета токен
+// проверить нечем — тоже 401, а не молчаливый аноним: ошибка конфигурации видна клиенту.
+func Authenticate(secret string) func(http.Handler) http.Handler {
+	key := []byte(secret)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, ok := bearerToken(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) == 0 {
+				shared.Unauthorized(w, r, "authentication is not configured")
+				return
+			}
+
+			var claims jwt.RegisteredClaims
+			_, err := jwt.ParseWithClaims(raw, &claims, func(*jwt.Token) (any, error) {
+				return key, nil
+			}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+			if err != nil {
+				shared.Unauthorized(w, r, "invalid token")
+				return
+			}
+			id, err := uuid.Parse(claims.Subject)
+			if err != nil {
+				shared.Unauthorized(w, r, "invalid token subject")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), id)))
+		})
+	}
+}
+
+// RequireUser — пропускает только авторизованных (после Authenticate).
+func RequireUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		if token != "secret-token" {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		if _, ok := UserIDFromContext(r.Context()); !ok {
+			shared.Unauthorized(w, r, "unauthorized")
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// bearerToken — токен из заголовка Authorization. Браузерный WebSocket API не умеет
+// ставить заголовки, поэтому для upgrade-запросов допускается ?access_token=.
+func bearerToken(r *http.Request) (string, bool) {
+	if raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return raw, true
+	}
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		if raw := r.URL.Query().Get("access_token"); raw != "" {
+			return raw, true
+		}
+	}
+	return "", false
+}
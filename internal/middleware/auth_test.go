@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestAuthenticate(t *testing.T) {
+	uid := uuid.New()
+	signClaims := func(secret string, claims jwt.RegisteredClaims) string {
+		tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + tok
+	}
+	sign := func(secret, sub string) string {
+		return signClaims(secret, jwt.RegisteredClaims{Subject: sub, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	}
+
+	for _, tc := range []struct {
+		name, secret, header string
+		status               int
+		user                 bool
+	}{
+		{"anonymous", "s", "", http.StatusOK, false},
+		{"valid token", "s", sign("s", uid.String()), http.StatusOK, true},
+		{"wrong key", "s", sign("other", uid.String()), http.StatusUnauthorized, false},
+		{"subject is not a uuid", "s", sign("s", "alice"), http.StatusUnauthorized, false},
+		{"garbage", "s", "Bearer garbage", http.StatusUnauthorized, false},
+		{"no exp", "s", signClaims("s", jwt.RegisteredClaims{Subject: uid.String()}), http.StatusUnauthorized, false},
+		{"expired", "s", signClaims("s", jwt.RegisteredClaims{Subject: uid.String(), ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))}), http.StatusUnauthorized, false},
+		{"no secret, anonymous", "", "", http.StatusOK, false},
+		{"no secret, token", "", sign("s", uid.String()), http.StatusUnauthorized, false},
+	} {
+		var gotUser bool
+		h := Authenticate(tc.secret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := UserIDFromContext(r.Context())
+			gotUser = ok && id == uid
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.status || gotUser != tc.user {
+			t.Errorf("%s: status %d, user %v; want %d, %v", tc.name, rec.Code, gotUser, tc.status, tc.user)
+		}
+	}
+}
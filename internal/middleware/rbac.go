@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+)
+
+// Права (таблица permission, см. миграцию 0009_rbac).
+const (
+	PermAdminAccess     = "admin.access"
+	PermListingsCreate  = "listings.create"
+	PermListingsEditOwn = "listings.edit.own"
+	PermListingsEditAny = "listings.edit.any"
+	PermBrandsManage    = "brands.manage"
+	PermReportsReview   = "reports.review"
+	PermRolesManage     = "roles.manage"
+)
+
+// PermissionChecker — источник прав (обычно repository.RolesRepository).
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, userID uuid.UUID, perm string) (bool, error)
+}
+
+// RequirePermission — пропускает только пользователей с правом perm:
+// без токена — 401, без права — 403.
+func RequirePermission(pc PermissionChecker, perm string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uid, ok := UserIDFromContext(r.Context())
+			if !ok {
+				shared.Unauthorized(w, r, "unauthorized")
+				return
+			}
+			allowed, err := pc.HasPermission(r.Context(), uid, perm)
+			if err != nil {
+				shared.InternalError(w, r, err)
+				return
+			}
+			if !allowed {
+				shared.Forbidden(w, r, "permission %s required", perm)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -8,13 +8,86 @@ import (
 
 // ===== Каталог =====
 
+// Product — карточка объявления в выдаче (listing + бренд/модель из каталога).
 type Product struct {
 	ID           uuid.UUID         `json:"id"`
+	ProductID    *uuid.UUID        `json:"product_id,omitempty"` // модель каталога, если объявление сопоставлено
 	Title        string            `json:"title"`
 	PriceAmount  float64           `json:"price_amount"`
 	CurrencyCode string            `json:"currency_code"`
+	PriceKGS     *float64          `json:"price_kgs,omitempty"`     // цена в сомах (для фильтров/сортировки)
+	DisplayPrice *Money            `json:"display_price,omitempty"` // цена в валюте отображения клиента
+	BrandID      *uuid.UUID        `json:"brand_id,omitempty"`
+	Brand        string            `json:"brand,omitempty"` // название бренда (из product → brand)
+	Model        string            `json:"model,omitempty"` // напр. "iPhone 13"
 	Attrs        map[string]string `json:"attrs,omitempty"`
 	FilterURL    string            `json:"filter_url,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+type Money struct {
+	Amount       float64 `json:"amount"`
+	CurrencyCode string  `json:"currency_code"`
+}
+
+// ExchangeRate — курс валюты к KGS (сколько сомов за 1 единицу).
+type ExchangeRate struct {
+	CurrencyCode string    `json:"currency_code"`
+	RateToKGS    float64   `json:"rate_to_kgs"`
+	Source       string    `json:"source"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CatalogProduct — каноническая модель товара (таблица product).
+type CatalogProduct struct {
+	ID         uuid.UUID      `json:"id"`
+	CategoryID uuid.UUID      `json:"category_id"`
+	BrandID    *uuid.UUID     `json:"brand_id,omitempty"`
+	Model      string         `json:"model,omitempty"`
+	Title      string         `json:"title"`
+	Specs      map[string]any `json:"specs,omitempty"`
+}
+
+// ProductDetails — страница модели: характеристики, медиа и сводка по предложениям.
+type ProductDetails struct {
+	CatalogProduct
+	Brand        *Brand         `json:"brand,omitempty"`
+	CategorySlug string         `json:"category_slug"`
+	Media        []ProductMedia `json:"media"`
+	Offers       OfferStats     `json:"offers"`
+}
+
+// OfferStats — агрегаты по активным объявлениям модели.
+type OfferStats struct {
+	Count       int            `json:"count"`
+	Prices      []PriceStats   `json:"prices"`       // по валютам
+	ByCondition map[string]int `json:"by_condition"` // new/used → количество
+}
+
+type PriceStats struct {
+	CurrencyCode string  `json:"currency_code"`
+	Min          float64 `json:"min"`
+	Median       float64 `json:"median"`
+	Max          float64 `json:"max"`
+	Count        int     `json:"count"`
+}
+
+// Listing — конкретное объявление продавца.
+type Listing struct {
+	ID           uuid.UUID      `json:"id"`
+	SellerID     uuid.UUID      `json:"seller_id"`
+	ProductID    *uuid.UUID     `json:"product_id,omitempty"`
+	CategoryID   uuid.UUID      `json:"category_id"`
+	Title        string         `json:"title"`
+	Description  string         `json:"description"`
+	PriceAmount  float64        `json:"price_amount"`
+	CurrencyCode string         `json:"currency_code"`
+	Condition    string         `json:"condition"` // "new" | "used"
+	LocationText string         `json:"location_text,omitempty"`
+	Attrs        map[string]any `json:"attrs,omitempty"`
+	Status       string         `json:"status"`             // active | paused | sold | deleted
+	BuyerID      *uuid.UUID     `json:"buyer_id,omitempty"` // покупатель проданного объявления (может оставить отзыв)
+	CreatedAt    time.Time      `json:"created_at"`
 }
 
 type ProductMedia struct {
@@ -24,7 +97,22 @@ type ProductMedia struct {
 	Cover     bool      `json:"cover"`
 }
 
+type Brand struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Aliases   []string  `json:"aliases,omitempty"` // альтернативные написания: "айфон", "эпл"
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BrandFacet — бренд и количество активных объявлений в категории (для фильтров).
+type BrandFacet struct {
+	Brand Brand `json:"brand"`
+	Count int   `json:"count"`
+}
+
 type Category struct {
+	ID       uuid.UUID  `json:"id"`
 	Name     string     `json:"name"`
 	Slug     string     `json:"slug"`
 	Children []Category `json:"children,omitempty"`
@@ -39,6 +127,15 @@ type Conversation struct {
 	CreatedAt time.Time  `json:"created_at"`
 }
 
+// ChatSession — серверная сессия чата с ассистентом (ID хранит фронт).
+type ChatSession struct {
+	ID         string     `json:"id"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"` // nil — анонимная сессия
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+}
+
 type Message struct {
 	ID             uuid.UUID         `json:"id"`
 	ConversationID uuid.UUID         `json:"conversation_id"`
@@ -57,3 +154,123 @@ type SearchRequest struct {
 	Params    map[string]string `json:"params,omitempty"` // распарсенные фильтры/категории/валюта
 	CreatedAt time.Time         `json:"created_at"`
 }
+
+// ===== Избранное / сохранённые поиски =====
+
+// SearchRequirements — структурированный запрос покупателя.
+// Тот же формат, что "requirements" в payload buyer-ассистента (n8n).
+type SearchRequirements struct {
+	CategorySlug string   `json:"category_slug,omitempty"`
+	Brand        string   `json:"brand,omitempty"` // slug или свободное написание ("айфон")
+	Query        string   `json:"query,omitempty"`
+	PriceMin     *float64 `json:"price_min,omitempty" validate:"min=0"`
+	PriceMax     *float64 `json:"price_max,omitempty" validate:"min=0"`
+	Currency     string   `json:"currency,omitempty" validate:"len=3"` // валюта price_min/price_max, по умолчанию KGS
+	Condition    string   `json:"condition,omitempty" validate:"enum=new|used"`
+}
+
+type SavedSearch struct {
+	ID           uuid.UUID          `json:"id"`
+	UserID       uuid.UUID          `json:"user_id"`
+	Name         string             `json:"name"`
+	Requirements SearchRequirements `json:"requirements"`
+	LastRunAt    *time.Time         `json:"last_run_at,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+// Notification — запись outbox: чат-ассистент и клиенты забирают недоставленные.
+type Notification struct {
+	ID          uuid.UUID      `json:"id"`
+	UserID      uuid.UUID      `json:"user_id"`
+	Kind        string         `json:"kind"` // напр. "saved_search_match"
+	Text        string         `json:"text"`
+	Payload     map[string]any `json:"payload,omitempty"`
+	DeliveredAt *time.Time     `json:"delivered_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// ===== Переписка покупатель ↔ продавец =====
+
+// Thread — переписка по объявлению. Unread считается для пользователя, запросившего список.
+type Thread struct {
+	ID            uuid.UUID  `json:"id"`
+	ListingID     uuid.UUID  `json:"listing_id"`
+	ListingTitle  string     `json:"listing_title,omitempty"`
+	BuyerID       uuid.UUID  `json:"buyer_id"`
+	SellerID      uuid.UUID  `json:"seller_id"`
+	LastMessage   string     `json:"last_message,omitempty"`
+	LastMessageAt *time.Time `json:"last_message_at,omitempty"`
+	Unread        int        `json:"unread"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type DirectMessage struct {
+	ID        uuid.UUID  `json:"id"`
+	ThreadID  uuid.UUID  `json:"thread_id"`
+	SenderID  uuid.UUID  `json:"sender_id"`
+	Body      string     `json:"body"`
+	ReadAt    *time.Time `json:"read_at,omitempty"` // прочитано получателем
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AbuseReport — жалоба пользователя; разбирается модераторами.
+type AbuseReport struct {
+	ID         uuid.UUID  `json:"id"`
+	ReporterID uuid.UUID  `json:"reporter_id"`
+	TargetType string     `json:"target_type"` // user | listing | thread | message | review
+	TargetID   uuid.UUID  `json:"target_id"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"` // open | resolved | rejected
+	ResolvedBy *uuid.UUID `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ===== Роли и права =====
+
+// UserAccess — роли пользователя и итоговый набор прав.
+type UserAccess struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Roles       []string  `json:"roles"`
+	Permissions []string  `json:"permissions"`
+}
+
+// ===== Профили и отзывы =====
+
+// ContactPrefs — как с продавцом можно связаться (app_user.contact_prefs).
+type ContactPrefs struct {
+	ShowPhone     bool   `json:"show_phone"`     // показывать телефон в публичном профиле
+	AllowMessages bool   `json:"allow_messages"` // принимать сообщения в переписке
+	Preferred     string `json:"preferred,omitempty" validate:"enum=chat|phone"`
+}
+
+// UserProfile — профиль пользователя. Phone в публичном профиле — только при Contact.ShowPhone.
+type UserProfile struct {
+	ID           uuid.UUID    `json:"id"`
+	DisplayName  string       `json:"display_name"`
+	AvatarURL    string       `json:"avatar_url,omitempty"`
+	LocationText string       `json:"location_text,omitempty"`
+	Phone        string       `json:"phone,omitempty"`
+	Contact      ContactPrefs `json:"contact"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// Review — отзыв покупателя о продавце по проданному объявлению.
+type Review struct {
+	ID           uuid.UUID `json:"id"`
+	ListingID    uuid.UUID `json:"listing_id"`
+	ReviewerID   uuid.UUID `json:"reviewer_id"`
+	ReviewerName string    `json:"reviewer_name,omitempty"`
+	SellerID     uuid.UUID `json:"seller_id"`
+	Rating       int       `json:"rating"` // 1..5
+	Body         string    `json:"body,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// RatingStats — сводка отзывов продавца; Distribution[i] — число оценок i+1.
+type RatingStats struct {
+	Count        int     `json:"count"`
+	Average      float64 `json:"average"`
+	Distribution [5]int  `json:"distribution"`
+}
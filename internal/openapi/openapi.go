@@ -0,0 +1,289 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Version — версия спецификации OpenAPI, в которой строится документ.
+const Version = "3.1.0"
+
+// ===== Описание операций (пишут хендлеры) =====
+
+// Operation — описание операции хендлера. DTO задаются значениями Go-типов,
+// схемы строятся из них рефлексией (теги json и validate).
+type Operation struct {
+	Summary     string
+	Description string
+	Query       []Param
+	Request     any // DTO тела запроса; nil — без тела
+	Response    any // DTO ответа; nil — ответ без тела
+	Status      int // код успешного ответа; 0 — 200 (204, если Response == nil)
+}
+
+// Operations — описания операций пакета хендлеров; ключ — имя метода хендлера.
+type Operations map[string]Operation
+
+// Param — query-параметр.
+type Param struct {
+	Name        string
+	Description string
+	Type        string // string (по умолчанию) | integer | number | boolean
+	Required    bool
+	Enum        []string
+}
+
+// OneOf — ответ одной из нескольких форм (напр. список или фасеты в зависимости от query).
+type OneOf []any
+
+// Route — операция, привязанная к маршруту роутера.
+type Route struct {
+	Method     string
+	Path       string // шаблон gorilla/mux: /listings/{id}
+	Tag        string
+	Auth       bool   // под middleware.RequireUser
+	Permission string // право middleware.RequirePermission
+	Limited    bool   // rate limit и суточная квота LLM (429)
+	Operation
+}
+
+// Info — заголовок документа.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// ===== Документ OpenAPI =====
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Server — базовый URL, относительно которого заданы пути (напр. /api/v1).
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem — операции пути; ключ — метод в нижнем регистре.
+type PathItem map[string]*OperationObject
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type OperationObject struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Permission  string                `json:"x-permission,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// bearerAuth — схема авторизации JWT (см. middleware.Authenticate).
+const bearerAuth = "bearerAuth"
+
+// ===== Сборка =====
+
+var pathParam = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// Build — собирает документ из маршрутов. errResp — DTO тела ошибки (общий для всех операций).
+// Недокументированная операция (без Summary) или повтор маршрута — ошибка.
+func Build(info Info, errResp any, routes []Route) (*Document, error) {
+	reg := newRegistry()
+	errSchema, err := reg.schemaOf(reflect.TypeOf(errResp))
+	if err != nil {
+		return nil, err
+	}
+	errContent := map[string]MediaType{"application/json": {Schema: errSchema}}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas:         reg.defs,
+			SecuritySchemes: map[string]SecurityScheme{bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"}},
+		},
+	}
+	for _, rt := range routes {
+		method := strings.ToLower(rt.Method)
+		if rt.Summary == "" {
+			return nil, fmt.Errorf("openapi: %s %s: operation is not documented", rt.Method, rt.Path)
+		}
+		path := pathParam.ReplaceAllString(rt.Path, "{$1}")
+		item := doc.Paths[path]
+		if item == nil {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		if _, dup := item[method]; dup {
+			return nil, fmt.Errorf("openapi: %s %s: duplicate route", rt.Method, rt.Path)
+		}
+
+		op, err := reg.operation(rt, errContent)
+		if err != nil {
+			return nil, fmt.Errorf("openapi: %s %s: %w", rt.Method, rt.Path, err)
+		}
+		item[method] = op
+	}
+	return doc, nil
+}
+
+func (reg *registry) operation(rt Route, errContent map[string]MediaType) (*OperationObject, error) {
+	op := &OperationObject{
+		Summary:     rt.Summary,
+		Description: rt.Description,
+		Responses:   map[string]Response{"default": {Description: "Error", Content: errContent}},
+		Permission:  rt.Permission,
+	}
+	if rt.Tag != "" {
+		op.Tags = []string{rt.Tag}
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(rt.Path, -1) {
+		s := &Schema{Type: "string"}
+		if m[1] == "id" || strings.HasSuffix(m[1], "_id") {
+			s.Format = "uuid"
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: m[1], In: "path", Required: true, Schema: s})
+	}
+	for _, p := range rt.Query {
+		typ := p.Type
+		if typ == "" {
+			typ = "string"
+		}
+		op.Parameters = append(op.Parameters, Parameter{
+			Name: p.Name, In: "query", Description: p.Description, Required: p.Required,
+			Schema: &Schema{Type: typ, Enum: p.Enum},
+		})
+	}
+
+	if rt.Request != nil {
+		s, err := reg.schemaOf(reflect.TypeOf(rt.Request))
+		if err != nil {
+			return nil, err
+		}
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: s}}}
+	}
+
+	status := rt.Status
+	switch {
+	case status == 0 && rt.Response == nil:
+		status = http.StatusNoContent
+	case status == 0:
+		status = http.StatusOK
+	}
+	resp := Response{Description: http.StatusText(status)}
+	if rt.Response != nil {
+		s, err := reg.responseSchema(rt.Response)
+		if err != nil {
+			return nil, err
+		}
+		resp.Content = map[string]MediaType{"application/json": {Schema: s}}
+	}
+	op.Responses[fmt.Sprint(status)] = resp
+
+	if rt.Auth {
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+		op.Responses["401"] = Response{Description: "Unauthorized", Content: errContent}
+	}
+	if rt.Permission != "" {
+		op.Responses["403"] = Response{Description: "Forbidden", Content: errContent}
+	}
+	if rt.Limited {
+		op.Responses["429"] = Response{Description: "Too Many Requests", Content: errContent}
+	}
+	return op, nil
+}
+
+func (reg *registry) responseSchema(v any) (*Schema, error) {
+	alts, ok := v.(OneOf)
+	if !ok {
+		return reg.schemaOf(reflect.TypeOf(v))
+	}
+	s := &Schema{}
+	for _, a := range alts {
+		as, err := reg.schemaOf(reflect.TypeOf(a))
+		if err != nil {
+			return nil, err
+		}
+		s.OneOf = append(s.OneOf, as)
+	}
+	return s, nil
+}
+
+// JSON — документ с отступами (для файла спецификации в репозитории).
+func (d *Document) JSON() ([]byte, error) {
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Handler — GET /openapi.json
+func Handler(doc *Document) http.Handler {
+	body, err := doc.JSON()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
+
+// Operations — пары "METHOD path" документа в отсортированном порядке (для сверки с роутером).
+func (d *Document) Operations() []string {
+	var out []string
+	for path, item := range d.Paths {
+		for method := range item {
+			out = append(out, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
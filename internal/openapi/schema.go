@@ -0,0 +1,247 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Schema — JSON Schema (диалект OpenAPI 3.1), только используемые ключевые слова.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// registry — именованные схемы components/schemas. Имя — имя Go-типа с заглавной буквы;
+// при совпадении имён у разных типов второй получает префикс пакета (ListingsCreateReq).
+type registry struct {
+	defs   map[string]*Schema
+	names  map[reflect.Type]string
+	byName map[string]reflect.Type
+}
+
+func newRegistry() *registry {
+	return &registry{defs: map[string]*Schema{}, names: map[reflect.Type]string{}, byName: map[string]reflect.Type{}}
+}
+
+func (reg *registry) schemaOf(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}, nil
+	case rawJSONType:
+		return &Schema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := reg.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s := &Schema{Type: "array", Items: items}
+		if t.Kind() == reflect.Array {
+			n := t.Len()
+			s.MinItems, s.MaxItems = &n, &n
+		}
+		return s, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map key of %s must be a string", t)
+		}
+		values, err := reg.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return reg.object(t)
+		}
+		return reg.ref(t)
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// ref — именованная структура: схема в components, на месте использования — $ref.
+func (reg *registry) ref(t reflect.Type) (*Schema, error) {
+	if name, ok := reg.names[t]; ok {
+		return &Schema{Ref: "#/components/schemas/" + name}, nil
+	}
+	name := exported(t.Name())
+	if other, taken := reg.byName[name]; taken && other != t {
+		name = exported(path.Base(t.PkgPath())) + name
+		if other, taken := reg.byName[name]; taken && other != t {
+			return nil, fmt.Errorf("schema name %s is used by %s and %s", name, other, t)
+		}
+	}
+	// имя регистрируем до обхода полей: типы бывают рекурсивными (Category.Children)
+	reg.names[t], reg.byName[name] = name, t
+	s, err := reg.object(t)
+	if err != nil {
+		return nil, err
+	}
+	reg.defs[name] = s
+	return &Schema{Ref: "#/components/schemas/" + name}, nil
+}
+
+func (reg *registry) object(t reflect.Type) (*Schema, error) {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	if err := reg.fields(t, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// fields — свойства структуры по правилам encoding/json (встроенные структуры без имени — на тот же уровень).
+func (reg *registry) fields(t reflect.Type, s *Schema) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := reg.fields(ft, s); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		fs, err := reg.schemaOf(sf.Type)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t, sf.Name, err)
+		}
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			target := fs
+			if fs.Ref != "" {
+				target = &Schema{} // рядом с $ref ограничения не пишем, важна только обязательность
+			}
+			if applyRules(target, tag) {
+				s.Required = append(s.Required, name)
+			}
+		}
+		s.Properties[name] = fs
+	}
+	return nil
+}
+
+// applyRules — ограничения из тега validate (те же правила, что в shared.Validate).
+// Возвращает true, если поле обязательное.
+func applyRules(s *Schema, tag string) (required bool) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		n, _ := strconv.ParseFloat(param, 64)
+		i := int(n)
+		switch name {
+		case "required":
+			required = true
+		case "min", "max", "len":
+			switch s.Type {
+			case "string":
+				if name != "max" {
+					s.MinLength = &i
+				}
+				if name != "min" {
+					s.MaxLength = &i
+				}
+			case "array":
+				if name == "min" {
+					s.MinItems = &i
+				} else {
+					s.MaxItems = &i
+				}
+			case "object":
+				if name == "max" {
+					s.MaxProperties = &i
+				}
+			default:
+				if name == "min" {
+					s.Minimum = &n
+				} else {
+					s.Maximum = &n
+				}
+			}
+		case "gt":
+			s.ExclusiveMinimum = &n
+		case "enum":
+			s.Enum = strings.Split(param, "|")
+		case "uuid":
+			s.Format = "uuid"
+		case "url":
+			s.Format = "uri"
+		}
+	}
+	// required у строки — не пустая (shared.Validate не пропускает и строку из пробелов)
+	if required && s.Type == "string" && s.Format == "" && s.Enum == nil && s.MinLength == nil {
+		one := 1
+		s.MinLength = &one
+	}
+	return required
+}
+
+func exported(name string) string {
+	r := []rune(name)
+	if len(r) > 0 {
+		r[0] = unicode.ToUpper(r[0])
+	}
+	return string(r)
+}
@@ -0,0 +1,99 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor — токен повреждён, подделан или выдан для другой сортировки или выдачи.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor — позиция в keyset-выдаче: последний показанный элемент.
+// Для сортировки по цене дополнительно хранится ключ сортировки.
+// Scope — выдача, для которой курсор выдан ("chat:<session>", "thread:<id>", ...): подпись
+// покрывает и его, поэтому курсор одной выдачи не принимается другой.
+type Cursor struct {
+	Scope     string    `json:"sc,omitempty"`
+	Sort      string    `json:"s,omitempty"`
+	Key       *float64  `json:"k,omitempty"`
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// Codec — подписывает курсоры HMAC-SHA256, чтобы клиент не мог их подменить.
+// Токен: base64url(json) + "." + base64url(mac).
+type Codec struct {
+	key []byte
+}
+
+// NewCodec — при пустом секрете генерируется случайный ключ:
+// курсоры перестанут быть валидными после рестарта, но offset продолжит работать.
+func NewCodec(secret string) *Codec {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return &Codec{key: key}
+}
+
+func (c *Codec) Encode(cur Cursor) string {
+	payload, _ := json.Marshal(cur)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(c.sign(payload))
+}
+
+// Decode — курсор каталога: проверяет подпись и, если sort не пуст, что курсор выдан для этой
+// же сортировки. Курсоры выдач с Scope (чат, переписка, отзывы) здесь не принимаются.
+func (c *Codec) Decode(token, sort string) (Cursor, error) {
+	cur, err := c.decode(token)
+	if err != nil || cur.Scope != "" || (sort != "" && cur.Sort != sort) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return cur, nil
+}
+
+// DecodeScope — курсор выдачи scope; выданный для другой выдачи (или каталога) — ErrInvalidCursor.
+func (c *Codec) DecodeScope(token, scope string) (Cursor, error) {
+	cur, err := c.decode(token)
+	if err != nil || scope == "" || cur.Scope != scope {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return cur, nil
+}
+
+// decode — подпись и разбор, без проверки выдачи.
+func (c *Codec) decode(token string) (Cursor, error) {
+	var cur Cursor
+	enc := base64.RawURLEncoding
+	body, mac, ok := strings.Cut(token, ".")
+	if !ok {
+		return cur, ErrInvalidCursor
+	}
+	payload, err := enc.DecodeString(body)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+	sig, err := enc.DecodeString(mac)
+	if err != nil || !hmac.Equal(sig, c.sign(payload)) {
+		return cur, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &cur); err != nil {
+		return cur, ErrInvalidCursor
+	}
+	return cur, nil
+}
+
+func (c *Codec) sign(payload []byte) []byte {
+	m := hmac.New(sha256.New, c.key)
+	m.Write(payload)
+	return m.Sum(nil)[:16]
+}
@@ -0,0 +1,40 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCodecScope(t *testing.T) {
+	c := NewCodec("secret")
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	catalog := c.Encode(Cursor{Sort: "newest", CreatedAt: at, ID: uuid.New()})
+	thread := c.Encode(Cursor{Scope: "thread:a", CreatedAt: at, ID: uuid.New()})
+
+	for _, tc := range []struct {
+		name, token, sort, scope string
+		ok                       bool
+	}{
+		{"catalog", catalog, "newest", "", true},
+		{"catalog, any sort", catalog, "", "", true},
+		{"catalog, other sort", catalog, "price_asc", "", false},
+		{"catalog cursor in a scope", catalog, "", "thread:a", false},
+		{"scoped", thread, "", "thread:a", true},
+		{"other scope", thread, "", "thread:b", false},
+		{"scoped cursor in catalog", thread, "", "", false},
+		{"other key", NewCodec("other").Encode(Cursor{Scope: "thread:a"}), "", "thread:a", false},
+		{"garbage", "garbage", "", "thread:a", false},
+	} {
+		var err error
+		if tc.scope == "" {
+			_, err = c.Decode(tc.token, tc.sort)
+		} else {
+			_, err = c.DecodeScope(tc.token, tc.scope)
+		}
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tc.name, err, tc.ok)
+		}
+	}
+}
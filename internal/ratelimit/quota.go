@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btynybekov/marketplace/internal/ai"
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/logging"
+	"github.com/btynybekov/marketplace/internal/middleware"
+)
+
+// UsageStore — суточный расход LLM-токенов по субъекту ("user:<uuid>" или "ip:<addr>").
+type UsageStore interface {
+	AddUsage(ctx context.Context, subject string, day time.Time, tokens int) error
+	Usage(ctx context.Context, subject string, day time.Time) (int, error)
+}
+
+type subjectKey struct{}
+
+// WithSubject — кладёт субъекта квоты в контекст (Quota.Middleware, WebSocket-шлюз).
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// Quota — суточные лимиты LLM-токенов: отдельно для пользователей и для анонимов (по IP).
+// Расход поступает из ai.Client через хук OnUsage (см. Record).
+type Quota struct {
+	store      UsageStore
+	userDaily  int
+	anonDaily  int
+	trustProxy bool
+}
+
+// NewQuota — лимит 0 отключает проверку для соответствующей группы.
+func NewQuota(store UsageStore, userDaily, anonDaily int, trustProxy bool) *Quota {
+	return &Quota{store: store, userDaily: userDaily, anonDaily: anonDaily, trustProxy: trustProxy}
+}
+
+// Subject — кому засчитывается расход запроса.
+func (q *Quota) Subject(r *http.Request) string {
+	if uid, ok := middleware.UserIDFromContext(r.Context()); ok {
+		return "user:" + uid.String()
+	}
+	return "ip:" + ClientIP(r, q.trustProxy)
+}
+
+// Exceeded — исчерпана ли квота субъекта на сегодня; retryAfter — до полуночи UTC.
+func (q *Quota) Exceeded(ctx context.Context, subject string) (bool, time.Duration, error) {
+	limit := q.userDaily
+	if strings.HasPrefix(subject, "ip:") {
+		limit = q.anonDaily
+	}
+	if limit <= 0 {
+		return false, 0, nil
+	}
+	now := time.Now().UTC()
+	used, err := q.store.Usage(ctx, subject, now)
+	if err != nil || used < limit {
+		return false, 0, err
+	}
+	midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	return true, midnight.Sub(now), nil
+}
+
+// Middleware — 429, если квота исчерпана; иначе субъект кладётся в контекст для Record.
+func (q *Quota) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject := q.Subject(r)
+		over, retry, err := q.Exceeded(r.Context(), subject)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "quota check failed", slog.String("subject", subject), logging.Err(err))
+		}
+		if over {
+			TooManyRequests(w, r, retry, apperror.QuotaExceeded("daily AI quota exceeded"))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithSubject(r.Context(), subject)))
+	})
+}
+
+// Record — ai.UsageFunc: засчитывает расход субъекту из контекста
+// (или пользователю, если запрос прошёл мимо Middleware). Без субъекта — не учитывается.
+func (q *Quota) Record(ctx context.Context, model string, u ai.Usage) {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	if subject == "" {
+		uid, ok := middleware.UserIDFromContext(ctx)
+		if !ok {
+			return
+		}
+		subject = "user:" + uid.String()
+	}
+	tokens := u.TotalTokens
+	if tokens == 0 {
+		tokens = u.PromptTokens + u.CompletionTokens
+	}
+	if tokens <= 0 {
+		return
+	}
+	// учёт не должен зависеть от отмены запроса клиентом
+	ctx = context.WithoutCancel(ctx)
+	if err := q.store.AddUsage(ctx, subject, time.Now().UTC(), tokens); err != nil {
+		slog.ErrorContext(ctx, "quota record failed", slog.String("subject", subject), slog.String("model", model), logging.Err(err))
+	}
+}
+
+// ===== In-memory store =====
+
+// MemoryUsageStore — расход в памяти процесса (один инстанс, тесты).
+// Хранятся только счётчики текущих суток.
+type MemoryUsageStore struct {
+	mu   sync.Mutex
+	day  string
+	used map[string]int
+}
+
+func NewMemoryUsageStore() *MemoryUsageStore {
+	return &MemoryUsageStore{used: map[string]int{}}
+}
+
+func (s *MemoryUsageStore) AddUsage(_ context.Context, subject string, day time.Time, tokens int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d := day.UTC().Format(time.DateOnly); d != s.day {
+		s.day, s.used = d, map[string]int{}
+	}
+	s.used[subject] += tokens
+	return nil
+}
+
+func (s *MemoryUsageStore) Usage(_ context.Context, subject string, day time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if day.UTC().Format(time.DateOnly) != s.day {
+		return 0, nil
+	}
+	return s.used[subject], nil
+}
@@ -0,0 +1,263 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btynybekov/marketplace/internal/apperror"
+	"github.com/btynybekov/marketplace/internal/handlers/shared"
+	"github.com/btynybekov/marketplace/internal/logging"
+	"github.com/btynybekov/marketplace/internal/middleware"
+)
+
+// Rule — token bucket: один токен каждые Every, ёмкость Burst.
+type Rule struct {
+	Every time.Duration
+	Burst int
+}
+
+// PerMinute — n запросов в минуту с всплеском до burst.
+func PerMinute(n, burst int) Rule {
+	if n <= 0 {
+		n = 1
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return Rule{Every: time.Minute / time.Duration(n), Burst: burst}
+}
+
+// Store — хранилище бакетов. Бакет хранится в GCRA-форме (одно время на ключ):
+// это тот же token bucket, но атомарно обновляется и в памяти, и в Postgres.
+// Take списывает по токену из каждого бакета keys — из всех или ни из одного: если хоть один
+// исчерпан, ничего не списывается, а retryAfter — через сколько появятся токены во всех.
+type Store interface {
+	Take(ctx context.Context, keys []string, now time.Time, every, window time.Duration) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// Limiter — проверка набора ключей (IP, пользователь, сессия) по одному правилу.
+type Limiter struct {
+	store Store
+	rule  Rule
+}
+
+func NewLimiter(store Store, rule Rule) *Limiter {
+	return &Limiter{store: store, rule: rule}
+}
+
+// Allow — запрос проходит, только если есть токен в каждом бакете; отклонённый запрос
+// токенов не тратит. retryAfter — максимальное ожидание среди исчерпанных бакетов.
+func (l *Limiter) Allow(ctx context.Context, keys ...string) (bool, time.Duration, error) {
+	if len(keys) == 0 {
+		return true, 0, nil
+	}
+	window := l.rule.Every * time.Duration(l.rule.Burst)
+	return l.store.Take(ctx, keys, time.Now(), l.rule.Every, window)
+}
+
+// KeyFunc — извлекает ключ бакета из запроса; ok=false — ключ неприменим.
+type KeyFunc func(r *http.Request) (key string, ok bool)
+
+// ByIP — ключ по адресу клиента. trustProxy — брать адрес из X-Forwarded-For, см. ClientIP
+// (только за доверенным reverse proxy, иначе заголовок подделывается).
+func ByIP(trustProxy bool) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		return "ip:" + ClientIP(r, trustProxy), true
+	}
+}
+
+// ByUser — ключ по авторизованному пользователю.
+func ByUser(r *http.Request) (string, bool) {
+	uid, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return "", false
+	}
+	return "user:" + uid.String(), true
+}
+
+// BySession — ключ по session_id чата (query или JSON-тело, тело при этом сохраняется).
+// Тело больше maxPeekBody ключа не даёт: такой запрос отклоняет Middleware.
+func BySession(r *http.Request) (string, bool) {
+	sid := r.URL.Query().Get("session_id")
+	if sid == "" {
+		sid = peekJSONField(r, "session_id")
+	}
+	if sid == "" {
+		return "", false
+	}
+	return "session:" + sid, true
+}
+
+// Middleware — 429 с Retry-After, если исчерпан любой из бакетов.
+// Ошибка хранилища не блокирует запрос: лимитер не должен ронять сервис.
+func (l *Limiter) Middleware(keys ...KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			list := make([]string, 0, len(keys))
+			for _, kf := range keys {
+				if k, ok := kf(r); ok {
+					list = append(list, k)
+				}
+			}
+			if b, ok := r.Body.(failedBody); ok && isTooLarge(b.err) {
+				shared.Error(w, r, apperror.TooLarge("request body must not exceed %d bytes", maxPeekBody))
+				return
+			}
+			ok, retry, err := l.Allow(r.Context(), list...)
+			if err == nil && !ok {
+				TooManyRequests(w, r, retry, apperror.TooManyRequests("rate limit exceeded"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TooManyRequests — 429 (через shared.Error) с Retry-After в секундах.
+func TooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, e *apperror.Error) {
+	secs := int(math.Ceil(retryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	shared.Error(w, r, e)
+}
+
+// ClientIP — адрес клиента без порта. За прокси (trustProxy) — последний адрес X-Forwarded-For:
+// его дописал наш прокси, всё левее прислал клиент и подделывает как хочет.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+			last := xff[len(xff)-1]
+			if i := strings.LastIndexByte(last, ','); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// maxPeekBody — сколько тела читает peekJSONField; совпадает с лимитом shared.DecodeJSON.
+const maxPeekBody = 1 << 20
+
+// peekJSONField — строковое поле из JSON-тела; тело возвращается на место для хендлера.
+// Если тело не прочиталось целиком (больше maxPeekBody или обрыв), хендлер вместо
+// обрезанной копии получает failedBody с той же ошибкой.
+func peekJSONField(r *http.Request, field string) string {
+	if r.Body == nil || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return ""
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxPeekBody))
+	if err != nil {
+		r.Body = failedBody{err}
+		return ""
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	var m map[string]any
+	if json.Unmarshal(body, &m) != nil {
+		return ""
+	}
+	v, _ := m[field].(string)
+	return v
+}
+
+// failedBody — тело, которое не удалось прочитать: каждое чтение возвращает err.
+type failedBody struct{ err error }
+
+func (b failedBody) Read([]byte) (int, error) { return 0, b.err }
+func (b failedBody) Close() error             { return nil }
+
+func isTooLarge(err error) bool {
+	var tooBig *http.MaxBytesError
+	return errors.As(err, &tooBig)
+}
+
+// ===== In-memory store =====
+
+// MemoryStore — бакеты в памяти процесса (один инстанс, тесты).
+type MemoryStore struct {
+	mu   sync.Mutex
+	tat  map[string]time.Time // theoretical arrival time
+	ops  int
+	gcAt int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tat: map[string]time.Time{}, gcAt: 10000}
+}
+
+func (s *MemoryStore) Take(_ context.Context, keys []string, now time.Time, every, window time.Duration) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ops++
+	if s.ops >= s.gcAt {
+		// бакеты с tat в прошлом полные — их можно забыть
+		for k, t := range s.tat {
+			if t.Before(now) {
+				delete(s.tat, k)
+			}
+		}
+		s.ops = 0
+	}
+
+	// сначала проверяются все бакеты, списание — только если проходят все
+	next := make(map[string]time.Time, len(keys))
+	var wait time.Duration
+	for _, key := range keys {
+		tat := s.tat[key]
+		if tat.Before(now) {
+			tat = now
+		}
+		next[key] = tat.Add(every)
+		if over := next[key].Sub(now) - window; over > wait {
+			wait = over
+		}
+	}
+	if wait > 0 {
+		return false, wait, nil
+	}
+	for key, tat := range next {
+		s.tat[key] = tat
+	}
+	return true, 0, nil
+}
+
+// Cleaner — хранилище, умеющее удалять простаивающие бакеты (Postgres).
+type Cleaner interface {
+	DeleteIdle(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RunCleanup — периодически удаляет бакеты, которые уже полностью восстановились.
+func RunCleanup(ctx context.Context, c Cleaner, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if _, err := c.DeleteIdle(ctx, time.Now()); err != nil {
+				slog.ErrorContext(ctx, "rate limit cleanup failed", logging.Err(err))
+			}
+		}
+	}
+}
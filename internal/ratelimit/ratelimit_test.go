@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAllowDebitsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(NewMemoryStore(), Rule{Every: time.Minute, Burst: 1})
+
+	if ok, _, _ := l.Allow(ctx, "ip:1"); !ok {
+		t.Fatal("first request to ip:1 rejected")
+	}
+	// ip:1 исчерпан: запрос отклонён и токен user:1 не тратит
+	ok, retry, err := l.Allow(ctx, "user:1", "ip:1")
+	if err != nil || ok || retry <= 0 {
+		t.Fatalf("Allow(user:1, ip:1) = %v, %v, %v; want rejected with Retry-After", ok, retry, err)
+	}
+	if ok, _, _ := l.Allow(ctx, "user:1"); !ok {
+		t.Fatal("user:1 was debited by a rejected request")
+	}
+	if ok, _, _ := l.Allow(ctx); !ok {
+		t.Fatal("request without keys rejected")
+	}
+}
+
+func TestMiddlewareRejectsOversizedBody(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), PerMinute(1000, 100))
+	var got string
+	h := l.Middleware(BySession)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+	}))
+
+	for _, tc := range []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"small", `{"session_id":"s1","text":"hi"}`, http.StatusOK},
+		{"exactly the limit", `{"session_id":"s1","text":"` + strings.Repeat("a", maxPeekBody-29) + `"}`, http.StatusOK},
+		{"over the limit", `{"session_id":"s1","text":"` + strings.Repeat("a", maxPeekBody) + `"}`, http.StatusRequestEntityTooLarge},
+	} {
+		got = ""
+		req := httptest.NewRequest(http.MethodPost, "/chat/ajax", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.status)
+		}
+		if tc.status == http.StatusOK && got != tc.body {
+			t.Errorf("%s: handler got %d bytes of %d", tc.name, len(got), len(tc.body))
+		}
+	}
+}
+
+func TestClientIPIgnoresSpoofedForwardedFor(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		xff   []string
+		trust bool
+		want  string
+	}{
+		{"no proxy", []string{"1.1.1.1"}, false, "10.0.0.9"},
+		{"proxy, no header", nil, true, "10.0.0.9"},
+		{"proxy appended the peer", []string{"203.0.113.7"}, true, "203.0.113.7"},
+		{"spoofed leading entry", []string{"1.1.1.1, 203.0.113.7"}, true, "203.0.113.7"},
+		{"another spoofed entry", []string{"2.2.2.2,3.3.3.3 , 203.0.113.7"}, true, "203.0.113.7"},
+		{"spoofed separate header", []string{"1.1.1.1", "203.0.113.7"}, true, "203.0.113.7"},
+		{"empty last entry", []string{"1.1.1.1, "}, true, "10.0.0.9"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.9:51234"
+		for _, v := range tc.xff {
+			req.Header.Add("X-Forwarded-For", v)
+		}
+		if got := ClientIP(req, tc.trust); got != tc.want {
+			t.Errorf("%s: ClientIP = %q, want %q", tc.name, got, tc.want)
+		}
+		if k, _ := ByIP(tc.trust)(req); k != "ip:"+tc.want {
+			t.Errorf("%s: ByIP = %q, want %q", tc.name, k, "ip:"+tc.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/btynybekov/marketplace/internal/logging"
+)
+
+// LocalBroker — брокер в памяти процесса: для одного инстанса и тестов.
+type LocalBroker struct {
+	mu   sync.RWMutex
+	subs []func([]byte)
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{}
+}
+
+func (b *LocalBroker) Publish(_ context.Context, msg []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, deliver := range b.subs {
+		deliver(msg)
+	}
+	return nil
+}
+
+func (b *LocalBroker) Listen(ctx context.Context, deliver func([]byte)) error {
+	b.mu.Lock()
+	b.subs = append(b.subs, deliver)
+	b.mu.Unlock()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// pgNotifyLimit — payload NOTIFY в Postgres ограничен 8000 байтами.
+const pgNotifyLimit = 8000
+
+// PGBroker — fan-out между инстансами через Postgres LISTEN/NOTIFY.
+// Для LISTEN держится отдельное соединение из пула; при обрыве — переподключение.
+type PGBroker struct {
+	pool    *pgxpool.Pool
+	channel string
+}
+
+func NewPGBroker(pool *pgxpool.Pool, channel string) *PGBroker {
+	return &PGBroker{pool: pool, channel: channel}
+}
+
+func (b *PGBroker) Publish(ctx context.Context, msg []byte) error {
+	if len(msg) > pgNotifyLimit {
+		return fmt.Errorf("%w: %d bytes", ErrPayloadTooLarge, len(msg))
+	}
+	_, err := b.pool.Exec(ctx, `SELECT pg_notify($1, $2)`, b.channel, string(msg))
+	return err
+}
+
+func (b *PGBroker) Listen(ctx context.Context, deliver func([]byte)) error {
+	backoff := time.Second
+	for {
+		err := b.listen(ctx, deliver)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slog.WarnContext(ctx, "realtime listen failed", slog.String("channel", b.channel), slog.Duration("retry_in", backoff), logging.Err(err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func (b *PGBroker) listen(ctx context.Context, deliver func([]byte)) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// соединение в режиме LISTEN забираем из пула насовсем
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return err
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		deliver([]byte(n.Payload))
+	}
+}
@@ -0,0 +1,125 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/btynybekov/marketplace/internal/logging"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxFrameSize   = 8 << 10 // входящие кадры маленькие: typing, текст сообщения
+	sendBufferSize = 64
+)
+
+// Inbound — кадр от клиента: {"type": "...", "data": {...}}.
+type Inbound struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// InboundFunc — обработчик входящих кадров; вызывается последовательно в горутине чтения.
+// reply отправляет событие только в это подключение (ошибки, подтверждения).
+type InboundFunc func(ctx context.Context, userID uuid.UUID, in Inbound, reply func(Event))
+
+// client — одно WebSocket-подключение пользователя.
+type client struct {
+	userID uuid.UUID
+	conn   *websocket.Conn
+	send   chan []byte
+	done   chan struct{}
+	cancel context.CancelFunc // закрывает подключение (Hub.Shutdown)
+}
+
+// enqueue — неблокирующая отправка: медленный клиент теряет события, а не тормозит хаб.
+func (c *client) enqueue(msg []byte) {
+	select {
+	case c.send <- msg:
+	case <-c.done:
+	default:
+		slog.Warn("realtime event dropped for slow client", slog.String("user_id", c.userID.String()))
+	}
+}
+
+func (c *client) reply(ev Event) {
+	msg, err := json.Marshal(ev)
+	if err != nil {
+		slog.Error("realtime marshal failed", slog.String("type", ev.Type), logging.Err(err))
+		return
+	}
+	c.enqueue(msg)
+}
+
+// Serve — обслуживает подключение до его закрытия или отмены ctx.
+// Входящие кадры передаются в onInbound (может быть nil).
+func (h *Hub) Serve(ctx context.Context, conn *websocket.Conn, userID uuid.UUID, onInbound InboundFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c := &client{
+		userID: userID,
+		conn:   conn,
+		send:   make(chan []byte, sendBufferSize),
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+	h.register(c)
+	defer h.unregister(c)
+
+	go c.writePump(ctx)
+	c.readPump(ctx, onInbound)
+	close(c.done)
+}
+
+func (c *client) readPump(ctx context.Context, onInbound InboundFunc) {
+	c.conn.SetReadLimit(maxFrameSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		var in Inbound
+		if err := c.conn.ReadJSON(&in); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				slog.DebugContext(ctx, "realtime read failed", slog.String("user_id", c.userID.String()), logging.Err(err))
+			}
+			return
+		}
+		if onInbound != nil {
+			onInbound(ctx, c.userID, in, c.reply)
+		}
+	}
+}
+
+func (c *client) writePump(ctx context.Context) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			_ = c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(writeWait))
+			return
+		case msg := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
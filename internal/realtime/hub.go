@@ -0,0 +1,146 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/logging"
+)
+
+// Типы событий, которые сервер отправляет клиентам.
+const (
+	EventAssistantReply = "assistant.reply" // ответ AI-ассистента в чате
+	EventDirectMessage  = "dm.message"      // новое сообщение в переписке по объявлению
+	EventDirectRead     = "dm.read"         // собеседник прочитал сообщения
+	EventTyping         = "dm.typing"       // собеседник печатает
+	EventNotification   = "notification"    // уведомление из outbox (сохранённые поиски и т.п.)
+	EventError          = "error"           // ошибка обработки входящего кадра
+)
+
+// Event — кадр, который получает клиент: {"type": "...", "data": {...}}.
+type Event struct {
+	Type string `json:"type"`
+	Data any    `json:"data,omitempty"`
+}
+
+// Publisher — отправка события всем подключениям пользователя (на всех инстансах).
+type Publisher interface {
+	Publish(ctx context.Context, userID uuid.UUID, ev Event)
+}
+
+// ErrPayloadTooLarge — брокер не может передать сообщение такого размера.
+var ErrPayloadTooLarge = errors.New("realtime: payload too large for broker")
+
+// Broker — транспорт между инстансами приложения.
+// Publish рассылает сообщение всем инстансам (включая текущий),
+// Listen блокируется до отмены ctx и передаёт полученные сообщения в deliver.
+type Broker interface {
+	Publish(ctx context.Context, msg []byte) error
+	Listen(ctx context.Context, deliver func(msg []byte)) error
+}
+
+// envelope — сообщение между инстансами: кому и что доставить.
+type envelope struct {
+	UserID uuid.UUID       `json:"u"`
+	Event  json.RawMessage `json:"e"`
+}
+
+// Hub — реестр WebSocket-подключений текущего инстанса по пользователям.
+// События идут через Broker, поэтому доходят до пользователя на любом инстансе.
+type Hub struct {
+	broker Broker
+
+	mu      sync.RWMutex
+	clients map[uuid.UUID]map[*client]struct{}
+}
+
+func NewHub(broker Broker) *Hub {
+	return &Hub{broker: broker, clients: map[uuid.UUID]map[*client]struct{}{}}
+}
+
+// Run — слушает брокер до отмены ctx (запускать в отдельной горутине).
+func (h *Hub) Run(ctx context.Context) {
+	if err := h.broker.Listen(ctx, h.deliver); err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "realtime broker stopped", logging.Err(err))
+	}
+}
+
+// Publish — best effort: ошибки логируются, вызывающий код не должен падать из-за realtime.
+// Если брокер не принимает сообщение (слишком большое), оно доставляется хотя бы локально.
+func (h *Hub) Publish(ctx context.Context, userID uuid.UUID, ev Event) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		slog.ErrorContext(ctx, "realtime marshal failed", slog.String("type", ev.Type), logging.Err(err))
+		return
+	}
+	msg, err := json.Marshal(envelope{UserID: userID, Event: payload})
+	if err != nil {
+		slog.ErrorContext(ctx, "realtime marshal envelope failed", logging.Err(err))
+		return
+	}
+	if err := h.broker.Publish(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "realtime publish failed", slog.String("type", ev.Type), logging.Err(err))
+		if errors.Is(err, ErrPayloadTooLarge) {
+			h.deliver(msg)
+		}
+	}
+}
+
+// Online — есть ли у пользователя подключения на этом инстансе.
+func (h *Hub) Online(userID uuid.UUID) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID]) > 0
+}
+
+// Shutdown — закрывает все подключения инстанса (при остановке сервера:
+// http.Server.Shutdown не трогает hijacked-соединения).
+func (h *Hub) Shutdown() {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, set := range h.clients {
+		for c := range set {
+			c.cancel()
+		}
+	}
+}
+
+func (h *Hub) deliver(msg []byte) {
+	var env envelope
+	if err := json.Unmarshal(msg, &env); err != nil {
+		slog.Warn("realtime bad envelope", logging.Err(err))
+		return
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range h.clients[env.UserID] {
+		c.enqueue(env.Event)
+	}
+}
+
+func (h *Hub) register(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	set := h.clients[c.userID]
+	if set == nil {
+		set = map[*client]struct{}{}
+		h.clients[c.userID] = set
+	}
+	set[c] = struct{}{}
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if set := h.clients[c.userID]; set != nil {
+		delete(set, c)
+		if len(set) == 0 {
+			delete(h.clients, c.userID)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// testClient — подключение без сокета: события копятся в send.
+func testClient(h *Hub, userID uuid.UUID, buf int) *client {
+	c := &client{userID: userID, send: make(chan []byte, buf), done: make(chan struct{})}
+	h.register(c)
+	return c
+}
+
+// received — типы событий, уже лежащих в очереди клиента.
+func received(t *testing.T, c *client) []string {
+	t.Helper()
+	var types []string
+	for {
+		select {
+		case msg := <-c.send:
+			var ev Event
+			if err := json.Unmarshal(msg, &ev); err != nil {
+				t.Fatalf("bad event %s: %v", msg, err)
+			}
+			types = append(types, ev.Type)
+		default:
+			return types
+		}
+	}
+}
+
+// runLocal — хаб на LocalBroker, который уже слушает (Publish до подписки теряется).
+func runLocal(t *testing.T) *Hub {
+	t.Helper()
+	b := NewLocalBroker()
+	h := NewHub(b)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go h.Run(ctx)
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		b.mu.RLock()
+		n := len(b.subs)
+		b.mu.RUnlock()
+		if n > 0 {
+			return h
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("hub does not listen the broker")
+		}
+	}
+}
+
+func TestHubFanOut(t *testing.T) {
+	h := runLocal(t)
+	alice, bob := uuid.New(), uuid.New()
+	tab1, tab2, other := testClient(h, alice, 4), testClient(h, alice, 4), testClient(h, bob, 4)
+
+	h.Publish(context.Background(), alice, Event{Type: EventNotification, Data: map[string]any{"n": 1}})
+
+	for name, c := range map[string]*client{"tab1": tab1, "tab2": tab2} {
+		if got := received(t, c); len(got) != 1 || got[0] != EventNotification {
+			t.Errorf("%s got %v, want one notification", name, got)
+		}
+	}
+	if got := received(t, other); len(got) != 0 {
+		t.Errorf("another user got %v", got)
+	}
+
+	h.unregister(tab1)
+	h.unregister(tab2)
+	if h.Online(alice) || !h.Online(bob) {
+		t.Errorf("Online after unregister: alice %v, bob %v", h.Online(alice), h.Online(bob))
+	}
+}
+
+func TestHubDropsEventsForSlowClient(t *testing.T) {
+	h := runLocal(t)
+	alice := uuid.New()
+	c := testClient(h, alice, 2)
+	for i := 0; i < 5; i++ {
+		h.Publish(context.Background(), alice, Event{Type: EventTyping}) // не блокируется на полной очереди
+	}
+	if got := received(t, c); len(got) != 2 {
+		t.Errorf("slow client got %d events, want the 2 that fit the buffer", len(got))
+	}
+}
+
+// failingBroker — Publish всегда с ошибкой err.
+type failingBroker struct{ err error }
+
+func (b failingBroker) Publish(context.Context, []byte) error { return b.err }
+func (b failingBroker) Listen(ctx context.Context, _ func([]byte)) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestHubPublishBrokerErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		err     error
+		locally bool
+	}{
+		{"payload too large — delivered locally", ErrPayloadTooLarge, true},
+		{"wrapped payload too large", errors.Join(errors.New("notify"), ErrPayloadTooLarge), true},
+		{"broker down — dropped", errors.New("connection refused"), false},
+	} {
+		h := NewHub(failingBroker{tc.err})
+		alice := uuid.New()
+		c := testClient(h, alice, 4)
+		h.Publish(context.Background(), alice, Event{Type: EventDirectMessage})
+		if got := len(received(t, c)); (got == 1) != tc.locally {
+			t.Errorf("%s: local client got %d events", tc.name, got)
+		}
+	}
+}
+
+func TestPGBrokerPayloadLimit(t *testing.T) {
+	b := NewPGBroker(nil, "realtime") // до пула дело не доходит
+	err := b.Publish(context.Background(), []byte(strings.Repeat("x", pgNotifyLimit+1)))
+	if !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("Publish over the NOTIFY limit = %v, want ErrPayloadTooLarge", err)
+	}
+}
+
+func TestServeWebSocket(t *testing.T) {
+	h := runLocal(t)
+	alice := uuid.New()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		h.Serve(r.Context(), conn, alice, func(_ context.Context, _ uuid.UUID, in Inbound, reply func(Event)) {
+			reply(Event{Type: "echo", Data: in.Type})
+		})
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if err := conn.WriteJSON(Inbound{Type: "ping"}); err != nil {
+		t.Fatal(err)
+	}
+	var ev Event
+	if err := conn.ReadJSON(&ev); err != nil || ev.Type != "echo" || ev.Data != "ping" {
+		t.Fatalf("reply = %+v, %v; want echo of ping", ev, err)
+	}
+	// reply уже пришёл — подключение зарегистрировано
+	h.Publish(context.Background(), alice, Event{Type: EventNotification})
+	if err := conn.ReadJSON(&ev); err != nil || ev.Type != EventNotification {
+		t.Fatalf("published event = %+v, %v", ev, err)
+	}
+
+	conn.Close()
+	for deadline := time.Now().Add(time.Second); h.Online(alice); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("client is still registered after the connection closed")
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// ===== BrandsRepository impl =====
+
+type brandsRepo struct{ db *pgxpool.Pool }
+
+const brandSelect = `
+	SELECT b.id, b.name, b.slug, b.created_at,
+	       COALESCE(array_agg(a.alias ORDER BY a.alias) FILTER (WHERE a.alias IS NOT NULL), '{}')
+	FROM brand b
+	LEFT JOIN brand_alias a ON a.brand_id = b.id
+`
+
+func (r *brandsRepo) List(ctx context.Context) ([]models.Brand, error) {
+	rows, err := r.db.Query(ctx, brandSelect+`
+		GROUP BY b.id
+		ORDER BY b.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Brand
+	for rows.Next() {
+		var b models.Brand
+		if err := rows.Scan(&b.ID, &b.Name, &b.Slug, &b.CreatedAt, &b.Aliases); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+func (r *brandsRepo) GetBySlug(ctx context.Context, slug string) (models.Brand, error) {
+	var b models.Brand
+	err := r.db.QueryRow(ctx, brandSelect+`
+		WHERE b.slug = $1
+		GROUP BY b.id
+	`, slug).Scan(&b.ID, &b.Name, &b.Slug, &b.CreatedAt, &b.Aliases)
+	return b, err
+}
+
+func (r *brandsRepo) Create(ctx context.Context, b models.Brand) (models.Brand, error) {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO brand (name, slug)
+			VALUES ($1, $2)
+			RETURNING id, created_at
+		`, b.Name, b.Slug).Scan(&b.ID, &b.CreatedAt); err != nil {
+			return err
+		}
+		return replaceAliases(ctx, tx, b.ID, b.Aliases)
+	})
+	return b, err
+}
+
+func (r *brandsRepo) Update(ctx context.Context, b models.Brand) (models.Brand, error) {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, `
+			UPDATE brand SET name = $2, slug = $3
+			WHERE id = $1
+			RETURNING created_at
+		`, b.ID, b.Name, b.Slug).Scan(&b.CreatedAt); err != nil {
+			return err
+		}
+		return replaceAliases(ctx, tx, b.ID, b.Aliases)
+	})
+	return b, err
+}
+
+func (r *brandsRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM brand WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// FacetsByCategorySlug — бренды с количеством активных объявлений в категории.
+func (r *brandsRepo) FacetsByCategorySlug(ctx context.Context, slug string) ([]models.BrandFacet, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT b.id, b.name, b.slug, b.created_at, count(*)
+		FROM listing l
+		JOIN category c ON c.id = l.category_id
+		JOIN product p ON p.id = l.product_id
+		JOIN brand b ON b.id = p.brand_id
+		WHERE c.slug = $1 AND l.status = 'active'
+		GROUP BY b.id
+		ORDER BY count(*) DESC, b.name
+	`, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.BrandFacet
+	for rows.Next() {
+		var f models.BrandFacet
+		if err := rows.Scan(&f.Brand.ID, &f.Brand.Name, &f.Brand.Slug, &f.Brand.CreatedAt, &f.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func replaceAliases(ctx context.Context, tx pgx.Tx, brandID uuid.UUID, aliases []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM brand_alias WHERE brand_id = $1`, brandID); err != nil {
+		return err
+	}
+	for _, a := range aliases {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO brand_alias (brand_id, alias) VALUES ($1, $2)
+		`, brandID, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
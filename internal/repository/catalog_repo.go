@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// ===== CatalogRepository impl =====
+
+type catalogRepo struct{ db *pgxpool.Pool }
+
+func (r *catalogRepo) GetProduct(ctx context.Context, id uuid.UUID) (models.ProductDetails, error) {
+	var (
+		d         models.ProductDetails
+		brandName *string
+		brandSlug *string
+	)
+	err := r.db.QueryRow(ctx, `
+		SELECT p.id, p.category_id, p.brand_id, COALESCE(p.model, ''), p.title, p.specs,
+		       c.slug, b.name, b.slug
+		FROM product p
+		JOIN category c ON c.id = p.category_id
+		LEFT JOIN brand b ON b.id = p.brand_id
+		WHERE p.id = $1 AND p.is_active
+	`, id).Scan(&d.ID, &d.CategoryID, &d.BrandID, &d.Model, &d.Title, &d.Specs,
+		&d.CategorySlug, &brandName, &brandSlug)
+	if err != nil {
+		return d, err
+	}
+	if d.BrandID != nil && brandName != nil {
+		d.Brand = &models.Brand{ID: *d.BrandID, Name: *brandName, Slug: *brandSlug}
+	}
+	return d, nil
+}
+
+// OfferStats — min/median/max цены по валютам и разбивка по состоянию для активных объявлений.
+func (r *catalogRepo) OfferStats(ctx context.Context, productID uuid.UUID) (models.OfferStats, error) {
+	st := models.OfferStats{Prices: []models.PriceStats{}, ByCondition: map[string]int{}}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT currency_code,
+		       min(price_amount)::float8,
+		       percentile_cont(0.5) WITHIN GROUP (ORDER BY price_amount)::float8,
+		       max(price_amount)::float8,
+		       count(*)
+		FROM listing
+		WHERE product_id = $1 AND status = 'active'
+		GROUP BY currency_code
+		ORDER BY currency_code
+	`, productID)
+	if err != nil {
+		return st, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ps models.PriceStats
+		if err := rows.Scan(&ps.CurrencyCode, &ps.Min, &ps.Median, &ps.Max, &ps.Count); err != nil {
+			return st, err
+		}
+		st.Count += ps.Count
+		st.Prices = append(st.Prices, ps)
+	}
+	if err := rows.Err(); err != nil {
+		return st, err
+	}
+
+	rows, err = r.db.Query(ctx, `
+		SELECT condition, count(*)
+		FROM listing
+		WHERE product_id = $1 AND status = 'active'
+		GROUP BY condition
+	`, productID)
+	if err != nil {
+		return st, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cond string
+			n    int
+		)
+		if err := rows.Scan(&cond, &n); err != nil {
+			return st, err
+		}
+		st.ByCondition[cond] = n
+	}
+	return st, rows.Err()
+}
+
+// FindCandidates — активные модели категории (и бренда, если он известен) для сопоставления.
+func (r *catalogRepo) FindCandidates(ctx context.Context, categoryID uuid.UUID, brandID *uuid.UUID) ([]models.CatalogProduct, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, category_id, brand_id, COALESCE(model, ''), title, specs
+		FROM product
+		WHERE category_id = $1
+		  AND is_active
+		  AND ($2::uuid IS NULL OR brand_id = $2)
+		ORDER BY updated_at DESC
+		LIMIT 500
+	`, categoryID, brandID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.CatalogProduct
+	for rows.Next() {
+		var p models.CatalogProduct
+		if err := rows.Scan(&p.ID, &p.CategoryID, &p.BrandID, &p.Model, &p.Title, &p.Specs); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -2,12 +2,17 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
 )
 
 // ===== RepositorySet (склейка) =====
@@ -18,10 +23,30 @@ type pgRepo struct {
 	productsRepo     ProductsRepository
 	productMediaRepo ProductMediaRepository
 	categoriesRepo   CategoriesRepository
+	brandsRepo       BrandsRepository
+	catalogRepo      CatalogRepository
+	listingsRepo     ListingsRepository
+	ratesRepo        ExchangeRatesRepository
 
+	chatSessionsRepo   ChatSessionsRepository
 	conversationsRepo  ConversationsRepository
 	messagesRepo       MessagesRepository
 	searchRequestsRepo SearchRequestsRepository
+
+	favoritesRepo     FavoritesRepository
+	savedSearchesRepo SavedSearchesRepository
+	notificationsRepo NotificationsRepository
+
+	threadsRepo ThreadsRepository
+	blocksRepo  BlocksRepository
+	reportsRepo ReportsRepository
+	rolesRepo   RolesRepository
+
+	usersRepo   UsersRepository
+	reviewsRepo ReviewsRepository
+
+	rateLimitsRepo RateLimitsRepository
+	aiUsageRepo    AIUsageRepository
 }
 
 func New(db *pgxpool.Pool) RepositorySet {
@@ -29,41 +54,158 @@ func New(db *pgxpool.Pool) RepositorySet {
 	r.productsRepo = &productsRepo{db: db}
 	r.productMediaRepo = &productMediaRepo{db: db}
 	r.categoriesRepo = &categoriesRepo{db: db}
+	r.brandsRepo = &brandsRepo{db: db}
+	r.catalogRepo = &catalogRepo{db: db}
+	r.listingsRepo = &listingsRepo{db: db}
+	r.ratesRepo = &exchangeRatesRepo{db: db}
+	r.chatSessionsRepo = &chatSessionsRepo{db: db}
 	r.conversationsRepo = &conversationsRepo{db: db}
 	r.messagesRepo = &messagesRepo{db: db}
 	r.searchRequestsRepo = &searchRequestsRepo{db: db}
+	r.favoritesRepo = &favoritesRepo{db: db}
+	r.savedSearchesRepo = &savedSearchesRepo{db: db}
+	r.notificationsRepo = &notificationsRepo{db: db}
+	r.threadsRepo = &threadsRepo{db: db}
+	r.blocksRepo = &blocksRepo{db: db}
+	r.reportsRepo = &reportsRepo{db: db}
+	r.rolesRepo = &rolesRepo{db: db}
+	r.usersRepo = &usersRepo{db: db}
+	r.reviewsRepo = &reviewsRepo{db: db}
+	r.rateLimitsRepo = &rateLimitsRepo{db: db}
+	r.aiUsageRepo = &aiUsageRepo{db: db}
 	return r
 }
 
 func (r *pgRepo) Products() ProductsRepository             { return r.productsRepo }
 func (r *pgRepo) ProductMedia() ProductMediaRepository     { return r.productMediaRepo }
 func (r *pgRepo) Categories() CategoriesRepository         { return r.categoriesRepo }
+func (r *pgRepo) Brands() BrandsRepository                 { return r.brandsRepo }
+func (r *pgRepo) Catalog() CatalogRepository               { return r.catalogRepo }
+func (r *pgRepo) Listings() ListingsRepository             { return r.listingsRepo }
+func (r *pgRepo) ExchangeRates() ExchangeRatesRepository   { return r.ratesRepo }
+func (r *pgRepo) ChatSessions() ChatSessionsRepository     { return r.chatSessionsRepo }
 func (r *pgRepo) Conversations() ConversationsRepository   { return r.conversationsRepo }
 func (r *pgRepo) Messages() MessagesRepository             { return r.messagesRepo }
 func (r *pgRepo) SearchRequests() SearchRequestsRepository { return r.searchRequestsRepo }
+func (r *pgRepo) Favorites() FavoritesRepository           { return r.favoritesRepo }
+func (r *pgRepo) SavedSearches() SavedSearchesRepository   { return r.savedSearchesRepo }
+func (r *pgRepo) Notifications() NotificationsRepository   { return r.notificationsRepo }
+func (r *pgRepo) Threads() ThreadsRepository               { return r.threadsRepo }
+func (r *pgRepo) Blocks() BlocksRepository                 { return r.blocksRepo }
+func (r *pgRepo) Reports() ReportsRepository               { return r.reportsRepo }
+func (r *pgRepo) Roles() RolesRepository                   { return r.rolesRepo }
+func (r *pgRepo) Users() UsersRepository                   { return r.usersRepo }
+func (r *pgRepo) Reviews() ReviewsRepository               { return r.reviewsRepo }
+func (r *pgRepo) RateLimits() RateLimitsRepository         { return r.rateLimitsRepo }
+func (r *pgRepo) AIUsage() AIUsageRepository               { return r.aiUsageRepo }
 
 // ===== ProductsRepository impl =====
 
 type productsRepo struct{ db *pgxpool.Pool }
 
 func (r *productsRepo) ListByCategorySlug(ctx context.Context, slug string, limit, offset int) ([]models.Product, error) {
-	rows, err := r.db.Query(ctx, `
-		SELECT p.id, p.title, p.price_amount, p.currency_code, p.attrs, p.filter_url
-		FROM product p
-		JOIN category c ON c.id = p.category_id
-		WHERE c.slug = $1
-		ORDER BY p.created_at DESC
-		LIMIT $2 OFFSET $3
-	`, slug, limit, offset)
+	return r.List(ctx, ProductFilter{CategorySlug: slug, Limit: limit, Offset: offset})
+}
+
+// List — активные объявления; бренд и модель берутся из привязанного product.
+func (r *productsRepo) List(ctx context.Context, f ProductFilter) ([]models.Product, error) {
+	where := []string{"l.status = 'active'"}
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.CategorySlug != "" {
+		add("c.slug = $%d", f.CategorySlug)
+	}
+	if f.BrandSlug != "" {
+		add("b.slug = $%d", f.BrandSlug)
+	}
+	if f.SellerID != nil {
+		add("l.seller_id = $%d", *f.SellerID)
+	}
+	if f.Query != "" {
+		add("l.title ILIKE '%%' || $%d || '%%'", f.Query)
+	}
+	if f.Condition != "" {
+		add("l.condition = $%d", f.Condition)
+	}
+	if f.CreatedAfter != nil {
+		add("l.created_at > $%d", *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		add("l.created_at <= $%d", *f.CreatedBefore)
+	}
+	if f.PriceMinKGS != nil {
+		add("l.price_kgs >= $%d", *f.PriceMinKGS)
+	}
+	if f.PriceMaxKGS != nil {
+		add("l.price_kgs <= $%d", *f.PriceMaxKGS)
+	}
+	if f.Limit <= 0 {
+		f.Limit = 20
+	}
+
+	// Keyset: (ключ сортировки, created_at, id). Объявления без курса (price_kgs NULL)
+	// при сортировке по цене уходят в конец через sentinel-значение.
+	const priceKey = "COALESCE(l.price_kgs, " + noPriceKey + ")"
+	orderBy := "l.created_at DESC, l.id DESC"
+	switch f.Sort {
+	case SortPriceAsc:
+		orderBy = priceKey + " ASC, " + orderBy
+	case SortPriceDesc:
+		orderBy = priceKey + " DESC, " + orderBy
+	}
+	if c := f.After; c != nil {
+		f.Offset = 0
+		args = append(args, c.CreatedAt, c.ID)
+		tie := fmt.Sprintf("(l.created_at, l.id) < ($%d, $%d)", len(args)-1, len(args))
+		switch {
+		case f.Sort == SortPriceAsc || f.Sort == SortPriceDesc:
+			if c.Key == nil {
+				return nil, pagination.ErrInvalidCursor
+			}
+			op := ">"
+			if f.Sort == SortPriceDesc {
+				op = "<"
+			}
+			args = append(args, *c.Key)
+			k := len(args)
+			where = append(where, fmt.Sprintf("(%s %s $%d::numeric OR (%s = $%d::numeric AND %s))", priceKey, op, k, priceKey, k, tie))
+		default:
+			where = append(where, tie)
+		}
+	}
+	args = append(args, f.Limit, f.Offset)
+
+	rows, err := r.db.Query(ctx, productCardSelect+`
+		WHERE `+strings.Join(where, " AND ")+fmt.Sprintf(`
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, orderBy, len(args)-1, len(args)), args...)
 	if err != nil {
 		return nil, err
 	}
+	return scanProductCards(rows, f.Limit)
+}
+
+// productCardSelect — карточка объявления для выдачи (listing + бренд/модель из product).
+const productCardSelect = `
+	SELECT l.id, l.product_id, l.title, l.price_amount, l.currency_code, l.price_kgs::float8, l.attrs,
+	       p.brand_id, COALESCE(b.name, ''), COALESCE(p.model, ''), l.created_at
+	FROM listing l
+	JOIN category c ON c.id = l.category_id
+	LEFT JOIN product p ON p.id = l.product_id
+	LEFT JOIN brand b ON b.id = p.brand_id
+`
+
+func scanProductCards(rows pgx.Rows, capacity int) ([]models.Product, error) {
 	defer rows.Close()
 
-	out := make([]models.Product, 0, limit)
+	out := make([]models.Product, 0, capacity)
 	for rows.Next() {
 		var p models.Product
-		if err := rows.Scan(&p.ID, &p.Title, &p.PriceAmount, &p.CurrencyCode, &p.Attrs, &p.FilterURL); err != nil {
+		if err := rows.Scan(&p.ID, &p.ProductID, &p.Title, &p.PriceAmount, &p.CurrencyCode, &p.PriceKGS, &p.Attrs,
+			&p.BrandID, &p.Brand, &p.Model, &p.CreatedAt); err != nil {
 			return nil, err
 		}
 		out = append(out, p)
@@ -71,6 +213,22 @@ func (r *productsRepo) ListByCategorySlug(ctx context.Context, slug string, limi
 	return out, rows.Err()
 }
 
+// noPriceKey — ключ сортировки для объявлений без price_kgs (валюта без курса).
+const noPriceKey = "1e15"
+
+// ProductCursor — курсор на элемент выдачи с учётом сортировки (для next_cursor).
+func ProductCursor(p models.Product, sort string) pagination.Cursor {
+	c := pagination.Cursor{Sort: sort, CreatedAt: p.CreatedAt, ID: p.ID}
+	if sort == SortPriceAsc || sort == SortPriceDesc {
+		k := 1e15
+		if p.PriceKGS != nil {
+			k = *p.PriceKGS
+		}
+		c.Key = &k
+	}
+	return c
+}
+
 // ===== ProductMediaRepository impl =====
 
 type productMediaRepo struct{ db *pgxpool.Pool }
@@ -81,9 +239,10 @@ func (r *productMediaRepo) ListByProductIDs(ctx context.Context, ids []uuid.UUID
 	}
 
 	rows, err := r.db.Query(ctx, `
-		SELECT product_id, url, sort, cover
+		SELECT product_id, url, sort_order, is_cover
 		FROM product_media
 		WHERE product_id = ANY($1)
+		ORDER BY product_id, sort_order
 	`, ids)
 	if err != nil {
 		return nil, err
@@ -107,7 +266,7 @@ type categoriesRepo struct{ db *pgxpool.Pool }
 
 func (r *categoriesRepo) ListRoots(ctx context.Context) ([]models.Category, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT name, slug
+		SELECT id, name, slug
 		FROM category
 		WHERE parent_id IS NULL
 		ORDER BY name
@@ -120,7 +279,7 @@ func (r *categoriesRepo) ListRoots(ctx context.Context) ([]models.Category, erro
 	var out []models.Category
 	for rows.Next() {
 		var c models.Category
-		if err := rows.Scan(&c.Name, &c.Slug); err != nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.Slug); err != nil {
 			return nil, err
 		}
 		out = append(out, c)
@@ -128,49 +287,143 @@ func (r *categoriesRepo) ListRoots(ctx context.Context) ([]models.Category, erro
 	return out, rows.Err()
 }
 
-func (r *categoriesRepo) Tree(ctx context.Context) ([]models.Category, error) {
-	type row struct {
-		ID       int64
-		Name     string
-		Slug     string
-		ParentID *int64
+func (r *categoriesRepo) ListChildrenBySlug(ctx context.Context, parentSlug string) ([]models.Category, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT c.id, c.name, c.slug
+		FROM category c
+		JOIN category p ON p.id = c.parent_id
+		WHERE p.slug = $1 AND c.is_active
+		ORDER BY c.sort_order, c.name
+	`, parentSlug)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
+	var out []models.Category
+	for rows.Next() {
+		var c models.Category
+		if err := rows.Scan(&c.ID, &c.Name, &c.Slug); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *categoriesRepo) GetBySlug(ctx context.Context, slug string) (models.Category, error) {
+	var c models.Category
+	err := r.db.QueryRow(ctx, `
+		SELECT id, name, slug
+		FROM category
+		WHERE slug = $1
+	`, slug).Scan(&c.ID, &c.Name, &c.Slug)
+	return c, err
+}
+
+// Tree — всё дерево категорий (включая неактивные); на каждом уровне — по sort_order, name.
+func (r *categoriesRepo) Tree(ctx context.Context) ([]models.Category, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT id, name, slug, parent_id
 		FROM category
+		ORDER BY sort_order, name
 	`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	all := make([]row, 0, 256)
+	// дети собираются по родителю, а узлы — рекурсивно сверху вниз: копии
+	// в срезах Children не должны создаваться раньше, чем заполнены их потомки
+	children := map[uuid.UUID][]models.Category{}
+	var roots []models.Category
 	for rows.Next() {
-		var rr row
-		if err := rows.Scan(&rr.ID, &rr.Name, &rr.Slug, &rr.ParentID); err != nil {
+		var (
+			c        models.Category
+			parentID *uuid.UUID
+		)
+		if err := rows.Scan(&c.ID, &c.Name, &c.Slug, &parentID); err != nil {
 			return nil, err
 		}
-		all = append(all, rr)
+		if parentID == nil {
+			roots = append(roots, c)
+		} else {
+			children[*parentID] = append(children[*parentID], c)
+		}
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	index := map[int64]*models.Category{}
-	for _, r := range all {
-		index[r.ID] = &models.Category{Name: r.Name, Slug: r.Slug}
-	}
-	var roots []models.Category
-	for _, r := range all {
-		if r.ParentID == nil {
-			roots = append(roots, *index[r.ID])
-		} else {
-			parent := index[*r.ParentID]
-			parent.Children = append(parent.Children, *index[r.ID])
+	var build func(level []models.Category) []models.Category
+	build = func(level []models.Category) []models.Category {
+		for i := range level {
+			level[i].Children = build(children[level[i].ID])
 		}
+		return level
+	}
+	return build(roots), nil
+}
+
+// ===== ChatSessionsRepository impl =====
+
+type chatSessionsRepo struct{ db *pgxpool.Pool }
+
+func (r *chatSessionsRepo) Create(ctx context.Context, s models.ChatSession) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO chat_session (id, user_id, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $3, $4)
+	`, s.ID, s.UserID, s.CreatedAt, s.ExpiresAt)
+	return err
+}
+
+func (r *chatSessionsRepo) Get(ctx context.Context, id string) (models.ChatSession, error) {
+	var s models.ChatSession
+	err := r.db.QueryRow(ctx, `
+		SELECT id, user_id, created_at, last_seen_at, expires_at
+		FROM chat_session
+		WHERE id = $1
+	`, id).Scan(&s.ID, &s.UserID, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt)
+	return s, err
+}
+
+func (r *chatSessionsRepo) Touch(ctx context.Context, id string, expiresAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE chat_session SET last_seen_at = now(), expires_at = $2
+		WHERE id = $1
+	`, id, expiresAt)
+	return err
+}
+
+func (r *chatSessionsRepo) BindUser(ctx context.Context, id string, userID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE chat_session SET user_id = $2 WHERE id = $1 AND user_id IS NULL
+	`, id, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE conversation SET user_id = $2, updated_at = now()
+		WHERE session_id = $1 AND user_id IS NULL
+	`, id, userID); err != nil {
+		return err
 	}
-	return roots, nil
+	return tx.Commit(ctx)
+}
+
+func (r *chatSessionsRepo) Delete(ctx context.Context, id string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM chat_session WHERE id = $1`, id)
+	return err
+}
+
+func (r *chatSessionsRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM chat_session WHERE expires_at < $1`, now)
+	return tag.RowsAffected(), err
 }
 
 // ===== ConversationsRepository impl =====
@@ -179,24 +432,18 @@ type conversationsRepo struct{ db *pgxpool.Pool }
 
 func (r *conversationsRepo) GetOrCreateBySession(ctx context.Context, sessionID string, userID *uuid.UUID) (models.Conversation, error) {
 	// пробуем найти
-	var c models.Conversation
-	err := r.db.QueryRow(ctx, `
-		SELECT id, session_id, user_id, created_at
-		FROM conversation
-		WHERE session_id = $1
-		LIMIT 1
-	`, sessionID).Scan(&c.ID, &c.SessionID, &c.UserID, &c.CreatedAt)
-
-	if err == nil {
-		return c, nil
+	c, err := r.GetBySession(ctx, sessionID)
+	if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+		return c, err
 	}
 
-	// создаём новое
+	// создаём новое (ON CONFLICT — если параллельный запрос успел раньше)
 	id := uuid.New()
 	now := time.Now().UTC()
 	err = r.db.QueryRow(ctx, `
 		INSERT INTO conversation (id, session_id, user_id, created_at)
 		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (session_id) DO UPDATE SET updated_at = now()
 		RETURNING id, session_id, user_id, created_at
 	`, id, sessionID, userID, now).Scan(&c.ID, &c.SessionID, &c.UserID, &c.CreatedAt)
 	return c, err
@@ -220,21 +467,41 @@ type messagesRepo struct{ db *pgxpool.Pool }
 func (r *messagesRepo) Append(ctx context.Context, conversationID uuid.UUID, role, text string, meta map[string]string) (uuid.UUID, error) {
 	id := uuid.New()
 	now := time.Now().UTC()
+	if meta == nil {
+		meta = map[string]string{}
+	}
 	_, err := r.db.Exec(ctx, `
-		INSERT INTO message (id, conversation_id, role, text, meta, created_at)
+		INSERT INTO message (id, conversation_id, role, content, meta, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`, id, conversationID, role, text, meta, now)
+	if err != nil {
+		return id, err
+	}
+	_, err = r.db.Exec(ctx, `
+		UPDATE conversation SET last_message_at = $2, updated_at = $2
+		WHERE id = $1
+	`, conversationID, now)
 	return id, err
 }
 
 func (r *messagesRepo) ListLast(ctx context.Context, conversationID uuid.UUID, limit int) ([]models.Message, error) {
+	return r.ListBefore(ctx, conversationID, nil, limit)
+}
+
+func (r *messagesRepo) ListBefore(ctx context.Context, conversationID uuid.UUID, before *pagination.Cursor, limit int) ([]models.Message, error) {
+	args := []any{conversationID, limit}
+	cond := ""
+	if before != nil {
+		args = append(args, before.CreatedAt, before.ID)
+		cond = "AND (created_at, id) < ($3, $4)"
+	}
 	rows, err := r.db.Query(ctx, `
-		SELECT id, conversation_id, role, text, meta, created_at
+		SELECT id, conversation_id, role, content, meta, created_at
 		FROM message
-		WHERE conversation_id = $1
-		ORDER BY created_at DESC
+		WHERE conversation_id = $1 `+cond+`
+		ORDER BY created_at DESC, id DESC
 		LIMIT $2
-	`, conversationID, limit)
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// ===== ExchangeRatesRepository impl =====
+
+type exchangeRatesRepo struct{ db *pgxpool.Pool }
+
+func (r *exchangeRatesRepo) List(ctx context.Context) ([]models.ExchangeRate, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT currency_code, rate_to_kgs::float8, source, updated_at
+		FROM exchange_rate
+		ORDER BY currency_code
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.ExchangeRate
+	for rows.Next() {
+		var er models.ExchangeRate
+		if err := rows.Scan(&er.CurrencyCode, &er.RateToKGS, &er.Source, &er.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, er)
+	}
+	return out, rows.Err()
+}
+
+// Upsert — сохраняет курсы и пересчитывает price_kgs у объявлений в этих валютах.
+// Строки, чья цена в KGS не изменилась, не переписываются: курсы обновляются каждый час,
+// а меняются редко.
+func (r *exchangeRatesRepo) Upsert(ctx context.Context, rates []models.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		codes := make([]string, 0, len(rates))
+		for _, er := range rates {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO exchange_rate (currency_code, rate_to_kgs, source, updated_at)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (currency_code) DO UPDATE
+				SET rate_to_kgs = EXCLUDED.rate_to_kgs, source = EXCLUDED.source, updated_at = EXCLUDED.updated_at
+			`, er.CurrencyCode, er.RateToKGS, er.Source, er.UpdatedAt); err != nil {
+				return err
+			}
+			codes = append(codes, er.CurrencyCode)
+		}
+		_, err := tx.Exec(ctx, `
+			UPDATE listing l
+			SET price_kgs = round(l.price_amount * r.rate_to_kgs, 2)
+			FROM exchange_rate r
+			WHERE r.currency_code = l.currency_code AND l.currency_code = ANY($1)
+			  AND l.price_kgs IS DISTINCT FROM round(l.price_amount * r.rate_to_kgs, 2)
+		`, codes)
+		return err
+	})
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// ===== FavoritesRepository impl =====
+
+type favoritesRepo struct{ db *pgxpool.Pool }
+
+func (r *favoritesRepo) Add(ctx context.Context, userID, listingID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO favorite (user_id, listing_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, userID, listingID)
+	return err
+}
+
+func (r *favoritesRepo) Remove(ctx context.Context, userID, listingID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM favorite WHERE user_id = $1 AND listing_id = $2`, userID, listingID)
+	return err
+}
+
+// List — избранные объявления пользователя (включая уже проданные), новые сверху.
+func (r *favoritesRepo) List(ctx context.Context, userID uuid.UUID) ([]models.Product, error) {
+	rows, err := r.db.Query(ctx, productCardSelect+`
+		JOIN favorite f ON f.listing_id = l.id
+		WHERE f.user_id = $1 AND l.status <> 'deleted'
+		ORDER BY f.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanProductCards(rows, 16)
+}
+
+// ===== SavedSearchesRepository impl =====
+
+type savedSearchesRepo struct{ db *pgxpool.Pool }
+
+const savedSearchSelect = `
+	SELECT id, user_id, name, requirements, last_run_at, created_at
+	FROM saved_search
+`
+
+func scanSavedSearches(rows pgx.Rows) ([]models.SavedSearch, error) {
+	defer rows.Close()
+
+	var out []models.SavedSearch
+	for rows.Next() {
+		var ss models.SavedSearch
+		if err := rows.Scan(&ss.ID, &ss.UserID, &ss.Name, &ss.Requirements, &ss.LastRunAt, &ss.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, ss)
+	}
+	return out, rows.Err()
+}
+
+func (r *savedSearchesRepo) Create(ctx context.Context, ss models.SavedSearch) (models.SavedSearch, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO saved_search (user_id, name, requirements, last_run_at)
+		VALUES ($1, $2, $3, now())
+		RETURNING id, last_run_at, created_at
+	`, ss.UserID, ss.Name, ss.Requirements).Scan(&ss.ID, &ss.LastRunAt, &ss.CreatedAt)
+	return ss, err
+}
+
+func (r *savedSearchesRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.SavedSearch, error) {
+	rows, err := r.db.Query(ctx, savedSearchSelect+`
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanSavedSearches(rows)
+}
+
+func (r *savedSearchesRepo) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM saved_search WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *savedSearchesRepo) ListDue(ctx context.Context, before time.Time, limit int) ([]models.SavedSearch, error) {
+	rows, err := r.db.Query(ctx, savedSearchSelect+`
+		WHERE last_run_at IS NULL OR last_run_at < $1
+		ORDER BY last_run_at NULLS FIRST
+		LIMIT $2
+	`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanSavedSearches(rows)
+}
+
+func (r *savedSearchesRepo) MarkRun(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.Exec(ctx, `UPDATE saved_search SET last_run_at = $2 WHERE id = $1`, id, at)
+	return err
+}
+
+// ===== NotificationsRepository impl =====
+
+type notificationsRepo struct{ db *pgxpool.Pool }
+
+func (r *notificationsRepo) Enqueue(ctx context.Context, n models.Notification) (uuid.UUID, error) {
+	if n.Payload == nil {
+		n.Payload = map[string]any{}
+	}
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO notification (user_id, kind, text, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, n.UserID, n.Kind, n.Text, n.Payload).Scan(&id)
+	return id, err
+}
+
+func (r *notificationsRepo) ListPending(ctx context.Context, userID uuid.UUID, limit int) ([]models.Notification, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, kind, text, payload, delivered_at, created_at
+		FROM notification
+		WHERE user_id = $1 AND delivered_at IS NULL
+		ORDER BY created_at
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Notification
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Text, &n.Payload, &n.DeliveredAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+func (r *notificationsRepo) MarkDelivered(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.db.Exec(ctx, `
+		UPDATE notification SET delivered_at = now()
+		WHERE user_id = $1 AND id = ANY($2) AND delivered_at IS NULL
+	`, userID, ids)
+	return err
+}
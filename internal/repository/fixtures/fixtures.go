@@ -0,0 +1,82 @@
+// Package fixtures — начальные данные хранилища в JSON: демо-каталог (-demo) и наборы для тестов.
+// Один набор загружается и в память (repository/memory), и в Postgres — с теми же ID.
+package fixtures
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// Data — содержимое хранилища. Ссылки — по ID: категории, бренды, пользователи и модели,
+// на которые ссылаются другие записи, должны иметь явный id. Пустые id и created_at
+// заполняются при загрузке, статус объявления по умолчанию — active, price_kgs считается по Rates.
+type Data struct {
+	Categories []Category            `json:"categories"`
+	Brands     []models.Brand        `json:"brands"`
+	Users      []User                `json:"users"`
+	Products   []Product             `json:"products"`
+	Media      []models.ProductMedia `json:"media"`
+	Listings   []models.Listing      `json:"listings"`
+	Rates      []models.ExchangeRate `json:"rates"` // курс KGS = 1 есть всегда
+}
+
+// Category — категория с полями, которых нет в models.Category (родитель, порядок, активность).
+type Category struct {
+	ID        uuid.UUID  `json:"id"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty"`
+	Name      string     `json:"name"`
+	Slug      string     `json:"slug"`
+	SortOrder int        `json:"sort_order,omitempty"`
+	Inactive  bool       `json:"inactive,omitempty"`
+}
+
+// User — профиль и роли сверх тех, что выдаются каждому (buyer, seller).
+type User struct {
+	models.UserProfile
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Product — модель каталога; Inactive — скрыта со страницы модели и из сопоставления.
+type Product struct {
+	models.CatalogProduct
+	Inactive bool `json:"inactive,omitempty"`
+}
+
+//go:embed demo.json
+var demoJSON []byte
+
+// Demo — встроенный демо-каталог: категории, бренды с алиасами, продавцы и объявления.
+func Demo() (Data, error) {
+	return Parse(demoJSON)
+}
+
+// Load — набор из JSON-файла.
+func Load(path string) (Data, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Data{}, err
+	}
+	d, err := Parse(b)
+	if err != nil {
+		return Data{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Parse — набор из JSON; неизвестные поля — ошибка (опечатка в ключе не должна молча терять данные).
+func Parse(b []byte) (Data, error) {
+	var d Data
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&d); err != nil {
+		return Data{}, fmt.Errorf("fixtures: %w", err)
+	}
+	return d, nil
+}
@@ -2,24 +2,85 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
 )
 
 // ===== Каталог =====
 
+// ProductFilter — параметры выборки объявлений для /items и /search.
+// Пустые поля не участвуют в фильтрации.
+type ProductFilter struct {
+	CategorySlug  string
+	BrandSlug     string
+	SellerID      *uuid.UUID // объявления одного продавца (публичная страница)
+	Query         string     // подстрока в заголовке
+	PriceMinKGS   *float64   // границы цены в KGS (по listing.price_kgs)
+	PriceMaxKGS   *float64
+	Condition     string             // "new" | "used"
+	CreatedAfter  *time.Time         // только объявления новее (для сохранённых поисков)
+	CreatedBefore *time.Time         // и не новее (включительно): окно прогона поиска
+	Sort          string             // SortNewest (по умолчанию) | SortPriceAsc | SortPriceDesc
+	After         *pagination.Cursor // keyset: продолжить после этого элемента (Offset игнорируется)
+	Limit         int
+	Offset        int
+}
+
+const (
+	SortNewest    = "new"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+)
+
 type ProductsRepository interface {
 	ListByCategorySlug(ctx context.Context, slug string, limit, offset int) ([]models.Product, error)
+	List(ctx context.Context, f ProductFilter) ([]models.Product, error)
+}
+
+// Бренды + алиасы для нечёткого поиска.
+type BrandsRepository interface {
+	List(ctx context.Context) ([]models.Brand, error)
+	GetBySlug(ctx context.Context, slug string) (models.Brand, error)
+	Create(ctx context.Context, b models.Brand) (models.Brand, error)
+	Update(ctx context.Context, b models.Brand) (models.Brand, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	FacetsByCategorySlug(ctx context.Context, slug string) ([]models.BrandFacet, error)
 }
 
 type ProductMediaRepository interface {
 	ListByProductIDs(ctx context.Context, ids []uuid.UUID) ([]models.ProductMedia, error)
 }
 
+// Каталог моделей (product) — страница модели и кандидаты для сопоставления объявлений.
+type CatalogRepository interface {
+	GetProduct(ctx context.Context, id uuid.UUID) (models.ProductDetails, error)
+	OfferStats(ctx context.Context, productID uuid.UUID) (models.OfferStats, error)
+	FindCandidates(ctx context.Context, categoryID uuid.UUID, brandID *uuid.UUID) ([]models.CatalogProduct, error)
+}
+
+// Объявления продавцов.
+type ListingsRepository interface {
+	Create(ctx context.Context, l models.Listing) (models.Listing, error)
+	GetByID(ctx context.Context, id uuid.UUID) (models.Listing, error)
+	SetProduct(ctx context.Context, listingID, productID uuid.UUID) error
+	// Update — сохраняет редактируемые поля (статус и покупателя), пересчитывает price_kgs.
+	Update(ctx context.Context, l models.Listing) (models.Listing, error)
+}
+
+// Курсы валют к KGS. Upsert пересчитывает listing.price_kgs.
+type ExchangeRatesRepository interface {
+	List(ctx context.Context) ([]models.ExchangeRate, error)
+	Upsert(ctx context.Context, rates []models.ExchangeRate) error
+}
+
 type CategoriesRepository interface {
 	ListRoots(ctx context.Context) ([]models.Category, error)
+	ListChildrenBySlug(ctx context.Context, parentSlug string) ([]models.Category, error)
+	GetBySlug(ctx context.Context, slug string) (models.Category, error)
 	Tree(ctx context.Context) ([]models.Category, error)
 }
 
@@ -31,10 +92,24 @@ type ConversationsRepository interface {
 	GetBySession(ctx context.Context, sessionID string) (models.Conversation, error)
 }
 
+// Сессии чата с TTL.
+type ChatSessionsRepository interface {
+	Create(ctx context.Context, s models.ChatSession) error
+	Get(ctx context.Context, id string) (models.ChatSession, error)
+	// Touch — отметка активности и продление срока жизни.
+	Touch(ctx context.Context, id string, expiresAt time.Time) error
+	// BindUser — привязывает анонимную сессию и её разговор к пользователю.
+	BindUser(ctx context.Context, id string, userID uuid.UUID) error
+	Delete(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
 // Сообщения внутри разговора.
 type MessagesRepository interface {
 	Append(ctx context.Context, conversationID uuid.UUID, role, text string, meta map[string]string) (uuid.UUID, error)
 	ListLast(ctx context.Context, conversationID uuid.UUID, limit int) ([]models.Message, error)
+	// ListBefore — страница истории до курсора (nil — самые новые), по возрастанию времени.
+	ListBefore(ctx context.Context, conversationID uuid.UUID, before *pagination.Cursor, limit int) ([]models.Message, error)
 }
 
 // Лог поисковых запросов (для аналитики/персонализации).
@@ -42,6 +117,107 @@ type SearchRequestsRepository interface {
 	Insert(ctx context.Context, sr models.SearchRequest) (uuid.UUID, error)
 }
 
+// ===== Избранное / сохранённые поиски / уведомления =====
+
+type FavoritesRepository interface {
+	Add(ctx context.Context, userID, listingID uuid.UUID) error
+	Remove(ctx context.Context, userID, listingID uuid.UUID) error
+	List(ctx context.Context, userID uuid.UUID) ([]models.Product, error)
+}
+
+type SavedSearchesRepository interface {
+	Create(ctx context.Context, ss models.SavedSearch) (models.SavedSearch, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]models.SavedSearch, error)
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	// ListDue — поиски, которые давно не прогонялись (last_run_at раньше before), старые первыми.
+	ListDue(ctx context.Context, before time.Time, limit int) ([]models.SavedSearch, error)
+	MarkRun(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type NotificationsRepository interface {
+	Enqueue(ctx context.Context, n models.Notification) (uuid.UUID, error)
+	ListPending(ctx context.Context, userID uuid.UUID, limit int) ([]models.Notification, error)
+	MarkDelivered(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error
+}
+
+// ===== Переписка покупатель ↔ продавец, блокировки, жалобы =====
+
+type ThreadsRepository interface {
+	GetOrCreate(ctx context.Context, listingID, buyerID, sellerID uuid.UUID) (models.Thread, error)
+	Get(ctx context.Context, id uuid.UUID) (models.Thread, error)
+	// Find — переписка покупателя по объявлению; pgx.ErrNoRows, если её не было.
+	Find(ctx context.Context, listingID, buyerID uuid.UUID) (models.Thread, error)
+	// ListByUser — переписки пользователя (как покупателя и как продавца) с числом непрочитанных.
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]models.Thread, error)
+	AppendMessage(ctx context.Context, threadID, senderID uuid.UUID, body string) (models.DirectMessage, error)
+	// ListMessages — страница до курсора (nil — самые новые), по возрастанию времени.
+	ListMessages(ctx context.Context, threadID uuid.UUID, before *pagination.Cursor, limit int) ([]models.DirectMessage, error)
+	// MarkRead — отмечает прочитанными сообщения второго участника; возвращает их количество.
+	MarkRead(ctx context.Context, threadID, readerID uuid.UUID) (int, error)
+	UnreadCount(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+type BlocksRepository interface {
+	Block(ctx context.Context, blockerID, blockedID uuid.UUID) error
+	Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error
+	// IsBlocked — заблокировал ли кто-то из двоих другого.
+	IsBlocked(ctx context.Context, a, b uuid.UUID) (bool, error)
+}
+
+type ReportsRepository interface {
+	// Create — pgx.ErrNoRows, если объекта target_type с id target_id нет.
+	Create(ctx context.Context, r models.AbuseReport) (models.AbuseReport, error)
+	// List — жалобы со статусом status (пусто — все), старые первыми.
+	List(ctx context.Context, status string, limit int) ([]models.AbuseReport, error)
+	// Resolve — закрывает жалобу (resolved | rejected); pgx.ErrNoRows, если её нет.
+	Resolve(ctx context.Context, id uuid.UUID, status string, moderatorID uuid.UUID) (models.AbuseReport, error)
+}
+
+// Роли и права пользователей.
+type RolesRepository interface {
+	HasPermission(ctx context.Context, userID uuid.UUID, perm string) (bool, error)
+	Access(ctx context.Context, userID uuid.UUID) (models.UserAccess, error)
+	// Grant — выдаёт роль; pgx.ErrNoRows, если такой роли нет.
+	Grant(ctx context.Context, userID uuid.UUID, role string) error
+	Revoke(ctx context.Context, userID uuid.UUID, role string) error
+}
+
+// ===== Профили и отзывы =====
+
+type UsersRepository interface {
+	// GetProfile — pgx.ErrNoRows, если пользователя нет.
+	GetProfile(ctx context.Context, id uuid.UUID) (models.UserProfile, error)
+	// SaveProfile — создаёт пользователя при первом сохранении профиля (id из JWT).
+	SaveProfile(ctx context.Context, p models.UserProfile) (models.UserProfile, error)
+}
+
+type ReviewsRepository interface {
+	// Upsert — один отзыв на объявление от покупателя; повторный вызов обновляет оценку и текст.
+	Upsert(ctx context.Context, rv models.Review) (models.Review, error)
+	Delete(ctx context.Context, listingID, reviewerID uuid.UUID) error
+	// Exists — есть ли отзыв на объявление (пока есть, покупателя объявления не сменить).
+	Exists(ctx context.Context, listingID uuid.UUID) (bool, error)
+	// ListBySeller — отзывы о продавце, новые первыми, после курсора (nil — с начала).
+	ListBySeller(ctx context.Context, sellerID uuid.UUID, after *pagination.Cursor, limit int) ([]models.Review, error)
+	Stats(ctx context.Context, sellerID uuid.UUID) (models.RatingStats, error)
+}
+
+// ===== Лимиты =====
+
+// RateLimitsRepository — бакеты rate limit в Postgres (общие для всех инстансов).
+type RateLimitsRepository interface {
+	// Take — токен из каждого бакета keys или ни из одного (см. ratelimit.Store).
+	Take(ctx context.Context, keys []string, now time.Time, every, window time.Duration) (allowed bool, retryAfter time.Duration, err error)
+	// DeleteIdle — удаляет бакеты, заполнившиеся до before.
+	DeleteIdle(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AIUsageRepository — суточный расход LLM-токенов.
+type AIUsageRepository interface {
+	AddUsage(ctx context.Context, subject string, day time.Time, tokens int) error
+	Usage(ctx context.Context, subject string, day time.Time) (int, error)
+}
+
 // ===== Набор всех репозиториев =====
 
 type RepositorySet interface {
@@ -49,9 +225,33 @@ type RepositorySet interface {
 	Products() ProductsRepository
 	ProductMedia() ProductMediaRepository
 	Categories() CategoriesRepository
+	Brands() BrandsRepository
+	Catalog() CatalogRepository
+	Listings() ListingsRepository
+	ExchangeRates() ExchangeRatesRepository
 
 	// чат
+	ChatSessions() ChatSessionsRepository
 	Conversations() ConversationsRepository
 	Messages() MessagesRepository
 	SearchRequests() SearchRequestsRepository
+
+	// избранное и уведомления
+	Favorites() FavoritesRepository
+	SavedSearches() SavedSearchesRepository
+	Notifications() NotificationsRepository
+
+	// переписка и модерация
+	Threads() ThreadsRepository
+	Blocks() BlocksRepository
+	Reports() ReportsRepository
+	Roles() RolesRepository
+
+	// профили и отзывы
+	Users() UsersRepository
+	Reviews() ReviewsRepository
+
+	// лимиты
+	RateLimits() RateLimitsRepository
+	AIUsage() AIUsageRepository
 }
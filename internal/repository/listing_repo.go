@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// ===== ListingsRepository impl =====
+
+type listingsRepo struct{ db *pgxpool.Pool }
+
+const listingSelect = `
+	SELECT id, seller_id, product_id, category_id, title, description,
+	       price_amount, currency_code, condition, COALESCE(location_text, ''), attrs, status, buyer_id, created_at
+	FROM listing
+`
+
+func scanListing(row pgx.Row) (models.Listing, error) {
+	var l models.Listing
+	err := row.Scan(&l.ID, &l.SellerID, &l.ProductID, &l.CategoryID, &l.Title, &l.Description,
+		&l.PriceAmount, &l.CurrencyCode, &l.Condition, &l.LocationText, &l.Attrs, &l.Status, &l.BuyerID, &l.CreatedAt)
+	return l, err
+}
+
+func (r *listingsRepo) Create(ctx context.Context, l models.Listing) (models.Listing, error) {
+	if l.Attrs == nil {
+		l.Attrs = map[string]any{}
+	}
+	return scanListing(r.db.QueryRow(ctx, `
+		INSERT INTO listing (seller_id, product_id, category_id, title, description,
+		                     price_amount, currency_code, condition, location_text, attrs, price_kgs)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10,
+		        (SELECT round($6 * rate_to_kgs, 2) FROM exchange_rate WHERE currency_code = $7))
+		RETURNING id, seller_id, product_id, category_id, title, description,
+		          price_amount, currency_code, condition, COALESCE(location_text, ''), attrs, status, buyer_id, created_at
+	`, l.SellerID, l.ProductID, l.CategoryID, l.Title, l.Description,
+		l.PriceAmount, l.CurrencyCode, l.Condition, l.LocationText, l.Attrs))
+}
+
+func (r *listingsRepo) GetByID(ctx context.Context, id uuid.UUID) (models.Listing, error) {
+	return scanListing(r.db.QueryRow(ctx, listingSelect+`WHERE id = $1`, id))
+}
+
+func (r *listingsRepo) SetProduct(ctx context.Context, listingID, productID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE listing SET product_id = $2, updated_at = now()
+		WHERE id = $1
+	`, listingID, productID)
+	return err
+}
+
+func (r *listingsRepo) Update(ctx context.Context, l models.Listing) (models.Listing, error) {
+	if l.Attrs == nil {
+		l.Attrs = map[string]any{}
+	}
+	return scanListing(r.db.QueryRow(ctx, `
+		UPDATE listing SET
+			title = $2, description = $3, price_amount = $4, currency_code = $5,
+			condition = $6, location_text = NULLIF($7, ''), attrs = $8, status = $9, buyer_id = $10,
+			price_kgs = (SELECT round($4 * rate_to_kgs, 2) FROM exchange_rate WHERE currency_code = $5),
+			updated_at = now()
+		WHERE id = $1
+		RETURNING id, seller_id, product_id, category_id, title, description,
+		          price_amount, currency_code, condition, COALESCE(location_text, ''), attrs, status, buyer_id, created_at
+	`, l.ID, l.Title, l.Description, l.PriceAmount, l.CurrencyCode,
+		l.Condition, l.LocationText, l.Attrs, l.Status, l.BuyerID))
+}
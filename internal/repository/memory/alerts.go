@@ -0,0 +1,247 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// ===== FavoritesRepository =====
+
+type favoritesRepo struct{ s *store }
+
+func (r favoritesRepo) Add(_ context.Context, userID, listingID uuid.UUID) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := favoriteKey{userID, listingID}
+	if _, ok := s.favorites[key]; ok {
+		return nil
+	}
+	if err := s.userRef(&userID, "favorite", "favorite_user_id_fkey"); err != nil {
+		return err
+	}
+	if _, ok := s.listings[listingID]; !ok {
+		return violation(foreignKeyViolation, "favorite", "favorite_listing_id_fkey")
+	}
+	s.favorites[key] = s.now()
+	return nil
+}
+
+func (r favoritesRepo) Remove(_ context.Context, userID, listingID uuid.UUID) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.favorites, favoriteKey{userID, listingID})
+	return nil
+}
+
+func (r favoritesRepo) List(_ context.Context, userID uuid.UUID) ([]models.Product, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type fav struct {
+		l  *listing
+		at time.Time
+	}
+	var found []fav
+	for key, at := range s.favorites {
+		if l := s.listings[key.listingID]; key.userID == userID && l.Status != "deleted" {
+			found = append(found, fav{l, at})
+		}
+	}
+	slices.SortFunc(found, func(a, b fav) int { return b.at.Compare(a.at) })
+
+	out := make([]models.Product, 0, len(found))
+	for _, f := range found {
+		p, err := s.card(f.l)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// ===== SavedSearchesRepository =====
+
+type savedSearchesRepo struct{ s *store }
+
+// savedSearchOut — копия: requirements хранятся как JSONB, указатели не разделяются.
+func savedSearchOut(ss *models.SavedSearch) (models.SavedSearch, error) {
+	v := *ss
+	v.LastRunAt = clonePtr(v.LastRunAt)
+	req, err := jsonClone(v.Requirements)
+	v.Requirements = req
+	return v, err
+}
+
+func (r savedSearchesRepo) Create(_ context.Context, ss models.SavedSearch) (models.SavedSearch, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.userRef(&ss.UserID, "saved_search", "saved_search_user_id_fkey"); err != nil {
+		return ss, err
+	}
+	now := s.now()
+	ss.ID, ss.LastRunAt, ss.CreatedAt = uuid.New(), ptr(now), now
+	stored, err := savedSearchOut(&ss)
+	if err != nil {
+		return ss, err
+	}
+	s.savedSearches[ss.ID] = &stored
+	return ss, nil
+}
+
+// sortedSearches — копии поисков, прошедших keep, в порядке cmp (не больше n).
+func (s *store) sortedSearches(keep func(*models.SavedSearch) bool, cmp func(a, b *models.SavedSearch) int, n int) ([]models.SavedSearch, error) {
+	var found []*models.SavedSearch
+	for _, ss := range s.savedSearches {
+		if keep(ss) {
+			found = append(found, ss)
+		}
+	}
+	slices.SortFunc(found, cmp)
+
+	var out []models.SavedSearch
+	for _, ss := range limit(found, n) {
+		v, err := savedSearchOut(ss)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (r savedSearchesRepo) ListByUser(_ context.Context, userID uuid.UUID) ([]models.SavedSearch, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sortedSearches(
+		func(ss *models.SavedSearch) bool { return ss.UserID == userID },
+		func(a, b *models.SavedSearch) int { return b.CreatedAt.Compare(a.CreatedAt) },
+		-1)
+}
+
+func (r savedSearchesRepo) Delete(_ context.Context, userID, id uuid.UUID) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ss, ok := s.savedSearches[id]; !ok || ss.UserID != userID {
+		return pgx.ErrNoRows
+	}
+	delete(s.savedSearches, id)
+	return nil
+}
+
+func (r savedSearchesRepo) ListDue(_ context.Context, before time.Time, n int) ([]models.SavedSearch, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sortedSearches(
+		func(ss *models.SavedSearch) bool { return ss.LastRunAt == nil || ss.LastRunAt.Before(before) },
+		func(a, b *models.SavedSearch) int { // last_run_at NULLS FIRST
+			switch {
+			case a.LastRunAt == nil && b.LastRunAt == nil:
+				return 0
+			case a.LastRunAt == nil:
+				return -1
+			case b.LastRunAt == nil:
+				return 1
+			}
+			return a.LastRunAt.Compare(*b.LastRunAt)
+		},
+		n)
+}
+
+func (r savedSearchesRepo) MarkRun(_ context.Context, id uuid.UUID, at time.Time) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ss, ok := s.savedSearches[id]; ok {
+		ss.LastRunAt = ptr(ts(at))
+	}
+	return nil
+}
+
+// ===== NotificationsRepository =====
+
+type notificationsRepo struct{ s *store }
+
+func (r notificationsRepo) Enqueue(_ context.Context, n models.Notification) (uuid.UUID, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.userRef(&n.UserID, "notification", "notification_user_id_fkey"); err != nil {
+		return uuid.Nil, err
+	}
+	if n.Payload == nil {
+		n.Payload = map[string]any{}
+	}
+	payload, err := jsonClone(n.Payload)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	// id, время создания и доставки задаёт база, как в INSERT Postgres-реализации
+	n.ID, n.Payload, n.DeliveredAt, n.CreatedAt = uuid.New(), payload, nil, s.now()
+	s.notifications[n.ID] = &n
+	return n.ID, nil
+}
+
+func (r notificationsRepo) ListPending(_ context.Context, userID uuid.UUID, n int) ([]models.Notification, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []*models.Notification
+	for _, nt := range s.notifications {
+		if nt.UserID == userID && nt.DeliveredAt == nil {
+			found = append(found, nt)
+		}
+	}
+	slices.SortFunc(found, func(a, b *models.Notification) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	var out []models.Notification
+	for _, nt := range limit(found, n) {
+		v := *nt
+		payload, err := jsonClone(v.Payload)
+		if err != nil {
+			return nil, err
+		}
+		v.Payload = payload
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (r notificationsRepo) MarkDelivered(_ context.Context, userID uuid.UUID, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for _, id := range ids {
+		if nt, ok := s.notifications[id]; ok && nt.UserID == userID && nt.DeliveredAt == nil {
+			nt.DeliveredAt = ptr(now)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,819 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/repository"
+	"github.com/btynybekov/marketplace/internal/repository/fixtures"
+)
+
+// ===== Вставка записей каталога (фикстуры и репозитории) =====
+
+func (s *store) insertCategory(c fixtures.Category) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	if _, ok := s.categories[c.ID]; ok {
+		return violation(uniqueViolation, "category", "category_pkey")
+	}
+	if c.ParentID != nil {
+		if _, ok := s.categories[*c.ParentID]; !ok {
+			return violation(foreignKeyViolation, "category", "category_parent_id_fkey")
+		}
+		c.ParentID = clonePtr(c.ParentID)
+	}
+	for _, o := range s.categories {
+		if o.Slug == c.Slug {
+			return violation(uniqueViolation, "category", "category_slug_key")
+		}
+		if o.Name == c.Name && o.ParentID != nil && c.ParentID != nil && *o.ParentID == *c.ParentID {
+			return violation(uniqueViolation, "category", "category_parent_id_name_key")
+		}
+	}
+	s.categories[c.ID] = &category{c}
+	return nil
+}
+
+// checkBrand — уникальность имени, slug и алиасов (без учёта регистра) среди других брендов.
+func (s *store) checkBrand(b models.Brand) error {
+	aliases := map[string]bool{}
+	for _, a := range b.Aliases {
+		a = strings.ToLower(a)
+		if aliases[a] {
+			return violation(uniqueViolation, "brand_alias", "uniq_brand_alias_lower")
+		}
+		aliases[a] = true
+	}
+	for _, o := range s.brands {
+		if o.ID == b.ID {
+			continue
+		}
+		switch {
+		case o.Name == b.Name:
+			return violation(uniqueViolation, "brand", "brand_name_key")
+		case o.Slug == b.Slug:
+			return violation(uniqueViolation, "brand", "brand_slug_key")
+		}
+		for _, a := range o.Aliases {
+			if aliases[strings.ToLower(a)] {
+				return violation(uniqueViolation, "brand_alias", "uniq_brand_alias_lower")
+			}
+		}
+	}
+	return nil
+}
+
+func (s *store) insertBrand(b models.Brand) (models.Brand, error) {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	if _, ok := s.brands[b.ID]; ok {
+		return b, violation(uniqueViolation, "brand", "brand_pkey")
+	}
+	if err := s.checkBrand(b); err != nil {
+		return b, err
+	}
+	if b.CreatedAt.IsZero() {
+		b.CreatedAt = s.now()
+	}
+	b.CreatedAt = ts(b.CreatedAt)
+	stored := b
+	stored.Aliases = slices.Clone(b.Aliases)
+	s.brands[b.ID] = &stored
+	return b, nil
+}
+
+func (s *store) insertProduct(p fixtures.Product) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	if _, ok := s.products[p.ID]; ok {
+		return violation(uniqueViolation, "product", "product_pkey")
+	}
+	if _, ok := s.categories[p.CategoryID]; !ok {
+		return violation(foreignKeyViolation, "product", "product_category_id_fkey")
+	}
+	if p.BrandID != nil {
+		if _, ok := s.brands[*p.BrandID]; !ok {
+			return violation(foreignKeyViolation, "product", "product_brand_id_fkey")
+		}
+	}
+	cp := p.CatalogProduct
+	cp.BrandID = clonePtr(cp.BrandID)
+	if cp.Specs == nil {
+		cp.Specs = map[string]any{}
+	}
+	specs, err := jsonClone(cp.Specs)
+	if err != nil {
+		return err
+	}
+	cp.Specs = specs
+	s.products[cp.ID] = &product{CatalogProduct: cp, active: !p.Inactive, updatedAt: s.now()}
+	return nil
+}
+
+func (s *store) insertMedia(m models.ProductMedia) error {
+	if _, ok := s.products[m.ProductID]; !ok {
+		return violation(foreignKeyViolation, "product_media", "product_media_product_id_fkey")
+	}
+	if m.Cover {
+		for _, o := range s.media {
+			if o.ProductID == m.ProductID && o.Cover {
+				return violation(uniqueViolation, "product_media", "uniq_product_media_cover")
+			}
+		}
+	}
+	s.media = append(s.media, m)
+	return nil
+}
+
+// checkListing — ограничения listing: ссылки, допустимые condition/status и покупатель.
+func (s *store) checkListing(l models.Listing) error {
+	if l.Condition != "new" && l.Condition != "used" {
+		return violation(checkViolation, "listing", "listing_condition_check")
+	}
+	switch l.Status {
+	case "active", "paused", "sold", "deleted":
+	default:
+		return violation(checkViolation, "listing", "listing_status_check")
+	}
+	if _, ok := s.users[l.SellerID]; !ok {
+		return violation(foreignKeyViolation, "listing", "listing_seller_id_fkey")
+	}
+	if _, ok := s.categories[l.CategoryID]; !ok {
+		return violation(foreignKeyViolation, "listing", "listing_category_id_fkey")
+	}
+	if l.ProductID != nil {
+		if _, ok := s.products[*l.ProductID]; !ok {
+			return violation(foreignKeyViolation, "listing", "listing_product_id_fkey")
+		}
+	}
+	if b := l.BuyerID; b != nil {
+		if *b == l.SellerID || (l.Status != "sold" && l.Status != "deleted") {
+			return violation(checkViolation, "listing", "listing_buyer_check")
+		}
+		if _, ok := s.users[*b]; !ok {
+			return violation(foreignKeyViolation, "listing", "listing_buyer_id_fkey")
+		}
+	}
+	return nil
+}
+
+// insertListing — как INSERT в listingsRepo.Create, но с id, статусом и created_at из l, если заданы.
+func (s *store) insertListing(l models.Listing) (models.Listing, error) {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	if _, ok := s.listings[l.ID]; ok {
+		return l, violation(uniqueViolation, "listing", "listing_pkey")
+	}
+	if l.Status == "" {
+		l.Status = "active"
+	}
+	if l.CurrencyCode == "" {
+		l.CurrencyCode = "KGS"
+	}
+	if err := s.checkListing(l); err != nil {
+		return l, err
+	}
+	if l.CreatedAt.IsZero() {
+		l.CreatedAt = s.now()
+	}
+	l.CreatedAt = ts(l.CreatedAt)
+	// price_kgs, как в INSERT, считается от переданной цены, а не от округлённой колонки
+	kgs := s.priceKGS(l.PriceAmount, l.CurrencyCode)
+	l.PriceAmount = round(l.PriceAmount, 2)
+	l.ProductID, l.BuyerID = clonePtr(l.ProductID), clonePtr(l.BuyerID)
+	if l.Attrs == nil {
+		l.Attrs = map[string]any{}
+	}
+	attrs, err := jsonClone(l.Attrs)
+	if err != nil {
+		return l, err
+	}
+	l.Attrs = attrs
+	stored := &listing{Listing: l, priceKGS: kgs}
+	s.listings[l.ID] = stored
+	return stored.out()
+}
+
+// priceKGS — round(amount * rate_to_kgs, 2); nil — у валюты нет курса.
+func (s *store) priceKGS(amount float64, code string) *float64 {
+	r, ok := s.rates[code]
+	if !ok {
+		return nil
+	}
+	return ptr(round(amount*r.RateToKGS, 2))
+}
+
+// out — копия объявления для вызывающего.
+func (l *listing) out() (models.Listing, error) {
+	v := l.Listing
+	v.ProductID, v.BuyerID = clonePtr(v.ProductID), clonePtr(v.BuyerID)
+	attrs, err := jsonClone(v.Attrs)
+	v.Attrs = attrs
+	return v, err
+}
+
+func (s *store) upsertRates(rates []models.ExchangeRate) error {
+	for _, er := range rates {
+		if er.RateToKGS <= 0 {
+			return violation(checkViolation, "exchange_rate", "exchange_rate_rate_to_kgs_check")
+		}
+	}
+	codes := map[string]bool{}
+	for _, er := range rates {
+		er.RateToKGS = round(er.RateToKGS, 8)
+		er.UpdatedAt = ts(er.UpdatedAt)
+		s.rates[er.CurrencyCode] = er
+		codes[er.CurrencyCode] = true
+	}
+	for _, l := range s.listings {
+		if codes[l.CurrencyCode] {
+			l.priceKGS = s.priceKGS(l.PriceAmount, l.CurrencyCode)
+		}
+	}
+	return nil
+}
+
+// card — карточка выдачи (productCardSelect): бренд и модель — из привязанной модели каталога.
+func (s *store) card(l *listing) (models.Product, error) {
+	p := models.Product{
+		ID:           l.ID,
+		ProductID:    clonePtr(l.ProductID),
+		Title:        l.Title,
+		PriceAmount:  l.PriceAmount,
+		CurrencyCode: l.CurrencyCode,
+		PriceKGS:     clonePtr(l.priceKGS),
+		CreatedAt:    l.CreatedAt,
+	}
+	if err := jsonConvert(l.Attrs, &p.Attrs); err != nil {
+		return p, err
+	}
+	if l.ProductID != nil {
+		if cp, ok := s.products[*l.ProductID]; ok {
+			p.BrandID = clonePtr(cp.BrandID)
+			p.Model = cp.Model
+			if cp.BrandID != nil {
+				p.Brand = s.brands[*cp.BrandID].Name
+			}
+		}
+	}
+	return p, nil
+}
+
+// brandSlug — slug бренда модели объявления ("" — модели или бренда нет).
+func (s *store) brandSlug(l *listing) string {
+	if l.ProductID == nil {
+		return ""
+	}
+	cp, ok := s.products[*l.ProductID]
+	if !ok || cp.BrandID == nil {
+		return ""
+	}
+	return s.brands[*cp.BrandID].Slug
+}
+
+// ===== ProductsRepository =====
+
+type productsRepo struct{ s *store }
+
+func (r productsRepo) ListByCategorySlug(ctx context.Context, slug string, limit, offset int) ([]models.Product, error) {
+	return r.List(ctx, repository.ProductFilter{CategorySlug: slug, Limit: limit, Offset: offset})
+}
+
+// noPriceKey — ключ сортировки объявлений без price_kgs (как в Postgres-реализации).
+const noPriceKey = 1e15
+
+func sortKey(l *listing) float64 {
+	if l.priceKGS == nil {
+		return noPriceKey
+	}
+	return *l.priceKGS
+}
+
+func (r productsRepo) List(_ context.Context, f repository.ProductFilter) ([]models.Product, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if f.Limit <= 0 {
+		f.Limit = 20
+	}
+	priceSort := f.Sort == repository.SortPriceAsc || f.Sort == repository.SortPriceDesc
+	if f.After != nil {
+		f.Offset = 0
+		if priceSort && f.After.Key == nil {
+			return nil, pagination.ErrInvalidCursor
+		}
+	}
+	query := strings.ToLower(f.Query)
+
+	var found []*listing
+	for _, l := range s.listings {
+		switch {
+		case l.Status != "active",
+			f.CategorySlug != "" && s.categories[l.CategoryID].Slug != f.CategorySlug,
+			f.BrandSlug != "" && s.brandSlug(l) != f.BrandSlug,
+			f.SellerID != nil && l.SellerID != *f.SellerID,
+			query != "" && !strings.Contains(strings.ToLower(l.Title), query),
+			f.Condition != "" && l.Condition != f.Condition,
+			f.CreatedAfter != nil && !l.CreatedAt.After(*f.CreatedAfter),
+			f.CreatedBefore != nil && l.CreatedAt.After(*f.CreatedBefore),
+			f.PriceMinKGS != nil && (l.priceKGS == nil || *l.priceKGS < *f.PriceMinKGS),
+			f.PriceMaxKGS != nil && (l.priceKGS == nil || *l.priceKGS > *f.PriceMaxKGS):
+			continue
+		}
+		if c := f.After; c != nil {
+			tie := before(l.CreatedAt, l.ID, c.CreatedAt, c.ID)
+			if priceSort {
+				k := sortKey(l)
+				after := k > *c.Key
+				if f.Sort == repository.SortPriceDesc {
+					after = k < *c.Key
+				}
+				if !after && !(k == *c.Key && tie) {
+					continue
+				}
+			} else if !tie {
+				continue
+			}
+		}
+		found = append(found, l)
+	}
+
+	slices.SortFunc(found, func(a, b *listing) int {
+		switch f.Sort {
+		case repository.SortPriceAsc:
+			if c := cmp.Compare(sortKey(a), sortKey(b)); c != 0 {
+				return c
+			}
+		case repository.SortPriceDesc:
+			if c := cmp.Compare(sortKey(b), sortKey(a)); c != 0 {
+				return c
+			}
+		}
+		return newerFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	if f.Offset >= len(found) {
+		found = nil
+	} else if f.Offset > 0 {
+		found = found[f.Offset:]
+	}
+
+	out := make([]models.Product, 0, f.Limit)
+	for _, l := range limit(found, f.Limit) {
+		p, err := s.card(l)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// ===== ProductMediaRepository =====
+
+type productMediaRepo struct{ s *store }
+
+func (r productMediaRepo) ListByProductIDs(_ context.Context, ids []uuid.UUID) ([]models.ProductMedia, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []models.ProductMedia
+	for _, m := range s.media {
+		if slices.Contains(ids, m.ProductID) {
+			out = append(out, m)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b models.ProductMedia) int {
+		if c := bytes.Compare(a.ProductID[:], b.ProductID[:]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Sort, b.Sort)
+	})
+	return out, nil
+}
+
+// ===== CategoriesRepository =====
+
+type categoriesRepo struct{ s *store }
+
+func (c *category) out() models.Category {
+	return models.Category{ID: c.ID, Name: c.Name, Slug: c.Slug}
+}
+
+// sortedCategories — категории по (sort_order, name) или только по name.
+func sortedCategories(cs []*category, bySortOrder bool) []models.Category {
+	slices.SortFunc(cs, func(a, b *category) int {
+		if bySortOrder {
+			if c := cmp.Compare(a.SortOrder, b.SortOrder); c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	var out []models.Category
+	for _, c := range cs {
+		out = append(out, c.out())
+	}
+	return out
+}
+
+func (r categoriesRepo) ListRoots(context.Context) ([]models.Category, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var roots []*category
+	for _, c := range s.categories {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		}
+	}
+	return sortedCategories(roots, false), nil
+}
+
+func (r categoriesRepo) ListChildrenBySlug(_ context.Context, parentSlug string) ([]models.Category, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var children []*category
+	for _, c := range s.categories {
+		if c.ParentID != nil && !c.Inactive && s.categories[*c.ParentID].Slug == parentSlug {
+			children = append(children, c)
+		}
+	}
+	return sortedCategories(children, true), nil
+}
+
+func (r categoriesRepo) GetBySlug(_ context.Context, slug string) (models.Category, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, c := range s.categories {
+		if c.Slug == slug {
+			return c.out(), nil
+		}
+	}
+	return models.Category{}, pgx.ErrNoRows
+}
+
+func (r categoriesRepo) Tree(context.Context) ([]models.Category, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	children := map[uuid.UUID][]*category{}
+	var roots []*category
+	for _, c := range s.categories {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+	var build func(level []*category) []models.Category
+	build = func(level []*category) []models.Category {
+		out := sortedCategories(level, true)
+		for i := range out {
+			out[i].Children = build(children[out[i].ID])
+		}
+		return out
+	}
+	return build(roots), nil
+}
+
+// ===== BrandsRepository =====
+
+type brandsRepo struct{ s *store }
+
+// brandOut — бренд с алиасами по алфавиту (как array_agg ... ORDER BY alias).
+func brandOut(b *models.Brand) models.Brand {
+	v := *b
+	v.Aliases = slices.Sorted(slices.Values(b.Aliases))
+	if v.Aliases == nil {
+		v.Aliases = []string{}
+	}
+	return v
+}
+
+func (r brandsRepo) List(context.Context) ([]models.Brand, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []models.Brand
+	for _, b := range s.brands {
+		out = append(out, brandOut(b))
+	}
+	slices.SortFunc(out, func(a, b models.Brand) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+func (r brandsRepo) GetBySlug(_ context.Context, slug string) (models.Brand, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, b := range s.brands {
+		if b.Slug == slug {
+			return brandOut(b), nil
+		}
+	}
+	return models.Brand{}, pgx.ErrNoRows
+}
+
+func (r brandsRepo) Create(_ context.Context, b models.Brand) (models.Brand, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b.ID, b.CreatedAt = uuid.Nil, s.now()
+	return s.insertBrand(b)
+}
+
+func (r brandsRepo) Update(_ context.Context, b models.Brand) (models.Brand, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur, ok := s.brands[b.ID]
+	if !ok {
+		return b, pgx.ErrNoRows
+	}
+	if err := s.checkBrand(b); err != nil {
+		return b, err
+	}
+	cur.Name, cur.Slug, cur.Aliases = b.Name, b.Slug, slices.Clone(b.Aliases)
+	b.CreatedAt = cur.CreatedAt
+	return b, nil
+}
+
+func (r brandsRepo) Delete(_ context.Context, id uuid.UUID) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.brands[id]; !ok {
+		return pgx.ErrNoRows
+	}
+	for _, p := range s.products {
+		if p.BrandID != nil && *p.BrandID == id {
+			return violation(foreignKeyViolation, "product", "product_brand_id_fkey")
+		}
+	}
+	delete(s.brands, id)
+	return nil
+}
+
+func (r brandsRepo) FacetsByCategorySlug(_ context.Context, slug string) ([]models.BrandFacet, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := map[uuid.UUID]int{}
+	for _, l := range s.listings {
+		if l.Status != "active" || l.ProductID == nil || s.categories[l.CategoryID].Slug != slug {
+			continue
+		}
+		if cp, ok := s.products[*l.ProductID]; ok && cp.BrandID != nil {
+			counts[*cp.BrandID]++
+		}
+	}
+	var out []models.BrandFacet
+	for id, n := range counts {
+		b := *s.brands[id]
+		b.Aliases = nil
+		out = append(out, models.BrandFacet{Brand: b, Count: n})
+	}
+	slices.SortFunc(out, func(a, b models.BrandFacet) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Brand.Name, b.Brand.Name)
+	})
+	return out, nil
+}
+
+// ===== CatalogRepository =====
+
+type catalogRepo struct{ s *store }
+
+func (p *product) out() (models.CatalogProduct, error) {
+	v := p.CatalogProduct
+	v.BrandID = clonePtr(v.BrandID)
+	specs, err := jsonClone(v.Specs)
+	v.Specs = specs
+	return v, err
+}
+
+func (r catalogRepo) GetProduct(_ context.Context, id uuid.UUID) (models.ProductDetails, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var d models.ProductDetails
+	p, ok := s.products[id]
+	if !ok || !p.active {
+		return d, pgx.ErrNoRows
+	}
+	cp, err := p.out()
+	if err != nil {
+		return d, err
+	}
+	d.CatalogProduct = cp
+	d.CategorySlug = s.categories[p.CategoryID].Slug
+	if p.BrandID != nil {
+		b := s.brands[*p.BrandID]
+		d.Brand = &models.Brand{ID: b.ID, Name: b.Name, Slug: b.Slug}
+	}
+	return d, nil
+}
+
+func (r catalogRepo) OfferStats(_ context.Context, productID uuid.UUID) (models.OfferStats, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st := models.OfferStats{Prices: []models.PriceStats{}, ByCondition: map[string]int{}}
+	prices := map[string][]float64{}
+	for _, l := range s.listings {
+		if l.Status != "active" || l.ProductID == nil || *l.ProductID != productID {
+			continue
+		}
+		prices[l.CurrencyCode] = append(prices[l.CurrencyCode], l.PriceAmount)
+		st.ByCondition[l.Condition]++
+	}
+	for _, code := range slices.Sorted(maps.Keys(prices)) {
+		ps := prices[code]
+		slices.Sort(ps)
+		st.Prices = append(st.Prices, models.PriceStats{
+			CurrencyCode: code,
+			Min:          ps[0],
+			Median:       median(ps),
+			Max:          ps[len(ps)-1],
+			Count:        len(ps),
+		})
+		st.Count += len(ps)
+	}
+	return st, nil
+}
+
+// median — percentile_cont(0.5): среднее двух средних значений при чётном количестве.
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func (r catalogRepo) FindCandidates(_ context.Context, categoryID uuid.UUID, brandID *uuid.UUID) ([]models.CatalogProduct, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []*product
+	for _, p := range s.products {
+		if p.CategoryID != categoryID || !p.active {
+			continue
+		}
+		if brandID != nil && (p.BrandID == nil || *p.BrandID != *brandID) {
+			continue
+		}
+		found = append(found, p)
+	}
+	slices.SortFunc(found, func(a, b *product) int { return b.updatedAt.Compare(a.updatedAt) })
+
+	var out []models.CatalogProduct
+	for _, p := range limit(found, 500) {
+		cp, err := p.out()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cp)
+	}
+	return out, nil
+}
+
+// ===== ListingsRepository =====
+
+type listingsRepo struct{ s *store }
+
+func (r listingsRepo) Create(_ context.Context, l models.Listing) (models.Listing, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// id, статус и время задаёт база, покупателя ещё нет
+	l.ID, l.Status, l.CreatedAt, l.BuyerID = uuid.Nil, "active", s.now(), nil
+	return s.insertListing(l)
+}
+
+func (r listingsRepo) GetByID(_ context.Context, id uuid.UUID) (models.Listing, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.listings[id]
+	if !ok {
+		return models.Listing{}, pgx.ErrNoRows
+	}
+	return l.out()
+}
+
+func (r listingsRepo) SetProduct(_ context.Context, listingID, productID uuid.UUID) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.listings[listingID]
+	if !ok {
+		return nil
+	}
+	if _, ok := s.products[productID]; !ok {
+		return violation(foreignKeyViolation, "listing", "listing_product_id_fkey")
+	}
+	l.ProductID = ptr(productID)
+	return nil
+}
+
+func (r listingsRepo) Update(_ context.Context, l models.Listing) (models.Listing, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur, ok := s.listings[l.ID]
+	if !ok {
+		return models.Listing{}, pgx.ErrNoRows
+	}
+	next := cur.Listing
+	next.Title, next.Description = l.Title, l.Description
+	next.PriceAmount, next.CurrencyCode = round(l.PriceAmount, 2), l.CurrencyCode
+	next.Condition, next.LocationText, next.Status = l.Condition, l.LocationText, l.Status
+	next.BuyerID = clonePtr(l.BuyerID)
+	next.Attrs = l.Attrs
+	if next.Attrs == nil {
+		next.Attrs = map[string]any{}
+	}
+	if err := s.checkListing(next); err != nil {
+		return models.Listing{}, err
+	}
+	// отзывы ссылаются на (id, seller_id, buyer_id): сменить покупателя с отзывом нельзя
+	for _, rv := range s.reviews {
+		if rv.ListingID == next.ID && (next.BuyerID == nil || *next.BuyerID != rv.ReviewerID) {
+			return models.Listing{}, violation(foreignKeyViolation, "review", "review_listing_buyer_fkey")
+		}
+	}
+	attrs, err := jsonClone(next.Attrs)
+	if err != nil {
+		return models.Listing{}, err
+	}
+	next.Attrs = attrs
+	cur.Listing = next
+	cur.priceKGS = s.priceKGS(l.PriceAmount, l.CurrencyCode)
+	return cur.out()
+}
+
+// ===== ExchangeRatesRepository =====
+
+type exchangeRatesRepo struct{ s *store }
+
+func (r exchangeRatesRepo) List(context.Context) ([]models.ExchangeRate, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []models.ExchangeRate
+	for _, code := range slices.Sorted(maps.Keys(s.rates)) {
+		out = append(out, s.rates[code])
+	}
+	return out, nil
+}
+
+func (r exchangeRatesRepo) Upsert(_ context.Context, rates []models.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.upsertRates(rates)
+}
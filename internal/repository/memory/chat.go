@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+)
+
+// userRef — внешний ключ на app_user (nil — NULL, проверять нечего).
+func (s *store) userRef(id *uuid.UUID, table, constraint string) error {
+	if id == nil {
+		return nil
+	}
+	if _, ok := s.users[*id]; !ok {
+		return violation(foreignKeyViolation, table, constraint)
+	}
+	return nil
+}
+
+// ===== ChatSessionsRepository =====
+
+type chatSessionsRepo struct{ s *store }
+
+func (r chatSessionsRepo) Create(_ context.Context, cs models.ChatSession) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[cs.ID]; ok {
+		return violation(uniqueViolation, "chat_session", "chat_session_pkey")
+	}
+	if err := s.userRef(cs.UserID, "chat_session", "chat_session_user_id_fkey"); err != nil {
+		return err
+	}
+	cs.UserID = clonePtr(cs.UserID)
+	cs.CreatedAt, cs.ExpiresAt = ts(cs.CreatedAt), ts(cs.ExpiresAt)
+	cs.LastSeenAt = cs.CreatedAt
+	s.sessions[cs.ID] = &cs
+	return nil
+}
+
+func (r chatSessionsRepo) Get(_ context.Context, id string) (models.ChatSession, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cs, ok := s.sessions[id]
+	if !ok {
+		return models.ChatSession{}, pgx.ErrNoRows
+	}
+	v := *cs
+	v.UserID = clonePtr(v.UserID)
+	return v, nil
+}
+
+func (r chatSessionsRepo) Touch(_ context.Context, id string, expiresAt time.Time) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cs, ok := s.sessions[id]; ok {
+		cs.LastSeenAt, cs.ExpiresAt = s.now(), ts(expiresAt)
+	}
+	return nil
+}
+
+func (r chatSessionsRepo) BindUser(_ context.Context, id string, userID uuid.UUID) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs, ok := s.sessions[id]
+	bindSession := ok && cs.UserID == nil
+	var conv *models.Conversation
+	for _, c := range s.conversations {
+		if c.SessionID == id && c.UserID == nil {
+			conv = c
+		}
+	}
+	if bindSession || conv != nil {
+		if err := s.userRef(&userID, "chat_session", "chat_session_user_id_fkey"); err != nil {
+			return err
+		}
+	}
+	if bindSession {
+		cs.UserID = ptr(userID)
+	}
+	if conv != nil {
+		conv.UserID = ptr(userID)
+	}
+	return nil
+}
+
+func (r chatSessionsRepo) Delete(_ context.Context, id string) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+func (r chatSessionsRepo) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for id, cs := range s.sessions {
+		if cs.ExpiresAt.Before(now) {
+			delete(s.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ===== ConversationsRepository =====
+
+type conversationsRepo struct{ s *store }
+
+func (s *store) conversationBySession(sessionID string) (models.Conversation, bool) {
+	for _, c := range s.conversations {
+		if c.SessionID == sessionID {
+			v := *c
+			v.UserID = clonePtr(v.UserID)
+			return v, true
+		}
+	}
+	return models.Conversation{}, false
+}
+
+func (r conversationsRepo) GetOrCreateBySession(_ context.Context, sessionID string, userID *uuid.UUID) (models.Conversation, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.conversationBySession(sessionID); ok {
+		return c, nil
+	}
+	if err := s.userRef(userID, "conversation", "conversation_user_id_fkey"); err != nil {
+		return models.Conversation{}, err
+	}
+	c := models.Conversation{ID: uuid.New(), SessionID: sessionID, UserID: clonePtr(userID), CreatedAt: s.now()}
+	stored := c
+	stored.UserID = clonePtr(userID)
+	s.conversations[c.ID] = &stored
+	return c, nil
+}
+
+func (r conversationsRepo) GetBySession(_ context.Context, sessionID string) (models.Conversation, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if c, ok := s.conversationBySession(sessionID); ok {
+		return c, nil
+	}
+	return models.Conversation{}, pgx.ErrNoRows
+}
+
+// ===== MessagesRepository =====
+
+type messagesRepo struct{ s *store }
+
+func (r messagesRepo) Append(_ context.Context, conversationID uuid.UUID, role, text string, meta map[string]string) (uuid.UUID, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := uuid.New()
+	switch role {
+	case "user", "assistant", "system":
+	default:
+		return id, violation(checkViolation, "message", "message_role_check")
+	}
+	if _, ok := s.conversations[conversationID]; !ok {
+		return id, violation(foreignKeyViolation, "message", "message_conversation_id_fkey")
+	}
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	s.messages = append(s.messages, &models.Message{
+		ID:             id,
+		ConversationID: conversationID,
+		Role:           role,
+		Text:           text,
+		Meta:           cloneStrings(meta),
+		CreatedAt:      s.now(),
+	})
+	return id, nil
+}
+
+func (r messagesRepo) ListLast(ctx context.Context, conversationID uuid.UUID, limit int) ([]models.Message, error) {
+	return r.ListBefore(ctx, conversationID, nil, limit)
+}
+
+func (r messagesRepo) ListBefore(_ context.Context, conversationID uuid.UUID, cur *pagination.Cursor, n int) ([]models.Message, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []*models.Message
+	for _, m := range s.messages {
+		if m.ConversationID == conversationID && (cur == nil || before(m.CreatedAt, m.ID, cur.CreatedAt, cur.ID)) {
+			found = append(found, m)
+		}
+	}
+	slices.SortFunc(found, func(a, b *models.Message) int { return newerFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+
+	out := make([]models.Message, 0, max(n, 0))
+	for _, m := range limit(found, n) {
+		v := *m
+		v.Meta = cloneStrings(v.Meta)
+		out = append(out, v)
+	}
+	return reverse(out), nil
+}
+
+// ===== SearchRequestsRepository =====
+
+type searchRequestsRepo struct{ s *store }
+
+func (r searchRequestsRepo) Insert(_ context.Context, sr models.SearchRequest) (uuid.UUID, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sr.ID == uuid.Nil {
+		sr.ID = uuid.New()
+	}
+	if sr.CreatedAt.IsZero() {
+		sr.CreatedAt = s.now()
+	}
+	sr.UserID, sr.Params = clonePtr(sr.UserID), cloneStrings(sr.Params)
+	s.searches = append(s.searches, sr)
+	return sr.ID, nil
+}
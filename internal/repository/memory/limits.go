@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// ===== RateLimitsRepository =====
+
+type rateLimitsRepo struct{ s *store }
+
+// Take — тот же GCRA, что в Postgres-реализации: списание проходит, только если новый tat
+// каждого бакета не дальше now+window; иначе Retry-After — время до освобождения места во всех.
+func (r rateLimitsRepo) Take(_ context.Context, keys []string, now time.Time, every, window time.Duration) (bool, time.Duration, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now = ts(now)
+	next := make(map[string]time.Time, len(keys))
+	var wait time.Duration
+	for _, key := range keys {
+		start := now
+		if tat, ok := s.buckets[key]; ok && tat.After(now) {
+			start = tat
+		}
+		next[key] = start.Add(every)
+		if over := next[key].Sub(now) - window; over > wait {
+			wait = over
+		}
+	}
+	if wait > 0 {
+		return false, wait, nil
+	}
+	for key, tat := range next {
+		s.buckets[key] = tat
+	}
+	return true, 0, nil
+}
+
+func (r rateLimitsRepo) DeleteIdle(_ context.Context, before time.Time) (int64, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for key, tat := range s.buckets {
+		if tat.Before(before) {
+			delete(s.buckets, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ===== AIUsageRepository =====
+
+type aiUsageRepo struct{ s *store }
+
+func (r aiUsageRepo) AddUsage(_ context.Context, subject string, day time.Time, tokens int) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.usage[usageKey{subject, day.UTC().Format(time.DateOnly)}] += tokens
+	return nil
+}
+
+func (r aiUsageRepo) Usage(_ context.Context, subject string, day time.Time) (int, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.usage[usageKey{subject, day.UTC().Format(time.DateOnly)}], nil
+}
@@ -0,0 +1,305 @@
+// Package memory — RepositorySet в памяти процесса: для тестов и демо-режима (-demo) без Postgres.
+//
+// Поведение повторяет Postgres-реализацию (общий набор тестов — repository/repotest):
+// порядок выдачи, keyset-пагинация, pgx.ErrNoRows для «не найдено», ограничения схемы
+// (уникальность, внешние ключи, CHECK) — ошибками *pgconn.PgError с теми же SQLSTATE,
+// поэтому apperror отвечает на них так же (404, 409). Все репозитории набора работают
+// с одним хранилищем под одним мьютексом; записи копируются на входе и выходе.
+// Поиск — перебором: рассчитано на десятки и сотни записей, не на прод.
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository"
+	"github.com/btynybekov/marketplace/internal/repository/fixtures"
+)
+
+// store — все «таблицы». Методы без блокировки; репозитории берут s.mu сами.
+type store struct {
+	mu   sync.RWMutex
+	last time.Time // последнее выданное now(): время строго растёт
+
+	categories    map[uuid.UUID]*category
+	brands        map[uuid.UUID]*models.Brand
+	products      map[uuid.UUID]*product
+	media         []models.ProductMedia
+	listings      map[uuid.UUID]*listing
+	rates         map[string]models.ExchangeRate
+	users         map[uuid.UUID]*models.UserProfile
+	userRoles     map[uuid.UUID]map[string]bool
+	sessions      map[string]*models.ChatSession
+	conversations map[uuid.UUID]*models.Conversation
+	messages      []*models.Message
+	searches      []models.SearchRequest
+	favorites     map[favoriteKey]time.Time
+	savedSearches map[uuid.UUID]*models.SavedSearch
+	notifications map[uuid.UUID]*models.Notification
+	threads       map[uuid.UUID]*thread
+	dms           []*models.DirectMessage
+	blocks        map[[2]uuid.UUID]bool
+	reports       map[uuid.UUID]*models.AbuseReport
+	reviews       map[uuid.UUID]*models.Review
+	buckets       map[string]time.Time
+	usage         map[usageKey]int
+}
+
+type category struct {
+	fixtures.Category
+}
+
+type product struct {
+	models.CatalogProduct
+	active    bool
+	updatedAt time.Time
+}
+
+type listing struct {
+	models.Listing
+	priceKGS *float64
+}
+
+type thread struct {
+	models.Thread // без ListingTitle, LastMessage и Unread: они вычисляются при чтении
+}
+
+type favoriteKey struct{ userID, listingID uuid.UUID }
+
+type usageKey struct {
+	subject string
+	day     string
+}
+
+// Repos — набор репозиториев над одним хранилищем.
+type Repos struct {
+	s *store
+}
+
+var _ repository.RepositorySet = (*Repos)(nil)
+
+// New — хранилище с данными d (как после миграций и загрузки d в Postgres): справочник ролей
+// и курс KGS есть всегда. Ошибка — d нарушает ограничения схемы (дубликат slug, ссылка на
+// несуществующую запись).
+func New(d fixtures.Data) (*Repos, error) {
+	s := &store{
+		categories:    map[uuid.UUID]*category{},
+		brands:        map[uuid.UUID]*models.Brand{},
+		products:      map[uuid.UUID]*product{},
+		listings:      map[uuid.UUID]*listing{},
+		rates:         map[string]models.ExchangeRate{},
+		users:         map[uuid.UUID]*models.UserProfile{},
+		userRoles:     map[uuid.UUID]map[string]bool{},
+		sessions:      map[string]*models.ChatSession{},
+		conversations: map[uuid.UUID]*models.Conversation{},
+		favorites:     map[favoriteKey]time.Time{},
+		savedSearches: map[uuid.UUID]*models.SavedSearch{},
+		notifications: map[uuid.UUID]*models.Notification{},
+		threads:       map[uuid.UUID]*thread{},
+		blocks:        map[[2]uuid.UUID]bool{},
+		reports:       map[uuid.UUID]*models.AbuseReport{},
+		reviews:       map[uuid.UUID]*models.Review{},
+		buckets:       map[string]time.Time{},
+		usage:         map[usageKey]int{},
+	}
+	s.rates["KGS"] = models.ExchangeRate{CurrencyCode: "KGS", RateToKGS: 1, Source: "builtin", UpdatedAt: s.now()}
+	if err := s.load(d); err != nil {
+		return nil, fmt.Errorf("fixtures: %w", err)
+	}
+	return &Repos{s: s}, nil
+}
+
+// load — данные d в порядке зависимостей; курсы — до объявлений (price_kgs).
+func (s *store) load(d fixtures.Data) error {
+	for _, c := range d.Categories {
+		if err := s.insertCategory(c); err != nil {
+			return fmt.Errorf("category %q: %w", c.Slug, err)
+		}
+	}
+	for _, b := range d.Brands {
+		if _, err := s.insertBrand(b); err != nil {
+			return fmt.Errorf("brand %q: %w", b.Slug, err)
+		}
+	}
+	for _, u := range d.Users {
+		if err := s.insertUser(u); err != nil {
+			return fmt.Errorf("user %s: %w", u.ID, err)
+		}
+	}
+	for _, p := range d.Products {
+		if err := s.insertProduct(p); err != nil {
+			return fmt.Errorf("product %q: %w", p.Title, err)
+		}
+	}
+	for _, m := range d.Media {
+		if err := s.insertMedia(m); err != nil {
+			return fmt.Errorf("media %q: %w", m.URL, err)
+		}
+	}
+	rates := slices.Clone(d.Rates)
+	for i := range rates {
+		if rates[i].UpdatedAt.IsZero() {
+			rates[i].UpdatedAt = s.now()
+		}
+	}
+	if err := s.upsertRates(rates); err != nil {
+		return fmt.Errorf("rates: %w", err)
+	}
+	for _, l := range d.Listings {
+		if _, err := s.insertListing(l); err != nil {
+			return fmt.Errorf("listing %q: %w", l.Title, err)
+		}
+	}
+	return nil
+}
+
+func (r *Repos) Products() repository.ProductsRepository             { return productsRepo{r.s} }
+func (r *Repos) ProductMedia() repository.ProductMediaRepository     { return productMediaRepo{r.s} }
+func (r *Repos) Categories() repository.CategoriesRepository         { return categoriesRepo{r.s} }
+func (r *Repos) Brands() repository.BrandsRepository                 { return brandsRepo{r.s} }
+func (r *Repos) Catalog() repository.CatalogRepository               { return catalogRepo{r.s} }
+func (r *Repos) Listings() repository.ListingsRepository             { return listingsRepo{r.s} }
+func (r *Repos) ExchangeRates() repository.ExchangeRatesRepository   { return exchangeRatesRepo{r.s} }
+func (r *Repos) ChatSessions() repository.ChatSessionsRepository     { return chatSessionsRepo{r.s} }
+func (r *Repos) Conversations() repository.ConversationsRepository   { return conversationsRepo{r.s} }
+func (r *Repos) Messages() repository.MessagesRepository             { return messagesRepo{r.s} }
+func (r *Repos) SearchRequests() repository.SearchRequestsRepository { return searchRequestsRepo{r.s} }
+func (r *Repos) Favorites() repository.FavoritesRepository           { return favoritesRepo{r.s} }
+func (r *Repos) SavedSearches() repository.SavedSearchesRepository   { return savedSearchesRepo{r.s} }
+func (r *Repos) Notifications() repository.NotificationsRepository   { return notificationsRepo{r.s} }
+func (r *Repos) Threads() repository.ThreadsRepository               { return threadsRepo{r.s} }
+func (r *Repos) Blocks() repository.BlocksRepository                 { return blocksRepo{r.s} }
+func (r *Repos) Reports() repository.ReportsRepository               { return reportsRepo{r.s} }
+func (r *Repos) Roles() repository.RolesRepository                   { return rolesRepo{r.s} }
+func (r *Repos) Users() repository.UsersRepository                   { return usersRepo{r.s} }
+func (r *Repos) Reviews() repository.ReviewsRepository               { return reviewsRepo{r.s} }
+func (r *Repos) RateLimits() repository.RateLimitsRepository         { return rateLimitsRepo{r.s} }
+func (r *Repos) AIUsage() repository.AIUsageRepository               { return aiUsageRepo{r.s} }
+
+// ===== Общие помощники =====
+
+// now — аналог now() в Postgres (микросекунды); строго растёт, чтобы записи,
+// созданные подряд, не совпадали по времени и порядок выдачи был порядком вставки.
+// Вызывается под s.mu.Lock.
+func (s *store) now() time.Time {
+	t := time.Now().UTC().Truncate(time.Microsecond)
+	if !t.After(s.last) {
+		t = s.last.Add(time.Microsecond)
+	}
+	s.last = t
+	return t
+}
+
+// ts — время от вызывающего с точностью timestamptz.
+func ts(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// Коды SQLSTATE — те же, что разбирает apperror.
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	checkViolation      = "23514"
+)
+
+// violation — ошибка нарушения ограничения в том виде, в каком её вернул бы pgx.
+func violation(code, table, constraint string) error {
+	msg := map[string]string{
+		uniqueViolation:     "duplicate key value violates unique constraint",
+		foreignKeyViolation: "insert or update violates foreign key constraint",
+		checkViolation:      "new row violates check constraint",
+	}[code]
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           code,
+		Message:        fmt.Sprintf("%s %q", msg, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+// before — (at, id) < (cAt, cID): сравнение строк, как в keyset-условиях SQL.
+func before(at time.Time, id uuid.UUID, cAt time.Time, cID uuid.UUID) bool {
+	if !at.Equal(cAt) {
+		return at.Before(cAt)
+	}
+	return bytes.Compare(id[:], cID[:]) < 0
+}
+
+// newerFirst — порядок ORDER BY created_at DESC, id DESC.
+func newerFirst(aAt time.Time, aID uuid.UUID, bAt time.Time, bID uuid.UUID) int {
+	if c := bAt.Compare(aAt); c != 0 {
+		return c
+	}
+	return bytes.Compare(bID[:], aID[:])
+}
+
+// reverse — страница, выбранная от новых к старым, в порядке по возрастанию времени.
+func reverse[T any](s []T) []T {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+	return s
+}
+
+// limit — LIMIT n (n < 0 — без ограничения).
+func limit[T any](s []T, n int) []T {
+	if n >= 0 && len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// jsonClone — копия через JSON, как у значения, прошедшего через колонку JSONB:
+// вложенные значения не разделяются, числа становятся float64.
+func jsonClone[T any](v T) (T, error) {
+	var out T
+	b, err := json.Marshal(v)
+	if err != nil {
+		return out, err
+	}
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+// jsonConvert — JSONB-значение src в тип dst (как Scan колонки в другую Go-структуру).
+func jsonConvert(src, dst any) error {
+	b, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
+
+func cloneStrings(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	return maps.Clone(m)
+}
+
+// round — округление NUMERIC(_, digits): половина — от нуля.
+func round(v float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(v*p) / p
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
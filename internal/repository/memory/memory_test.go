@@ -0,0 +1,94 @@
+package memory_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/btynybekov/marketplace/internal/repository"
+	"github.com/btynybekov/marketplace/internal/repository/fixtures"
+	"github.com/btynybekov/marketplace/internal/repository/memory"
+	"github.com/btynybekov/marketplace/internal/repository/repotest"
+)
+
+func newRepos(t *testing.T, d fixtures.Data) repository.RepositorySet {
+	t.Helper()
+	r, err := memory.New(d)
+	if err != nil {
+		t.Fatalf("memory.New: %v", err)
+	}
+	return r
+}
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, newRepos)
+}
+
+func TestDemoFixtures(t *testing.T) {
+	d, err := fixtures.Demo()
+	if err != nil {
+		t.Fatalf("fixtures.Demo: %v", err)
+	}
+	r := newRepos(t, d)
+	ctx := context.Background()
+
+	items, err := r.Products().List(ctx, repository.ProductFilter{CategorySlug: "smartphones"})
+	if err != nil || len(items) == 0 {
+		t.Fatalf("demo smartphones = %d items, err %v", len(items), err)
+	}
+	for _, p := range items {
+		if p.PriceKGS == nil {
+			t.Errorf("demo listing %q has no price in KGS", p.Title)
+		}
+	}
+	b, err := r.Brands().GetBySlug(ctx, "apple")
+	if err != nil || len(b.Aliases) == 0 {
+		t.Fatalf("demo brand apple = %+v, err %v", b, err)
+	}
+}
+
+func TestInvalidFixtures(t *testing.T) {
+	d := repotest.Data()
+	d.Listings = append(d.Listings, d.Listings[0])
+	if _, err := memory.New(d); err == nil {
+		t.Fatal("memory.New with duplicate listing id: want error")
+	}
+}
+
+// TestConcurrentAccess — запись и чтение из разных горутин (смысл — под -race).
+func TestConcurrentAccess(t *testing.T) {
+	r := newRepos(t, repotest.Data())
+	ctx := context.Background()
+	conv, err := r.Conversations().GetOrCreateBySession(ctx, "sess", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				if _, err := r.Messages().Append(ctx, conv.ID, "user", "hi", nil); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := r.Messages().ListLast(ctx, conv.ID, 10); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := r.Products().List(ctx, repository.ProductFilter{Sort: repository.SortPriceAsc}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	msgs, err := r.Messages().ListLast(ctx, conv.ID, 1000)
+	if err != nil || len(msgs) != 400 {
+		t.Fatalf("messages = %d, err %v; want 400", len(msgs), err)
+	}
+}
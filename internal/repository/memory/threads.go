@@ -0,0 +1,340 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+)
+
+// ===== ThreadsRepository =====
+
+type threadsRepo struct{ s *store }
+
+// threadOut — переписка с заголовком объявления и текстом последнего сообщения (threadSelect).
+func (s *store) threadOut(t *thread) models.Thread {
+	v := t.Thread
+	v.LastMessageAt = clonePtr(v.LastMessageAt)
+	v.ListingTitle = s.listings[t.ListingID].Title
+	var last *models.DirectMessage
+	for _, m := range s.dms {
+		if m.ThreadID == t.ID && (last == nil || newerFirst(m.CreatedAt, m.ID, last.CreatedAt, last.ID) < 0) {
+			last = m
+		}
+	}
+	if last != nil {
+		v.LastMessage = last.Body
+	}
+	return v
+}
+
+func (s *store) findThread(listingID, buyerID uuid.UUID) *thread {
+	for _, t := range s.threads {
+		if t.ListingID == listingID && t.BuyerID == buyerID {
+			return t
+		}
+	}
+	return nil
+}
+
+func (r threadsRepo) GetOrCreate(_ context.Context, listingID, buyerID, sellerID uuid.UUID) (models.Thread, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// CHECK проверяется до ON CONFLICT, внешние ключи — только у вставленной строки
+	if buyerID == sellerID {
+		return models.Thread{}, violation(checkViolation, "dm_thread", "dm_thread_check")
+	}
+	t := s.findThread(listingID, buyerID)
+	if t == nil {
+		if _, ok := s.listings[listingID]; !ok {
+			return models.Thread{}, violation(foreignKeyViolation, "dm_thread", "dm_thread_listing_id_fkey")
+		}
+		if err := s.userRef(&buyerID, "dm_thread", "dm_thread_buyer_id_fkey"); err != nil {
+			return models.Thread{}, err
+		}
+		if err := s.userRef(&sellerID, "dm_thread", "dm_thread_seller_id_fkey"); err != nil {
+			return models.Thread{}, err
+		}
+		t = &thread{models.Thread{ID: uuid.New(), ListingID: listingID, BuyerID: buyerID, SellerID: sellerID, CreatedAt: s.now()}}
+		s.threads[t.ID] = t
+	}
+	return s.threadOut(t), nil
+}
+
+func (r threadsRepo) Get(_ context.Context, id uuid.UUID) (models.Thread, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.threads[id]
+	if !ok {
+		return models.Thread{}, pgx.ErrNoRows
+	}
+	return s.threadOut(t), nil
+}
+
+func (r threadsRepo) Find(_ context.Context, listingID, buyerID uuid.UUID) (models.Thread, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t := s.findThread(listingID, buyerID)
+	if t == nil {
+		return models.Thread{}, pgx.ErrNoRows
+	}
+	return s.threadOut(t), nil
+}
+
+// unread — непрочитанные userID сообщения второго участника переписки.
+func (s *store) unread(threadID, userID uuid.UUID) int {
+	n := 0
+	for _, m := range s.dms {
+		if m.ThreadID == threadID && m.SenderID != userID && m.ReadAt == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (r threadsRepo) ListByUser(_ context.Context, userID uuid.UUID) ([]models.Thread, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []models.Thread
+	for _, t := range s.threads {
+		if t.BuyerID == userID || t.SellerID == userID {
+			v := s.threadOut(t)
+			v.Unread = s.unread(t.ID, userID)
+			out = append(out, v)
+		}
+	}
+	// ORDER BY COALESCE(last_message_at, created_at) DESC
+	activity := func(t models.Thread) int64 {
+		if t.LastMessageAt != nil {
+			return t.LastMessageAt.UnixMicro()
+		}
+		return t.CreatedAt.UnixMicro()
+	}
+	slices.SortFunc(out, func(a, b models.Thread) int {
+		if c := activity(b) - activity(a); c != 0 {
+			if c > 0 {
+				return 1
+			}
+			return -1
+		}
+		return bytes.Compare(b.ID[:], a.ID[:])
+	})
+	return out, nil
+}
+
+func (r threadsRepo) AppendMessage(_ context.Context, threadID, senderID uuid.UUID, body string) (models.DirectMessage, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threads[threadID]
+	if !ok {
+		return models.DirectMessage{}, violation(foreignKeyViolation, "dm_message", "dm_message_thread_id_fkey")
+	}
+	if err := s.userRef(&senderID, "dm_message", "dm_message_sender_id_fkey"); err != nil {
+		return models.DirectMessage{}, err
+	}
+	m := models.DirectMessage{ID: uuid.New(), ThreadID: threadID, SenderID: senderID, Body: body, CreatedAt: s.now()}
+	stored := m
+	s.dms = append(s.dms, &stored)
+	t.LastMessageAt = ptr(m.CreatedAt)
+	return m, nil
+}
+
+func (r threadsRepo) ListMessages(_ context.Context, threadID uuid.UUID, cur *pagination.Cursor, n int) ([]models.DirectMessage, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []*models.DirectMessage
+	for _, m := range s.dms {
+		if m.ThreadID == threadID && (cur == nil || before(m.CreatedAt, m.ID, cur.CreatedAt, cur.ID)) {
+			found = append(found, m)
+		}
+	}
+	slices.SortFunc(found, func(a, b *models.DirectMessage) int { return newerFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+
+	out := make([]models.DirectMessage, 0, max(n, 0))
+	for _, m := range limit(found, n) {
+		v := *m
+		v.ReadAt = clonePtr(v.ReadAt)
+		out = append(out, v)
+	}
+	return reverse(out), nil
+}
+
+func (r threadsRepo) MarkRead(_ context.Context, threadID, readerID uuid.UUID) (int, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now, n := s.now(), 0
+	for _, m := range s.dms {
+		if m.ThreadID == threadID && m.SenderID != readerID && m.ReadAt == nil {
+			m.ReadAt = ptr(now)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r threadsRepo) UnreadCount(_ context.Context, userID uuid.UUID) (int, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := 0
+	for _, t := range s.threads {
+		if t.BuyerID == userID || t.SellerID == userID {
+			n += s.unread(t.ID, userID)
+		}
+	}
+	return n, nil
+}
+
+// ===== BlocksRepository =====
+
+type blocksRepo struct{ s *store }
+
+func (r blocksRepo) Block(_ context.Context, blockerID, blockedID uuid.UUID) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := [2]uuid.UUID{blockerID, blockedID}
+	if s.blocks[key] {
+		return nil
+	}
+	if err := s.userRef(&blockerID, "user_block", "user_block_blocker_id_fkey"); err != nil {
+		return err
+	}
+	if err := s.userRef(&blockedID, "user_block", "user_block_blocked_id_fkey"); err != nil {
+		return err
+	}
+	s.blocks[key] = true
+	return nil
+}
+
+func (r blocksRepo) Unblock(_ context.Context, blockerID, blockedID uuid.UUID) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blocks, [2]uuid.UUID{blockerID, blockedID})
+	return nil
+}
+
+func (r blocksRepo) IsBlocked(_ context.Context, a, b uuid.UUID) (bool, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.blocks[[2]uuid.UUID{a, b}] || s.blocks[[2]uuid.UUID{b, a}], nil
+}
+
+// ===== ReportsRepository =====
+
+type reportsRepo struct{ s *store }
+
+func reportOut(rep *models.AbuseReport) models.AbuseReport {
+	v := *rep
+	v.ResolvedBy, v.ResolvedAt = clonePtr(v.ResolvedBy), clonePtr(v.ResolvedAt)
+	return v
+}
+
+func (r reportsRepo) Create(_ context.Context, rep models.AbuseReport) (models.AbuseReport, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch rep.TargetType {
+	case "user", "listing", "thread", "message", "review":
+	default:
+		return rep, violation(checkViolation, "abuse_report", "abuse_report_target_type_check")
+	}
+	if !s.reportTargetExists(rep.TargetType, rep.TargetID) {
+		return rep, pgx.ErrNoRows
+	}
+	if err := s.userRef(&rep.ReporterID, "abuse_report", "abuse_report_reporter_id_fkey"); err != nil {
+		return rep, err
+	}
+	rep.ID, rep.Status, rep.CreatedAt = uuid.New(), "open", s.now()
+	stored := models.AbuseReport{
+		ID: rep.ID, ReporterID: rep.ReporterID, TargetType: rep.TargetType, TargetID: rep.TargetID,
+		Reason: rep.Reason, Status: rep.Status, CreatedAt: rep.CreatedAt,
+	}
+	s.reports[rep.ID] = &stored
+	return rep, nil
+}
+
+// reportTargetExists — есть ли объект, на который жалуются. Вызывается под s.mu.
+func (s *store) reportTargetExists(targetType string, id uuid.UUID) bool {
+	switch targetType {
+	case "user":
+		return s.users[id] != nil
+	case "listing":
+		return s.listings[id] != nil
+	case "thread":
+		return s.threads[id] != nil
+	case "message":
+		return slices.ContainsFunc(s.dms, func(m *models.DirectMessage) bool { return m.ID == id })
+	case "review":
+		return s.reviews[id] != nil
+	}
+	return false
+}
+
+func (r reportsRepo) List(_ context.Context, status string, n int) ([]models.AbuseReport, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []*models.AbuseReport
+	for _, rep := range s.reports {
+		if status == "" || rep.Status == status {
+			found = append(found, rep)
+		}
+	}
+	// ORDER BY created_at, id
+	slices.SortFunc(found, func(a, b *models.AbuseReport) int { return newerFirst(b.CreatedAt, b.ID, a.CreatedAt, a.ID) })
+
+	var out []models.AbuseReport
+	for _, rep := range limit(found, n) {
+		out = append(out, reportOut(rep))
+	}
+	return out, nil
+}
+
+func (r reportsRepo) Resolve(_ context.Context, id uuid.UUID, status string, moderatorID uuid.UUID) (models.AbuseReport, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rep, ok := s.reports[id]
+	if !ok {
+		return models.AbuseReport{}, pgx.ErrNoRows
+	}
+	switch status {
+	case "open", "resolved", "rejected":
+	default:
+		return models.AbuseReport{}, violation(checkViolation, "abuse_report", "abuse_report_status_check")
+	}
+	if err := s.userRef(&moderatorID, "abuse_report", "abuse_report_resolved_by_fkey"); err != nil {
+		return models.AbuseReport{}, err
+	}
+	rep.Status, rep.ResolvedBy, rep.ResolvedAt = status, ptr(moderatorID), ptr(s.now())
+	return reportOut(rep), nil
+}
@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/repository/fixtures"
+)
+
+// rolePermissions — справочник role_permission из миграции 0009.
+var rolePermissions = map[string][]string{
+	"buyer":     nil,
+	"seller":    {"listings.create", "listings.edit.own"},
+	"moderator": {"admin.access", "listings.edit.any", "reports.review"},
+	"admin":     {"admin.access", "listings.edit.any", "brands.manage", "reports.review", "roles.manage"},
+}
+
+// defaultRoles — роли с is_default: триггер выдаёт их каждому новому пользователю.
+var defaultRoles = []string{"buyer", "seller"}
+
+// insertUserLocked — новая строка app_user с ролями по умолчанию (триггер grant_default_roles).
+func (s *store) insertUserLocked(p models.UserProfile) *models.UserProfile {
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = s.now()
+	}
+	p.CreatedAt = ts(p.CreatedAt)
+	s.users[p.ID] = &p
+	roles := map[string]bool{}
+	for _, role := range defaultRoles {
+		roles[role] = true
+	}
+	s.userRoles[p.ID] = roles
+	return &p
+}
+
+func (s *store) insertUser(u fixtures.User) error {
+	if _, ok := s.users[u.ID]; ok {
+		return violation(uniqueViolation, "app_user", "app_user_pkey")
+	}
+	for _, role := range u.Roles {
+		if _, ok := rolePermissions[role]; !ok {
+			return violation(foreignKeyViolation, "user_role", "user_role_role_id_fkey")
+		}
+	}
+	s.insertUserLocked(u.UserProfile)
+	for _, role := range u.Roles {
+		s.userRoles[u.ID][role] = true
+	}
+	return nil
+}
+
+// ===== RolesRepository =====
+
+type rolesRepo struct{ s *store }
+
+func (r rolesRepo) HasPermission(_ context.Context, userID uuid.UUID, perm string) (bool, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for role := range s.userRoles[userID] {
+		if slices.Contains(rolePermissions[role], perm) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r rolesRepo) Access(_ context.Context, userID uuid.UUID) (models.UserAccess, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a := models.UserAccess{UserID: userID, Roles: []string{}, Permissions: []string{}}
+	for role := range s.userRoles[userID] {
+		a.Roles = append(a.Roles, role)
+		a.Permissions = append(a.Permissions, rolePermissions[role]...)
+	}
+	slices.Sort(a.Roles)
+	slices.Sort(a.Permissions)
+	a.Permissions = slices.Compact(a.Permissions)
+	return a, nil
+}
+
+func (r rolesRepo) Grant(_ context.Context, userID uuid.UUID, role string) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := rolePermissions[role]; !ok {
+		return pgx.ErrNoRows
+	}
+	if err := s.userRef(&userID, "user_role", "user_role_user_id_fkey"); err != nil {
+		return err
+	}
+	s.userRoles[userID][role] = true
+	return nil
+}
+
+func (r rolesRepo) Revoke(_ context.Context, userID uuid.UUID, role string) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.userRoles[userID], role)
+	return nil
+}
+
+// ===== UsersRepository =====
+
+type usersRepo struct{ s *store }
+
+func (r usersRepo) GetProfile(_ context.Context, id uuid.UUID) (models.UserProfile, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.users[id]
+	if !ok {
+		return models.UserProfile{}, pgx.ErrNoRows
+	}
+	return *p, nil
+}
+
+func (r usersRepo) SaveProfile(_ context.Context, p models.UserProfile) (models.UserProfile, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// телефон профилем не меняется: у нового пользователя его нет, у существующего — остаётся
+	u, ok := s.users[p.ID]
+	if !ok {
+		u = s.insertUserLocked(models.UserProfile{ID: p.ID})
+	}
+	u.DisplayName, u.AvatarURL, u.LocationText, u.Contact = p.DisplayName, p.AvatarURL, p.LocationText, p.Contact
+	return *u, nil
+}
+
+// ===== ReviewsRepository =====
+
+type reviewsRepo struct{ s *store }
+
+func (r reviewsRepo) Upsert(_ context.Context, rv models.Review) (models.Review, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rv.Rating < 1 || rv.Rating > 5 {
+		return rv, violation(checkViolation, "review", "review_rating_check")
+	}
+	if rv.ReviewerID == rv.SellerID {
+		return rv, violation(checkViolation, "review", "review_check")
+	}
+	l, ok := s.listings[rv.ListingID]
+	if !ok {
+		return rv, violation(foreignKeyViolation, "review", "review_listing_id_fkey")
+	}
+	if err := s.userRef(&rv.ReviewerID, "review", "review_reviewer_id_fkey"); err != nil {
+		return rv, err
+	}
+	if err := s.userRef(&rv.SellerID, "review", "review_seller_id_fkey"); err != nil {
+		return rv, err
+	}
+	// отзыв — только от покупателя объявления и о его продавце
+	if l.BuyerID == nil || *l.BuyerID != rv.ReviewerID || l.SellerID != rv.SellerID {
+		return rv, violation(foreignKeyViolation, "review", "review_listing_buyer_fkey")
+	}
+
+	now := s.now()
+	for _, old := range s.reviews {
+		if old.ListingID == rv.ListingID && old.ReviewerID == rv.ReviewerID {
+			// ON CONFLICT DO UPDATE: продавец и created_at остаются прежними
+			old.Rating, old.Body, old.UpdatedAt = rv.Rating, rv.Body, now
+			rv.ID, rv.CreatedAt, rv.UpdatedAt = old.ID, old.CreatedAt, old.UpdatedAt
+			return rv, nil
+		}
+	}
+	rv.ID, rv.CreatedAt, rv.UpdatedAt = uuid.New(), now, now
+	stored := rv
+	stored.ReviewerName = ""
+	s.reviews[rv.ID] = &stored
+	return rv, nil
+}
+
+func (r reviewsRepo) Delete(_ context.Context, listingID, reviewerID uuid.UUID) error {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, rv := range s.reviews {
+		if rv.ListingID == listingID && rv.ReviewerID == reviewerID {
+			delete(s.reviews, id)
+		}
+	}
+	return nil
+}
+
+func (r reviewsRepo) Exists(_ context.Context, listingID uuid.UUID) (bool, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rv := range s.reviews {
+		if rv.ListingID == listingID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r reviewsRepo) ListBySeller(_ context.Context, sellerID uuid.UUID, after *pagination.Cursor, n int) ([]models.Review, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []*models.Review
+	for _, rv := range s.reviews {
+		if rv.SellerID == sellerID && (after == nil || before(rv.CreatedAt, rv.ID, after.CreatedAt, after.ID)) {
+			found = append(found, rv)
+		}
+	}
+	slices.SortFunc(found, func(a, b *models.Review) int { return newerFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID) })
+
+	out := make([]models.Review, 0, max(n, 0))
+	for _, rv := range limit(found, n) {
+		v := *rv
+		v.ReviewerName = s.users[rv.ReviewerID].DisplayName
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (r reviewsRepo) Stats(_ context.Context, sellerID uuid.UUID) (models.RatingStats, error) {
+	s := r.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var st models.RatingStats
+	sum := 0
+	for _, rv := range s.reviews {
+		if rv.SellerID == sellerID {
+			st.Distribution[rv.Rating-1]++
+			st.Count++
+			sum += rv.Rating
+		}
+	}
+	if st.Count > 0 {
+		st.Average = round(float64(sum)/float64(st.Count), 2)
+	}
+	return st, nil
+}
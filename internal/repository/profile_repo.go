@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+)
+
+// ===== UsersRepository impl =====
+
+type usersRepo struct{ db *pgxpool.Pool }
+
+const profileColumns = `id, COALESCE(display_name, ''), COALESCE(avatar_url, ''), COALESCE(location_text, ''),
+	COALESCE(phone, ''), contact_prefs, created_at`
+
+func (r *usersRepo) GetProfile(ctx context.Context, id uuid.UUID) (models.UserProfile, error) {
+	var p models.UserProfile
+	err := r.db.QueryRow(ctx, `SELECT `+profileColumns+` FROM app_user WHERE id = $1`, id).
+		Scan(&p.ID, &p.DisplayName, &p.AvatarURL, &p.LocationText, &p.Phone, &p.Contact, &p.CreatedAt)
+	return p, err
+}
+
+func (r *usersRepo) SaveProfile(ctx context.Context, p models.UserProfile) (models.UserProfile, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO app_user (id, display_name, avatar_url, location_text, contact_prefs)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), $5)
+		ON CONFLICT (id) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			avatar_url = EXCLUDED.avatar_url,
+			location_text = EXCLUDED.location_text,
+			contact_prefs = EXCLUDED.contact_prefs,
+			updated_at = now()
+		RETURNING `+profileColumns,
+		p.ID, p.DisplayName, p.AvatarURL, p.LocationText, p.Contact).
+		Scan(&p.ID, &p.DisplayName, &p.AvatarURL, &p.LocationText, &p.Phone, &p.Contact, &p.CreatedAt)
+	return p, err
+}
+
+// ===== ReviewsRepository impl =====
+
+type reviewsRepo struct{ db *pgxpool.Pool }
+
+func (r *reviewsRepo) Upsert(ctx context.Context, rv models.Review) (models.Review, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO review (listing_id, reviewer_id, seller_id, rating, body)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (listing_id, reviewer_id) DO UPDATE SET
+			rating = EXCLUDED.rating, body = EXCLUDED.body, updated_at = now()
+		RETURNING id, created_at, updated_at
+	`, rv.ListingID, rv.ReviewerID, rv.SellerID, rv.Rating, rv.Body).Scan(&rv.ID, &rv.CreatedAt, &rv.UpdatedAt)
+	return rv, err
+}
+
+func (r *reviewsRepo) Delete(ctx context.Context, listingID, reviewerID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM review WHERE listing_id = $1 AND reviewer_id = $2`, listingID, reviewerID)
+	return err
+}
+
+func (r *reviewsRepo) Exists(ctx context.Context, listingID uuid.UUID) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM review WHERE listing_id = $1)`, listingID).Scan(&ok)
+	return ok, err
+}
+
+func (r *reviewsRepo) ListBySeller(ctx context.Context, sellerID uuid.UUID, after *pagination.Cursor, limit int) ([]models.Review, error) {
+	args := []any{sellerID, limit}
+	cond := ""
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		cond = "AND (rv.created_at, rv.id) < ($3, $4)"
+	}
+	rows, err := r.db.Query(ctx, `
+		SELECT rv.id, rv.listing_id, rv.reviewer_id, COALESCE(u.display_name, ''), rv.seller_id,
+		       rv.rating, rv.body, rv.created_at, rv.updated_at
+		FROM review rv
+		JOIN app_user u ON u.id = rv.reviewer_id
+		WHERE rv.seller_id = $1 `+cond+`
+		ORDER BY rv.created_at DESC, rv.id DESC
+		LIMIT $2
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.Review, 0, limit)
+	for rows.Next() {
+		var rv models.Review
+		if err := rows.Scan(&rv.ID, &rv.ListingID, &rv.ReviewerID, &rv.ReviewerName, &rv.SellerID,
+			&rv.Rating, &rv.Body, &rv.CreatedAt, &rv.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, rv)
+	}
+	return out, rows.Err()
+}
+
+func (r *reviewsRepo) Stats(ctx context.Context, sellerID uuid.UUID) (models.RatingStats, error) {
+	var st models.RatingStats
+	rows, err := r.db.Query(ctx, `
+		SELECT rating, count(*) FROM review WHERE seller_id = $1 GROUP BY rating
+	`, sellerID)
+	if err != nil {
+		return st, err
+	}
+	defer rows.Close()
+
+	sum := 0
+	for rows.Next() {
+		var rating, n int
+		if err := rows.Scan(&rating, &n); err != nil {
+			return st, err
+		}
+		if rating >= 1 && rating <= 5 {
+			st.Distribution[rating-1] = n
+		}
+		st.Count += n
+		sum += rating * n
+	}
+	if st.Count > 0 {
+		st.Average = math.Round(float64(sum)/float64(st.Count)*100) / 100
+	}
+	return st, rows.Err()
+}
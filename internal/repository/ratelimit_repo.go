@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ===== RateLimitsRepository impl =====
+
+type rateLimitsRepo struct{ db *pgxpool.Pool }
+
+// Take — GCRA в транзакции: бакеты всех ключей блокируются (в порядке байтов ключа, как
+// сортирует Go, чтобы параллельные запросы с общими ключами не взаимоблокировались), проверяются
+// и сдвигаются одним UPDATE, только если не переполнен ни один.
+func (r *rateLimitsRepo) Take(ctx context.Context, keys []string, now time.Time, every, window time.Duration) (bool, time.Duration, error) {
+	keys = slices.Compact(slices.Sorted(slices.Values(keys)))
+	var (
+		allowed bool
+		wait    time.Duration
+	)
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// нового ключа ещё нет — заводим полный бакет (tat = now), чтобы его тоже заблокировать
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO rate_limit_bucket (key, tat)
+			SELECT unnest($1::text[]), $2
+			ON CONFLICT (key) DO NOTHING
+		`, keys, now); err != nil {
+			return err
+		}
+		rows, err := tx.Query(ctx, `
+			SELECT tat FROM rate_limit_bucket WHERE key = ANY($1) ORDER BY key COLLATE "C" FOR UPDATE
+		`, keys)
+		if err != nil {
+			return err
+		}
+		tats, err := pgx.CollectRows(rows, pgx.RowTo[time.Time])
+		if err != nil {
+			return err
+		}
+		for _, tat := range tats {
+			if tat.Before(now) {
+				tat = now
+			}
+			if over := tat.Add(every).Sub(now) - window; over > wait {
+				wait = over
+			}
+		}
+		if wait > 0 {
+			return nil
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE rate_limit_bucket SET tat = GREATEST(tat, $2) + make_interval(secs => $3)
+			WHERE key = ANY($1)
+		`, keys, now, every.Seconds())
+		allowed = err == nil
+		return err
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed, wait, nil
+}
+
+func (r *rateLimitsRepo) DeleteIdle(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM rate_limit_bucket WHERE tat < $1`, before)
+	return tag.RowsAffected(), err
+}
+
+// ===== AIUsageRepository impl =====
+
+type aiUsageRepo struct{ db *pgxpool.Pool }
+
+func (r *aiUsageRepo) AddUsage(ctx context.Context, subject string, day time.Time, tokens int) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO ai_usage_daily (subject, day, tokens)
+		VALUES ($1, $2::date, $3)
+		ON CONFLICT (subject, day) DO UPDATE SET tokens = ai_usage_daily.tokens + EXCLUDED.tokens
+	`, subject, day.UTC().Format(time.DateOnly), tokens)
+	return err
+}
+
+func (r *aiUsageRepo) Usage(ctx context.Context, subject string, day time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(tokens), 0) FROM ai_usage_daily WHERE subject = $1 AND day = $2::date
+	`, subject, day.UTC().Format(time.DateOnly)).Scan(&n)
+	return n, err
+}
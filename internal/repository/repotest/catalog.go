@@ -0,0 +1,419 @@
+package repotest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+func productID(p models.Product) uuid.UUID   { return p.ID }
+func categoryID(c models.Category) uuid.UUID { return c.ID }
+func brandID(b models.Brand) uuid.UUID       { return b.ID }
+
+func testProducts(t *testing.T, r repository.RepositorySet) {
+	list := func(f repository.ProductFilter) []models.Product {
+		t.Helper()
+		items, err := r.Products().List(ctx, f)
+		must(t, err)
+		return items
+	}
+	kgs := func(v float64) *float64 { return &v }
+	after, before := hours(5), hours(6)
+
+	for _, tc := range []struct {
+		name string
+		f    repository.ProductFilter
+		want []uuid.UUID
+	}{
+		{"newest", repository.ProductFilter{}, []uuid.UUID{l9, l6, l5, l4, l3, l2, l1}},
+		{"category", repository.ProductFilter{CategorySlug: "phones"}, []uuid.UUID{l9, l4, l3, l2, l1}},
+		{"brand", repository.ProductFilter{BrandSlug: "apple"}, []uuid.UUID{l9, l5, l2, l1}},
+		{"seller", repository.ProductFilter{SellerID: &alice}, []uuid.UUID{l5, l3, l1}},
+		{"query", repository.ProductFilter{Query: "IPHONE"}, []uuid.UUID{l9, l2, l1}},
+		{"condition", repository.ProductFilter{Condition: "new"}, []uuid.UUID{l9, l4}},
+		{"created after", repository.ProductFilter{CreatedAfter: &after}, []uuid.UUID{l9, l6}},
+		{"created before", repository.ProductFilter{CreatedBefore: &before}, []uuid.UUID{l6, l5, l4, l3, l2, l1}},
+		{"created window", repository.ProductFilter{CreatedAfter: &after, CreatedBefore: &before}, []uuid.UUID{l6}},
+		{"price range", repository.ProductFilter{PriceMinKGS: kgs(50000), PriceMaxKGS: kgs(61250)}, []uuid.UUID{l9, l2, l1}},
+		{"price asc", repository.ProductFilter{Sort: repository.SortPriceAsc}, []uuid.UUID{l3, l9, l1, l2, l5, l6, l4}},
+		{"price desc", repository.ProductFilter{Sort: repository.SortPriceDesc}, []uuid.UUID{l4, l6, l5, l2, l1, l9, l3}},
+		{"offset", repository.ProductFilter{Limit: 2, Offset: 2}, []uuid.UUID{l5, l4}},
+		{"offset past end", repository.ProductFilter{Offset: 100}, nil},
+		{"combined", repository.ProductFilter{CategorySlug: "phones", BrandSlug: "apple", Condition: "used"}, []uuid.UUID{l2, l1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			wantIDs(t, "List", list(tc.f), productID, tc.want...)
+		})
+	}
+
+	items, err := r.Products().ListByCategorySlug(ctx, "laptops", 10, 0)
+	must(t, err)
+	wantIDs(t, "ListByCategorySlug", items, productID, l5)
+
+	// карточка: бренд и модель — из модели каталога, цена в KGS — по курсу
+	for _, p := range list(repository.ProductFilter{}) {
+		switch p.ID {
+		case l2:
+			equal(t, "L2 brand", p.Brand, "Apple")
+			equal(t, "L2 model", p.Model, "iPhone 13")
+			equal(t, "L2 currency", p.CurrencyCode, "USD")
+			equal(t, "L2 price", p.PriceAmount, 700.0)
+			if p.PriceKGS == nil || *p.PriceKGS != 61250 {
+				t.Fatalf("L2 price_kgs = %v, want 61250", p.PriceKGS)
+			}
+			if p.BrandID == nil || *p.BrandID != brandApple || p.ProductID == nil || *p.ProductID != prodIPhone {
+				t.Fatalf("L2 brand_id/product_id = %v/%v", p.BrandID, p.ProductID)
+			}
+			equal(t, "L2 created_at", p.CreatedAt.Equal(hours(2)), true)
+		case l4:
+			if p.PriceKGS != nil {
+				t.Fatalf("L4 price_kgs = %v, want nil (no EUR rate)", *p.PriceKGS)
+			}
+		case l6:
+			if p.Brand != "" || p.BrandID != nil || p.ProductID != nil {
+				t.Fatalf("L6 without product: brand %q, brand_id %v, product_id %v", p.Brand, p.BrandID, p.ProductID)
+			}
+		}
+	}
+}
+
+func testProductsPagination(t *testing.T, r repository.RepositorySet) {
+	// cursorOf — курсор, который выдал бы обработчик после последнего элемента страницы.
+	cursorOf := func(sort string, p models.Product) *pagination.Cursor {
+		c := &pagination.Cursor{Sort: sort, CreatedAt: p.CreatedAt, ID: p.ID}
+		if sort != repository.SortNewest {
+			k := 1e15
+			if p.PriceKGS != nil {
+				k = *p.PriceKGS
+			}
+			c.Key = &k
+		}
+		return c
+	}
+
+	for _, tc := range []struct {
+		sort  string
+		pages [][]uuid.UUID
+	}{
+		{repository.SortNewest, [][]uuid.UUID{{l9, l6, l5}, {l4, l3, l2}, {l1}, {}}},
+		{repository.SortPriceAsc, [][]uuid.UUID{{l3, l9, l1}, {l2, l5, l6}, {l4}, {}}},
+		{repository.SortPriceDesc, [][]uuid.UUID{{l4, l6, l5}, {l2, l1, l9}, {l3}, {}}},
+	} {
+		t.Run(tc.sort, func(t *testing.T) {
+			f := repository.ProductFilter{Sort: tc.sort, Limit: 3}
+			for i, want := range tc.pages {
+				items, err := r.Products().List(ctx, f)
+				must(t, err)
+				wantIDs(t, "page", items, productID, want...)
+				if len(items) == 0 {
+					break
+				}
+				if i+1 < len(tc.pages) {
+					// Offset при курсоре игнорируется
+					f.After, f.Offset = cursorOf(tc.sort, items[len(items)-1]), 5
+				}
+			}
+		})
+	}
+
+	_, err := r.Products().List(ctx, repository.ProductFilter{
+		Sort:  repository.SortPriceAsc,
+		After: &pagination.Cursor{CreatedAt: hours(1), ID: l1},
+	})
+	if !errors.Is(err, pagination.ErrInvalidCursor) {
+		t.Fatalf("price sort, cursor without key: err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func testProductMedia(t *testing.T, r repository.RepositorySet) {
+	media, err := r.ProductMedia().ListByProductIDs(ctx, []uuid.UUID{prodMacBook, prodIPhone, prodGalaxy})
+	must(t, err)
+	want := []models.ProductMedia{
+		{ProductID: prodIPhone, URL: "https://img.example/iphone.jpg", Sort: 1, Cover: true},
+		{ProductID: prodIPhone, URL: "https://img.example/iphone-back.jpg", Sort: 2},
+		{ProductID: prodMacBook, URL: "https://img.example/macbook.jpg", Sort: 1, Cover: true},
+	}
+	if !slices.Equal(media, want) {
+		t.Fatalf("ListByProductIDs = %+v, want %+v", media, want)
+	}
+
+	media, err = r.ProductMedia().ListByProductIDs(ctx, nil)
+	must(t, err)
+	equal(t, "ListByProductIDs(nil)", len(media), 0)
+}
+
+func testCategories(t *testing.T, r repository.RepositorySet) {
+	roots, err := r.Categories().ListRoots(ctx)
+	must(t, err)
+	wantIDs(t, "ListRoots", roots, categoryID, catElectronics, catTransport)
+
+	// неактивная retro скрыта; порядок — sort_order, затем name
+	children, err := r.Categories().ListChildrenBySlug(ctx, "electronics")
+	must(t, err)
+	wantIDs(t, "ListChildrenBySlug", children, categoryID, catPhones, catLaptops)
+
+	children, err = r.Categories().ListChildrenBySlug(ctx, "no-such-category")
+	must(t, err)
+	equal(t, "children of missing category", len(children), 0)
+
+	c, err := r.Categories().GetBySlug(ctx, "laptops")
+	must(t, err)
+	if c.ID != catLaptops || c.Name != "Laptops" || c.Slug != "laptops" {
+		t.Fatalf("GetBySlug(laptops) = %+v", c)
+	}
+	_, err = r.Categories().GetBySlug(ctx, "no-such-category")
+	wantNoRows(t, err)
+
+	// дерево — целиком, включая неактивные
+	tree, err := r.Categories().Tree(ctx)
+	must(t, err)
+	wantIDs(t, "Tree roots", tree, categoryID, catElectronics, catTransport)
+	wantIDs(t, "Tree electronics", tree[0].Children, categoryID, catRetro, catPhones, catLaptops)
+	wantIDs(t, "Tree transport", tree[1].Children, categoryID, catCars)
+	equal(t, "Tree leaf children", len(tree[0].Children[1].Children), 0)
+}
+
+func testBrands(t *testing.T, r repository.RepositorySet) {
+	brands, err := r.Brands().List(ctx)
+	must(t, err)
+	wantIDs(t, "List", brands, brandID, brandApple, brandSamsung, brandToyota)
+	if !slices.Equal(brands[0].Aliases, []string{"aple", "iphone"}) {
+		t.Fatalf("Apple aliases = %q, want sorted [aple iphone]", brands[0].Aliases)
+	}
+	if brands[2].Aliases == nil || len(brands[2].Aliases) != 0 {
+		t.Fatalf("Toyota aliases = %#v, want empty slice", brands[2].Aliases)
+	}
+
+	b, err := r.Brands().GetBySlug(ctx, "samsung")
+	must(t, err)
+	equal(t, "GetBySlug id", b.ID, brandSamsung)
+	equal(t, "GetBySlug created_at", b.CreatedAt.Equal(t0), true)
+	_, err = r.Brands().GetBySlug(ctx, "nokia")
+	wantNoRows(t, err)
+
+	created, err := r.Brands().Create(ctx, models.Brand{Name: "Xiaomi", Slug: "xiaomi", Aliases: []string{"redmi", "mi"}})
+	must(t, err)
+	if created.ID == uuid.Nil || created.CreatedAt.IsZero() {
+		t.Fatalf("Create = %+v, want id and created_at", created)
+	}
+	got, err := r.Brands().GetBySlug(ctx, "xiaomi")
+	must(t, err)
+	equal(t, "created id", got.ID, created.ID)
+	if !slices.Equal(got.Aliases, []string{"mi", "redmi"}) {
+		t.Fatalf("created aliases = %q", got.Aliases)
+	}
+
+	_, err = r.Brands().Create(ctx, models.Brand{Name: "Apple 2", Slug: "apple"})
+	wantCode(t, err, uniqueViolation)
+	_, err = r.Brands().Create(ctx, models.Brand{Name: "Aple Inc", Slug: "aple-inc", Aliases: []string{"APLE"}})
+	wantCode(t, err, uniqueViolation)
+
+	updated, err := r.Brands().Update(ctx, models.Brand{ID: brandToyota, Name: "Toyota Motor", Slug: "toyota", Aliases: []string{"camry"}})
+	must(t, err)
+	equal(t, "Update created_at", updated.CreatedAt.Equal(t0), true)
+	got, err = r.Brands().GetBySlug(ctx, "toyota")
+	must(t, err)
+	equal(t, "updated name", got.Name, "Toyota Motor")
+	if !slices.Equal(got.Aliases, []string{"camry"}) {
+		t.Fatalf("updated aliases = %q", got.Aliases)
+	}
+	_, err = r.Brands().Update(ctx, models.Brand{ID: brandToyota, Name: "Samsung", Slug: "toyota"})
+	wantCode(t, err, uniqueViolation)
+	_, err = r.Brands().Update(ctx, models.Brand{ID: uuid.New(), Name: "Nokia", Slug: "nokia"})
+	wantNoRows(t, err)
+
+	// на Apple ссылаются модели каталога
+	wantCode(t, r.Brands().Delete(ctx, brandApple), foreignKeyViolation)
+	must(t, r.Brands().Delete(ctx, brandToyota))
+	wantNoRows(t, r.Brands().Delete(ctx, brandToyota))
+	_, err = r.Brands().GetBySlug(ctx, "toyota")
+	wantNoRows(t, err)
+
+	facets, err := r.Brands().FacetsByCategorySlug(ctx, "phones")
+	must(t, err)
+	if len(facets) != 2 ||
+		facets[0].Brand.ID != brandApple || facets[0].Count != 3 || facets[0].Brand.Name != "Apple" ||
+		facets[1].Brand.ID != brandSamsung || facets[1].Count != 2 {
+		t.Fatalf("FacetsByCategorySlug(phones) = %+v, want Apple 3, Samsung 2", facets)
+	}
+	facets, err = r.Brands().FacetsByCategorySlug(ctx, "cars")
+	must(t, err)
+	equal(t, "facets of cars (listing without product)", len(facets), 0)
+}
+
+func testCatalog(t *testing.T, r repository.RepositorySet) {
+	d, err := r.Catalog().GetProduct(ctx, prodIPhone)
+	must(t, err)
+	equal(t, "title", d.Title, "Apple iPhone 13")
+	equal(t, "model", d.Model, "iPhone 13")
+	equal(t, "category", d.CategorySlug, "phones")
+	equal(t, "spec", d.Specs["chip"], any("A15"))
+	if d.Brand == nil || d.Brand.ID != brandApple || d.Brand.Name != "Apple" || d.Brand.Slug != "apple" {
+		t.Fatalf("brand = %+v", d.Brand)
+	}
+	_, err = r.Catalog().GetProduct(ctx, prodOldIPhone)
+	wantNoRows(t, err)
+	_, err = r.Catalog().GetProduct(ctx, uuid.New())
+	wantNoRows(t, err)
+
+	// активные объявления iPhone 13: L1 60000 KGS, L9 50000 KGS (new), L2 700 USD; L7 продано
+	st, err := r.Catalog().OfferStats(ctx, prodIPhone)
+	must(t, err)
+	equal(t, "offers", st.Count, 3)
+	want := []models.PriceStats{
+		{CurrencyCode: "KGS", Min: 50000, Median: 55000, Max: 60000, Count: 2},
+		{CurrencyCode: "USD", Min: 700, Median: 700, Max: 700, Count: 1},
+	}
+	if !slices.Equal(st.Prices, want) {
+		t.Fatalf("prices = %+v, want %+v", st.Prices, want)
+	}
+	equal(t, "used", st.ByCondition["used"], 2)
+	equal(t, "new", st.ByCondition["new"], 1)
+
+	st, err = r.Catalog().OfferStats(ctx, prodOldIPhone)
+	must(t, err)
+	if st.Count != 0 || st.Prices == nil || len(st.Prices) != 0 || st.ByCondition == nil {
+		t.Fatalf("stats without offers = %+v, want zero with empty slice and map", st)
+	}
+
+	candidates, err := r.Catalog().FindCandidates(ctx, catPhones, &brandApple)
+	must(t, err)
+	wantIDs(t, "FindCandidates(phones, apple)", candidates, func(p models.CatalogProduct) uuid.UUID { return p.ID }, prodIPhone)
+	candidates, err = r.Catalog().FindCandidates(ctx, catPhones, nil)
+	must(t, err)
+	got := []uuid.UUID{}
+	for _, p := range candidates {
+		got = append(got, p.ID)
+	}
+	slices.SortFunc(got, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	if !slices.Equal(got, []uuid.UUID{prodIPhone, prodGalaxy}) {
+		t.Fatalf("FindCandidates(phones) = %s, want 401, 402 in any order", names(got))
+	}
+}
+
+func testListings(t *testing.T, r repository.RepositorySet) {
+	l, err := r.Listings().Create(ctx, models.Listing{
+		SellerID: dave, CategoryID: catPhones, Title: "Pixel 7", Description: "as new",
+		PriceAmount: 100.5, CurrencyCode: "USD", Condition: "used", LocationText: "Bishkek",
+		Attrs: map[string]any{"ram": "8GB", "color": "black"}, Status: "sold", BuyerID: &bob,
+	})
+	must(t, err)
+	if l.ID == uuid.Nil || l.CreatedAt.IsZero() {
+		t.Fatalf("Create = %+v, want id and created_at", l)
+	}
+	equal(t, "status", l.Status, "active")
+	equal(t, "price", l.PriceAmount, 100.5)
+	equal(t, "no buyer", l.BuyerID, (*uuid.UUID)(nil))
+
+	got, err := r.Listings().GetByID(ctx, l.ID)
+	must(t, err)
+	equal(t, "title", got.Title, "Pixel 7")
+	equal(t, "location", got.LocationText, "Bishkek")
+	equal(t, "attr ram", got.Attrs["ram"], any("8GB"))
+	equal(t, "created_at", got.CreatedAt.Equal(l.CreatedAt), true)
+	_, err = r.Listings().GetByID(ctx, uuid.New())
+	wantNoRows(t, err)
+
+	// новое объявление — первое в выдаче, цена в KGS посчитана по курсу
+	items, err := r.Products().List(ctx, repository.ProductFilter{Limit: 1})
+	must(t, err)
+	wantIDs(t, "newest", items, productID, l.ID)
+	if items[0].PriceKGS == nil || *items[0].PriceKGS != 8793.75 {
+		t.Fatalf("price_kgs = %v, want 8793.75", items[0].PriceKGS)
+	}
+	equal(t, "no model yet", items[0].Model, "")
+
+	must(t, r.Listings().SetProduct(ctx, l.ID, prodGalaxy))
+	must(t, r.Listings().SetProduct(ctx, uuid.New(), prodGalaxy))
+	items, err = r.Products().List(ctx, repository.ProductFilter{BrandSlug: "samsung"})
+	must(t, err)
+	wantIDs(t, "samsung after SetProduct", items, productID, l.ID, l4, l3)
+
+	got.PriceAmount, got.CurrencyCode, got.Status, got.Condition = 9000.456, "KGS", "paused", "new"
+	upd, err := r.Listings().Update(ctx, got)
+	must(t, err)
+	equal(t, "updated status", upd.Status, "paused")
+	equal(t, "updated price", upd.PriceAmount, 9000.46)
+	if upd.ProductID == nil || *upd.ProductID != prodGalaxy {
+		t.Fatalf("Update lost product_id: %v", upd.ProductID)
+	}
+	items, err = r.Products().List(ctx, repository.ProductFilter{Limit: 1})
+	must(t, err)
+	wantIDs(t, "paused hidden", items, productID, l9)
+
+	_, err = r.Listings().Update(ctx, models.Listing{ID: uuid.New(), Title: "x", CurrencyCode: "KGS", Condition: "new", Status: "active"})
+	wantNoRows(t, err)
+	got.Condition = "broken"
+	_, err = r.Listings().Update(ctx, got)
+	wantCode(t, err, checkViolation)
+
+	// покупатель — только у проданного объявления и не сам продавец
+	got.Condition = "new"
+	for _, bad := range []struct {
+		status string
+		buyer  uuid.UUID
+		code   string
+	}{
+		{"paused", bob, checkViolation},
+		{"sold", dave, checkViolation},
+		{"sold", uuid.New(), foreignKeyViolation},
+	} {
+		got.Status, got.BuyerID = bad.status, &bad.buyer
+		_, err = r.Listings().Update(ctx, got)
+		wantCode(t, err, bad.code)
+	}
+	got.Status, got.BuyerID = "sold", &bob
+	upd, err = r.Listings().Update(ctx, got)
+	must(t, err)
+	if upd.BuyerID == nil || *upd.BuyerID != bob {
+		t.Fatalf("Update buyer = %v, want bob", upd.BuyerID)
+	}
+	got, err = r.Listings().GetByID(ctx, l.ID)
+	must(t, err)
+	equal(t, "stored buyer", got.BuyerID != nil && *got.BuyerID == bob, true)
+
+	_, err = r.Listings().Create(ctx, models.Listing{
+		SellerID: uuid.New(), CategoryID: catPhones, Title: "x", CurrencyCode: "KGS", Condition: "new",
+	})
+	wantCode(t, err, foreignKeyViolation)
+	_, err = r.Listings().Create(ctx, models.Listing{
+		SellerID: dave, CategoryID: catPhones, Title: "x", CurrencyCode: "KGS", Condition: "mint",
+	})
+	wantCode(t, err, checkViolation)
+}
+
+func testExchangeRates(t *testing.T, r repository.RepositorySet) {
+	rates, err := r.ExchangeRates().List(ctx)
+	must(t, err)
+	if len(rates) != 2 || rates[0].CurrencyCode != "KGS" || rates[0].RateToKGS != 1 ||
+		rates[1].CurrencyCode != "USD" || rates[1].RateToKGS != 87.5 || rates[1].Source != "test" || !rates[1].UpdatedAt.Equal(t0) {
+		t.Fatalf("List = %+v, want KGS 1 and USD 87.5", rates)
+	}
+
+	at := t0.Add(24 * time.Hour)
+	must(t, r.ExchangeRates().Upsert(ctx, []models.ExchangeRate{
+		{CurrencyCode: "EUR", RateToKGS: 95, Source: "nbkr", UpdatedAt: at},
+		{CurrencyCode: "USD", RateToKGS: 88, Source: "nbkr", UpdatedAt: at},
+	}))
+	rates, err = r.ExchangeRates().List(ctx)
+	must(t, err)
+	if len(rates) != 3 || rates[0].CurrencyCode != "EUR" || rates[0].RateToKGS != 95 || rates[2].RateToKGS != 88 || !rates[2].UpdatedAt.Equal(at) {
+		t.Fatalf("List after Upsert = %+v", rates)
+	}
+
+	// price_kgs пересчитан: L4 (500 EUR) получил цену, L2 (700 USD) подорожал
+	kgs := func(v float64) *float64 { return &v }
+	items, err := r.Products().List(ctx, repository.ProductFilter{PriceMinKGS: kgs(47500), PriceMaxKGS: kgs(61600)})
+	must(t, err)
+	wantIDs(t, "price range after Upsert", items, productID, l9, l4, l2, l1)
+
+	wantCode(t, r.ExchangeRates().Upsert(ctx, []models.ExchangeRate{{CurrencyCode: "RUB", Source: "x", UpdatedAt: at}}), checkViolation)
+	must(t, r.ExchangeRates().Upsert(ctx, nil))
+}
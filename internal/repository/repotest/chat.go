@@ -0,0 +1,214 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+func testChatSessions(t *testing.T, r repository.RepositorySet) {
+	now := time.Now().UTC().Truncate(time.Second)
+	s := models.ChatSession{ID: "sess-anon", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	must(t, r.ChatSessions().Create(ctx, s))
+	wantCode(t, r.ChatSessions().Create(ctx, s), uniqueViolation)
+	wantCode(t, r.ChatSessions().Create(ctx, models.ChatSession{ID: "sess-ghost", UserID: ptr(uuid.New()),
+		CreatedAt: now, ExpiresAt: now.Add(time.Hour)}), foreignKeyViolation)
+
+	got, err := r.ChatSessions().Get(ctx, s.ID)
+	must(t, err)
+	if got.UserID != nil || !got.CreatedAt.Equal(now) || !got.LastSeenAt.Equal(now) || !got.ExpiresAt.Equal(s.ExpiresAt) {
+		t.Fatalf("Get = %+v, want anonymous session with last_seen_at = created_at", got)
+	}
+	_, err = r.ChatSessions().Get(ctx, "no-such-session")
+	wantNoRows(t, err)
+
+	must(t, r.ChatSessions().Touch(ctx, s.ID, now.Add(2*time.Hour)))
+	got, err = r.ChatSessions().Get(ctx, s.ID)
+	must(t, err)
+	equal(t, "expires_at after Touch", got.ExpiresAt.Equal(now.Add(2*time.Hour)), true)
+	equal(t, "last_seen_at moved", got.LastSeenAt.Before(now), false)
+
+	// разговор анонимной сессии привязывается к пользователю вместе с ней
+	conv, err := r.Conversations().GetOrCreateBySession(ctx, s.ID, nil)
+	must(t, err)
+	if conv.ID == uuid.Nil || conv.SessionID != s.ID || conv.UserID != nil {
+		t.Fatalf("GetOrCreateBySession = %+v", conv)
+	}
+	again, err := r.Conversations().GetOrCreateBySession(ctx, s.ID, &bob)
+	must(t, err)
+	equal(t, "same conversation", again.ID, conv.ID)
+
+	must(t, r.ChatSessions().BindUser(ctx, s.ID, alice))
+	got, err = r.ChatSessions().Get(ctx, s.ID)
+	must(t, err)
+	if got.UserID == nil || *got.UserID != alice {
+		t.Fatalf("session user after BindUser = %v, want alice", got.UserID)
+	}
+	conv, err = r.Conversations().GetBySession(ctx, s.ID)
+	must(t, err)
+	if conv.UserID == nil || *conv.UserID != alice {
+		t.Fatalf("conversation user after BindUser = %v, want alice", conv.UserID)
+	}
+	// уже привязанная сессия чужому пользователю не достаётся
+	must(t, r.ChatSessions().BindUser(ctx, s.ID, bob))
+	got, err = r.ChatSessions().Get(ctx, s.ID)
+	must(t, err)
+	equal(t, "session user after second BindUser", *got.UserID, alice)
+	_, err = r.Conversations().GetBySession(ctx, "no-such-session")
+	wantNoRows(t, err)
+
+	must(t, r.ChatSessions().Create(ctx, models.ChatSession{ID: "sess-old", UserID: &bob,
+		CreatedAt: now.Add(-3 * time.Hour), ExpiresAt: now.Add(-time.Hour)}))
+	n, err := r.ChatSessions().DeleteExpired(ctx, now)
+	must(t, err)
+	equal(t, "DeleteExpired", n, int64(1))
+	_, err = r.ChatSessions().Get(ctx, "sess-old")
+	wantNoRows(t, err)
+
+	must(t, r.ChatSessions().Delete(ctx, s.ID))
+	_, err = r.ChatSessions().Get(ctx, s.ID)
+	wantNoRows(t, err)
+}
+
+func testMessages(t *testing.T, r repository.RepositorySet) {
+	conv, err := r.Conversations().GetOrCreateBySession(ctx, "sess-msg", &alice)
+	must(t, err)
+
+	var ids []uuid.UUID
+	for _, m := range []struct{ role, text string }{
+		{"user", "ищу айфон"}, {"assistant", "какой бюджет?"}, {"user", "до 60 000"},
+	} {
+		id, err := r.Messages().Append(ctx, conv.ID, m.role, m.text, map[string]string{"n": m.text})
+		must(t, err)
+		ids = append(ids, id)
+	}
+	_, err = r.Messages().Append(ctx, conv.ID, "robot", "x", nil)
+	wantCode(t, err, checkViolation)
+	_, err = r.Messages().Append(ctx, uuid.New(), "user", "x", nil)
+	wantCode(t, err, foreignKeyViolation)
+
+	msgID := func(m models.Message) uuid.UUID { return m.ID }
+	last, err := r.Messages().ListLast(ctx, conv.ID, 2)
+	must(t, err)
+	wantIDs(t, "ListLast", last, msgID, ids[1], ids[2])
+	equal(t, "role", last[0].Role, "assistant")
+	equal(t, "text", last[1].Text, "до 60 000")
+	equal(t, "meta", last[1].Meta["n"], "до 60 000")
+
+	page, err := r.Messages().ListBefore(ctx, conv.ID, &pagination.Cursor{CreatedAt: last[0].CreatedAt, ID: last[0].ID}, 10)
+	must(t, err)
+	wantIDs(t, "ListBefore", page, msgID, ids[0])
+
+	id, err := r.Messages().Append(ctx, conv.ID, "system", "без meta", nil)
+	must(t, err)
+	last, err = r.Messages().ListLast(ctx, conv.ID, 1)
+	must(t, err)
+	wantIDs(t, "ListLast after nil meta", last, msgID, id)
+	equal(t, "nil meta stored as {}", len(last[0].Meta), 0)
+}
+
+func testFavorites(t *testing.T, r repository.RepositorySet) {
+	must(t, r.Favorites().Add(ctx, dave, l2))
+	must(t, r.Favorites().Add(ctx, dave, l7)) // проданные остаются в избранном
+	must(t, r.Favorites().Add(ctx, dave, l5))
+	must(t, r.Favorites().Add(ctx, dave, l2))
+	wantCode(t, r.Favorites().Add(ctx, dave, uuid.New()), foreignKeyViolation)
+	wantCode(t, r.Favorites().Add(ctx, uuid.New(), l1), foreignKeyViolation)
+
+	favs, err := r.Favorites().List(ctx, dave)
+	must(t, err)
+	wantIDs(t, "List", favs, productID, l5, l7, l2)
+	equal(t, "card brand", favs[2].Brand, "Apple")
+
+	must(t, r.Favorites().Remove(ctx, dave, l7))
+	got, err := r.Listings().GetByID(ctx, l5)
+	must(t, err)
+	got.Status = "deleted"
+	_, err = r.Listings().Update(ctx, got)
+	must(t, err)
+	favs, err = r.Favorites().List(ctx, dave)
+	must(t, err)
+	wantIDs(t, "List after Remove and delete", favs, productID, l2)
+
+	favs, err = r.Favorites().List(ctx, bob)
+	must(t, err)
+	equal(t, "no favorites", len(favs), 0)
+}
+
+func testSavedSearches(t *testing.T, r repository.RepositorySet) {
+	price := 60000.0
+	first, err := r.SavedSearches().Create(ctx, models.SavedSearch{UserID: dave, Name: "iphone",
+		Requirements: models.SearchRequirements{CategorySlug: "phones", Brand: "apple", PriceMax: &price, Currency: "KGS"}})
+	must(t, err)
+	if first.ID == uuid.Nil || first.LastRunAt == nil || !first.LastRunAt.Equal(first.CreatedAt) {
+		t.Fatalf("Create = %+v, want id and last_run_at = created_at", first)
+	}
+	second, err := r.SavedSearches().Create(ctx, models.SavedSearch{UserID: dave, Name: "cars",
+		Requirements: models.SearchRequirements{CategorySlug: "cars"}})
+	must(t, err)
+	_, err = r.SavedSearches().Create(ctx, models.SavedSearch{UserID: uuid.New(), Name: "ghost"})
+	wantCode(t, err, foreignKeyViolation)
+
+	ssID := func(ss models.SavedSearch) uuid.UUID { return ss.ID }
+	list, err := r.SavedSearches().ListByUser(ctx, dave)
+	must(t, err)
+	wantIDs(t, "ListByUser", list, ssID, second.ID, first.ID)
+	if req := list[1].Requirements; req.Brand != "apple" || req.PriceMax == nil || *req.PriceMax != price {
+		t.Fatalf("requirements = %+v", req)
+	}
+
+	// только что созданные ещё не «просрочены»; после MarkRun в прошлом — первыми старые
+	due, err := r.SavedSearches().ListDue(ctx, first.CreatedAt, 10)
+	must(t, err)
+	equal(t, "ListDue right after Create", len(due), 0)
+	must(t, r.SavedSearches().MarkRun(ctx, first.ID, t0))
+	must(t, r.SavedSearches().MarkRun(ctx, second.ID, t0.Add(time.Hour)))
+	due, err = r.SavedSearches().ListDue(ctx, t0.Add(2*time.Hour), 10)
+	must(t, err)
+	wantIDs(t, "ListDue", due, ssID, first.ID, second.ID)
+	equal(t, "last_run_at", due[0].LastRunAt.Equal(t0), true)
+	due, err = r.SavedSearches().ListDue(ctx, t0.Add(2*time.Hour), 1)
+	must(t, err)
+	wantIDs(t, "ListDue limit", due, ssID, first.ID)
+
+	wantNoRows(t, r.SavedSearches().Delete(ctx, bob, first.ID))
+	must(t, r.SavedSearches().Delete(ctx, dave, first.ID))
+	wantNoRows(t, r.SavedSearches().Delete(ctx, dave, first.ID))
+	list, err = r.SavedSearches().ListByUser(ctx, dave)
+	must(t, err)
+	wantIDs(t, "ListByUser after Delete", list, ssID, second.ID)
+}
+
+func testNotifications(t *testing.T, r repository.RepositorySet) {
+	var ids []uuid.UUID
+	for i, text := range []string{"first", "second", "third"} {
+		id, err := r.Notifications().Enqueue(ctx, models.Notification{UserID: dave, Kind: "saved_search_match", Text: text,
+			Payload: map[string]any{"n": i}, CreatedAt: t0})
+		must(t, err)
+		ids = append(ids, id)
+	}
+	_, err := r.Notifications().Enqueue(ctx, models.Notification{UserID: uuid.New(), Kind: "x", Text: "x"})
+	wantCode(t, err, foreignKeyViolation)
+
+	nID := func(n models.Notification) uuid.UUID { return n.ID }
+	pending, err := r.Notifications().ListPending(ctx, dave, 2)
+	must(t, err)
+	wantIDs(t, "ListPending", pending, nID, ids[0], ids[1])
+	equal(t, "kind", pending[0].Kind, "saved_search_match")
+	equal(t, "payload", pending[1].Payload["n"], any(1.0))
+	equal(t, "created_at set by store", pending[0].CreatedAt.After(t0), true)
+
+	must(t, r.Notifications().MarkDelivered(ctx, bob, ids[:2])) // чужие — не трогаются
+	must(t, r.Notifications().MarkDelivered(ctx, dave, ids[:2]))
+	must(t, r.Notifications().MarkDelivered(ctx, dave, nil))
+	pending, err = r.Notifications().ListPending(ctx, dave, 10)
+	must(t, err)
+	wantIDs(t, "ListPending after MarkDelivered", pending, nID, ids[2])
+}
+
+func ptr[T any](v T) *T { return &v }
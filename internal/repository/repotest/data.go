@@ -0,0 +1,116 @@
+package repotest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/repository/fixtures"
+)
+
+// id — стабильный UUID фикстуры: n-я запись набора.
+func id(n int) uuid.UUID {
+	return uuid.MustParse(fmt.Sprintf("00000000-0000-4000-8000-%012d", n))
+}
+
+// Записи набора Data. Номера объявлений совпадают с порядком их создания (L1 — самое старое).
+var (
+	catElectronics, catPhones, catLaptops, catRetro, catTransport, catCars = id(101), id(102), id(103), id(104), id(105), id(106)
+
+	brandApple, brandSamsung, brandToyota = id(201), id(202), id(203)
+
+	alice, bob, carol, dave = id(301), id(302), id(303), id(304)
+
+	prodIPhone, prodGalaxy, prodMacBook, prodOldIPhone = id(401), id(402), id(403), id(404)
+
+	l1, l2, l3, l4, l5, l6, l7, l8, l9 = id(501), id(502), id(503), id(504), id(505), id(506), id(507), id(508), id(509)
+)
+
+// t0 — точка отсчёта времени набора; объявление Ln создано в t0 + n часов.
+var t0 = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func hours(n int) time.Time { return t0.Add(time.Duration(n) * time.Hour) }
+
+// Data — набор, на котором работает Run. Имена — ASCII в одном регистре, чтобы порядок
+// по name и ILIKE не зависели от collation базы.
+//
+// Активные объявления и цены в KGS (курс USD 87.5, у EUR курса нет):
+//
+//	L1 alice iPhone 13     60000 KGS  used  → 60000
+//	L2 bob   iPhone 13     700 USD    used  → 61250
+//	L3 alice Galaxy S21    30000 KGS  used  → 30000
+//	L4 bob   Galaxy S21    500 EUR    new   → NULL
+//	L5 alice MacBook Air   80000 KGS  used  → 80000 (laptops)
+//	L6 bob   без модели    15000 USD  used  → 1312500 (cars)
+//	L9 carol iPhone 13     50000 KGS  new   → 50000
+//
+// L7 (alice, продано bob) и L8 (bob, на паузе) в выдачу не попадают.
+func Data() fixtures.Data {
+	return fixtures.Data{
+		Categories: []fixtures.Category{
+			{ID: catElectronics, Name: "Electronics", Slug: "electronics"},
+			{ID: catPhones, ParentID: &catElectronics, Name: "Phones", Slug: "phones", SortOrder: 1},
+			{ID: catLaptops, ParentID: &catElectronics, Name: "Laptops", Slug: "laptops", SortOrder: 2},
+			{ID: catRetro, ParentID: &catElectronics, Name: "Retro", Slug: "retro", Inactive: true},
+			{ID: catTransport, Name: "Transport", Slug: "transport"},
+			{ID: catCars, ParentID: &catTransport, Name: "Cars", Slug: "cars"},
+		},
+		Brands: []models.Brand{
+			{ID: brandApple, Name: "Apple", Slug: "apple", Aliases: []string{"iphone", "aple"}, CreatedAt: t0},
+			{ID: brandSamsung, Name: "Samsung", Slug: "samsung", Aliases: []string{"galaxy"}, CreatedAt: t0},
+			{ID: brandToyota, Name: "Toyota", Slug: "toyota", CreatedAt: t0},
+		},
+		Users: []fixtures.User{
+			{UserProfile: models.UserProfile{ID: alice, DisplayName: "alice", Phone: "+996555000001",
+				Contact: models.ContactPrefs{AllowMessages: true}, CreatedAt: t0}},
+			{UserProfile: models.UserProfile{ID: bob, DisplayName: "bob", Contact: models.ContactPrefs{AllowMessages: true}, CreatedAt: t0}},
+			{UserProfile: models.UserProfile{ID: carol, DisplayName: "carol", CreatedAt: t0}, Roles: []string{"moderator"}},
+			{UserProfile: models.UserProfile{ID: dave, DisplayName: "dave", CreatedAt: t0}},
+		},
+		Products: []fixtures.Product{
+			{CatalogProduct: models.CatalogProduct{ID: prodIPhone, CategoryID: catPhones, BrandID: &brandApple,
+				Model: "iPhone 13", Title: "Apple iPhone 13", Specs: map[string]any{"chip": "A15"}}},
+			{CatalogProduct: models.CatalogProduct{ID: prodGalaxy, CategoryID: catPhones, BrandID: &brandSamsung,
+				Model: "Galaxy S21", Title: "Samsung Galaxy S21"}},
+			{CatalogProduct: models.CatalogProduct{ID: prodMacBook, CategoryID: catLaptops, BrandID: &brandApple,
+				Model: "MacBook Air M1", Title: "Apple MacBook Air M1"}},
+			{CatalogProduct: models.CatalogProduct{ID: prodOldIPhone, CategoryID: catPhones, BrandID: &brandApple,
+				Model: "iPhone 6", Title: "Apple iPhone 6"}, Inactive: true},
+		},
+		Media: []models.ProductMedia{
+			{ProductID: prodMacBook, URL: "https://img.example/macbook.jpg", Sort: 1, Cover: true},
+			{ProductID: prodIPhone, URL: "https://img.example/iphone-back.jpg", Sort: 2},
+			{ProductID: prodIPhone, URL: "https://img.example/iphone.jpg", Sort: 1, Cover: true},
+		},
+		Rates: []models.ExchangeRate{
+			{CurrencyCode: "USD", RateToKGS: 87.5, Source: "test", UpdatedAt: t0},
+		},
+		Listings: []models.Listing{
+			listing(l1, alice, &prodIPhone, catPhones, "iPhone 13 128GB", 60000, "KGS", "used", "active", 1),
+			listing(l2, bob, &prodIPhone, catPhones, "iPhone 13 Pro", 700, "USD", "used", "active", 2),
+			listing(l3, alice, &prodGalaxy, catPhones, "Galaxy S21", 30000, "KGS", "used", "active", 3),
+			listing(l4, bob, &prodGalaxy, catPhones, "Galaxy S21 Ultra", 500, "EUR", "new", "active", 4),
+			listing(l5, alice, &prodMacBook, catLaptops, "MacBook Air M1", 80000, "KGS", "used", "active", 5),
+			listing(l6, bob, nil, catCars, "Toyota Camry 2015", 15000, "USD", "used", "active", 6),
+			soldTo(listing(l7, alice, &prodIPhone, catPhones, "iPhone 13 mini", 40000, "KGS", "used", "sold", 7), bob),
+			listing(l8, bob, &prodGalaxy, catPhones, "Galaxy S21 FE", 25000, "KGS", "used", "paused", 8),
+			listing(l9, carol, &prodIPhone, catPhones, "iPhone 13 new", 50000, "KGS", "new", "active", 9),
+		},
+	}
+}
+
+func listing(id, seller uuid.UUID, productID *uuid.UUID, categoryID uuid.UUID, title string,
+	price float64, currency, condition, status string, hour int) models.Listing {
+	return models.Listing{
+		ID: id, SellerID: seller, ProductID: productID, CategoryID: categoryID,
+		Title: title, Description: title, PriceAmount: price, CurrencyCode: currency,
+		Condition: condition, Status: status, CreatedAt: hours(hour),
+	}
+}
+
+func soldTo(l models.Listing, buyer uuid.UUID) models.Listing {
+	l.BuyerID = &buyer
+	return l
+}
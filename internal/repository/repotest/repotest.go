@@ -0,0 +1,122 @@
+// Package repotest — общий набор тестов RepositorySet. Его проходят и Postgres-реализация
+// (repository, при заданном TEST_DATABASE_URL), и хранилище в памяти (repository/memory):
+// порядок выдачи, пагинация и ошибки (pgx.ErrNoRows, SQLSTATE нарушенных ограничений)
+// у них должны совпадать, иначе демо-режим и тесты на памяти расходятся с продом.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/btynybekov/marketplace/internal/repository"
+	"github.com/btynybekov/marketplace/internal/repository/fixtures"
+)
+
+// Factory — новый набор репозиториев, в котором есть ровно данные d (и справочники миграций).
+// Вызывается в каждом подтесте; подтесты не запускаются параллельно.
+type Factory func(t *testing.T, d fixtures.Data) repository.RepositorySet
+
+// Run — весь набор проверок на хранилищах, созданных newRepos из Data().
+func Run(t *testing.T, newRepos Factory) {
+	for _, tc := range []struct {
+		name string
+		fn   func(t *testing.T, r repository.RepositorySet)
+	}{
+		{"Products", testProducts},
+		{"ProductsPagination", testProductsPagination},
+		{"ProductMedia", testProductMedia},
+		{"Categories", testCategories},
+		{"Brands", testBrands},
+		{"Catalog", testCatalog},
+		{"Listings", testListings},
+		{"ExchangeRates", testExchangeRates},
+		{"ChatSessions", testChatSessions},
+		{"Messages", testMessages},
+		{"Favorites", testFavorites},
+		{"SavedSearches", testSavedSearches},
+		{"Notifications", testNotifications},
+		{"Threads", testThreads},
+		{"Blocks", testBlocks},
+		{"Reports", testReports},
+		{"Roles", testRoles},
+		{"Users", testUsers},
+		{"Reviews", testReviews},
+		{"RateLimits", testRateLimits},
+		{"AIUsage", testAIUsage},
+		// SearchRequests не проверяется: Postgres-реализация пишет колонки, которых нет в схеме
+		// search_request, и нигде не вызывается.
+	} {
+		t.Run(tc.name, func(t *testing.T) { tc.fn(t, newRepos(t, Data())) })
+	}
+}
+
+var ctx = context.Background()
+
+// ===== Проверки =====
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func wantNoRows(t *testing.T, err error) {
+	t.Helper()
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("err = %v, want pgx.ErrNoRows", err)
+	}
+}
+
+// wantCode — ошибка нарушения ограничения с кодом SQLSTATE code.
+func wantCode(t *testing.T, err error, code string) {
+	t.Helper()
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != code {
+		t.Fatalf("err = %v, want SQLSTATE %s", err, code)
+	}
+}
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	checkViolation      = "23514"
+)
+
+// wantIDs — ids элементов в порядке выдачи.
+func wantIDs[T any](t *testing.T, what string, items []T, key func(T) uuid.UUID, want ...uuid.UUID) {
+	t.Helper()
+	got := make([]uuid.UUID, 0, len(items))
+	for _, it := range items {
+		got = append(got, key(it))
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("%s = %s, want %s", what, names(got), names(want))
+	}
+}
+
+// names — номера фикстур вместо UUID в сообщениях (L1 → "501"); чужие id — целиком.
+func names(ids []uuid.UUID) []string {
+	out := make([]string, 0, len(ids))
+	for _, v := range ids {
+		s := v.String()
+		if s[:24] == "00000000-0000-4000-8000-" {
+			s = s[33:]
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func equal[T comparable](t *testing.T, what string, got, want T) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("%s = %v, want %v", what, got, want)
+	}
+}
@@ -0,0 +1,409 @@
+package repotest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+	"github.com/btynybekov/marketplace/internal/repository"
+)
+
+func testThreads(t *testing.T, r repository.RepositorySet) {
+	th, err := r.Threads().GetOrCreate(ctx, l1, bob, alice)
+	must(t, err)
+	if th.ID == uuid.Nil || th.ListingID != l1 || th.BuyerID != bob || th.SellerID != alice || th.LastMessageAt != nil {
+		t.Fatalf("GetOrCreate = %+v", th)
+	}
+	equal(t, "listing title", th.ListingTitle, "iPhone 13 128GB")
+	again, err := r.Threads().GetOrCreate(ctx, l1, bob, alice)
+	must(t, err)
+	equal(t, "same thread", again.ID, th.ID)
+
+	_, err = r.Threads().GetOrCreate(ctx, l1, alice, alice)
+	wantCode(t, err, checkViolation)
+	_, err = r.Threads().GetOrCreate(ctx, uuid.New(), bob, alice)
+	wantCode(t, err, foreignKeyViolation)
+	_, err = r.Threads().GetOrCreate(ctx, l1, uuid.New(), alice)
+	wantCode(t, err, foreignKeyViolation)
+
+	found, err := r.Threads().Find(ctx, l1, bob)
+	must(t, err)
+	equal(t, "Find", found.ID, th.ID)
+	_, err = r.Threads().Find(ctx, l1, dave)
+	wantNoRows(t, err)
+	_, err = r.Threads().Get(ctx, uuid.New())
+	wantNoRows(t, err)
+
+	// вторая переписка alice — как покупателя, без сообщений
+	other, err := r.Threads().GetOrCreate(ctx, l6, alice, bob)
+	must(t, err)
+
+	var msgs []models.DirectMessage
+	for _, m := range []struct {
+		from uuid.UUID
+		body string
+	}{{bob, "still available?"}, {alice, "yes"}, {bob, "deal"}} {
+		dm, err := r.Threads().AppendMessage(ctx, th.ID, m.from, m.body)
+		must(t, err)
+		if dm.ID == uuid.Nil || dm.ThreadID != th.ID || dm.Body != m.body || dm.ReadAt != nil || dm.CreatedAt.IsZero() {
+			t.Fatalf("AppendMessage = %+v", dm)
+		}
+		msgs = append(msgs, dm)
+	}
+	_, err = r.Threads().AppendMessage(ctx, uuid.New(), bob, "x")
+	wantCode(t, err, foreignKeyViolation)
+
+	got, err := r.Threads().Get(ctx, th.ID)
+	must(t, err)
+	equal(t, "last message", got.LastMessage, "deal")
+	if got.LastMessageAt == nil || !got.LastMessageAt.Equal(msgs[2].CreatedAt) {
+		t.Fatalf("last_message_at = %v, want %v", got.LastMessageAt, msgs[2].CreatedAt)
+	}
+
+	threadID := func(t models.Thread) uuid.UUID { return t.ID }
+	list, err := r.Threads().ListByUser(ctx, alice)
+	must(t, err)
+	wantIDs(t, "ListByUser(alice)", list, threadID, th.ID, other.ID)
+	equal(t, "alice unread", list[0].Unread, 2)
+	equal(t, "listing title in list", list[1].ListingTitle, "Toyota Camry 2015")
+	list, err = r.Threads().ListByUser(ctx, bob)
+	must(t, err)
+	wantIDs(t, "ListByUser(bob)", list, threadID, th.ID, other.ID)
+	equal(t, "bob unread", list[0].Unread, 1)
+	list, err = r.Threads().ListByUser(ctx, dave)
+	must(t, err)
+	equal(t, "dave threads", len(list), 0)
+
+	dmID := func(m models.DirectMessage) uuid.UUID { return m.ID }
+	page, err := r.Threads().ListMessages(ctx, th.ID, nil, 2)
+	must(t, err)
+	wantIDs(t, "ListMessages", page, dmID, msgs[1].ID, msgs[2].ID)
+	page, err = r.Threads().ListMessages(ctx, th.ID, &pagination.Cursor{CreatedAt: page[0].CreatedAt, ID: page[0].ID}, 2)
+	must(t, err)
+	wantIDs(t, "ListMessages before cursor", page, dmID, msgs[0].ID)
+
+	n, err := r.Threads().UnreadCount(ctx, alice)
+	must(t, err)
+	equal(t, "UnreadCount(alice)", n, 2)
+	n, err = r.Threads().MarkRead(ctx, th.ID, alice)
+	must(t, err)
+	equal(t, "MarkRead", n, 2)
+	n, err = r.Threads().MarkRead(ctx, th.ID, alice)
+	must(t, err)
+	equal(t, "MarkRead again", n, 0)
+	n, err = r.Threads().UnreadCount(ctx, alice)
+	must(t, err)
+	equal(t, "UnreadCount after MarkRead", n, 0)
+	n, err = r.Threads().UnreadCount(ctx, bob)
+	must(t, err)
+	equal(t, "UnreadCount(bob)", n, 1)
+
+	page, err = r.Threads().ListMessages(ctx, th.ID, nil, 10)
+	must(t, err)
+	if page[0].ReadAt == nil || page[1].ReadAt != nil || page[2].ReadAt == nil {
+		t.Fatalf("read_at after MarkRead(alice): %v, %v, %v", page[0].ReadAt, page[1].ReadAt, page[2].ReadAt)
+	}
+
+	// новое сообщение поднимает переписку наверх
+	_, err = r.Threads().AppendMessage(ctx, other.ID, bob, "camry is sold")
+	must(t, err)
+	list, err = r.Threads().ListByUser(ctx, alice)
+	must(t, err)
+	wantIDs(t, "ListByUser after new message", list, threadID, other.ID, th.ID)
+	equal(t, "last message of other", list[0].LastMessage, "camry is sold")
+}
+
+func testBlocks(t *testing.T, r repository.RepositorySet) {
+	must(t, r.Blocks().Block(ctx, alice, bob))
+	must(t, r.Blocks().Block(ctx, alice, bob))
+	wantCode(t, r.Blocks().Block(ctx, alice, uuid.New()), foreignKeyViolation)
+
+	for _, pair := range [][2]uuid.UUID{{alice, bob}, {bob, alice}} {
+		blocked, err := r.Blocks().IsBlocked(ctx, pair[0], pair[1])
+		must(t, err)
+		equal(t, "IsBlocked "+names(pair[:])[0]+"/"+names(pair[:])[1], blocked, true)
+	}
+	blocked, err := r.Blocks().IsBlocked(ctx, alice, dave)
+	must(t, err)
+	equal(t, "IsBlocked alice/dave", blocked, false)
+
+	must(t, r.Blocks().Unblock(ctx, bob, alice)) // блокировал не bob — ничего не меняется
+	blocked, err = r.Blocks().IsBlocked(ctx, alice, bob)
+	must(t, err)
+	equal(t, "IsBlocked after foreign Unblock", blocked, true)
+	must(t, r.Blocks().Unblock(ctx, alice, bob))
+	blocked, err = r.Blocks().IsBlocked(ctx, bob, alice)
+	must(t, err)
+	equal(t, "IsBlocked after Unblock", blocked, false)
+}
+
+func testReports(t *testing.T, r repository.RepositorySet) {
+	first, err := r.Reports().Create(ctx, models.AbuseReport{ReporterID: dave, TargetType: "listing", TargetID: l6, Reason: "spam"})
+	must(t, err)
+	if first.ID == uuid.Nil || first.Status != "open" || first.CreatedAt.IsZero() || first.ResolvedBy != nil {
+		t.Fatalf("Create = %+v", first)
+	}
+	second, err := r.Reports().Create(ctx, models.AbuseReport{ReporterID: alice, TargetType: "user", TargetID: bob, Reason: "rude"})
+	must(t, err)
+	_, err = r.Reports().Create(ctx, models.AbuseReport{ReporterID: dave, TargetType: "planet", TargetID: l6})
+	wantCode(t, err, checkViolation)
+	_, err = r.Reports().Create(ctx, models.AbuseReport{ReporterID: uuid.New(), TargetType: "listing", TargetID: l6})
+	wantCode(t, err, foreignKeyViolation)
+	for _, target := range []string{"user", "listing", "thread", "message", "review"} {
+		_, err = r.Reports().Create(ctx, models.AbuseReport{ReporterID: dave, TargetType: target, TargetID: uuid.New(), Reason: "x"})
+		wantNoRows(t, err)
+	}
+	_, err = r.Reports().Create(ctx, models.AbuseReport{ReporterID: dave, TargetType: "listing", TargetID: bob, Reason: "x"})
+	wantNoRows(t, err) // id пользователя — не объявление
+
+	reportID := func(rep models.AbuseReport) uuid.UUID { return rep.ID }
+	list, err := r.Reports().List(ctx, "open", 10)
+	must(t, err)
+	wantIDs(t, "List(open)", list, reportID, first.ID, second.ID)
+	equal(t, "reason", list[0].Reason, "spam")
+
+	res, err := r.Reports().Resolve(ctx, first.ID, "resolved", carol)
+	must(t, err)
+	if res.Status != "resolved" || res.ResolvedBy == nil || *res.ResolvedBy != carol || res.ResolvedAt == nil || res.Reason != "spam" {
+		t.Fatalf("Resolve = %+v", res)
+	}
+	_, err = r.Reports().Resolve(ctx, uuid.New(), "resolved", carol)
+	wantNoRows(t, err)
+	_, err = r.Reports().Resolve(ctx, second.ID, "ignored", carol)
+	wantCode(t, err, checkViolation)
+	_, err = r.Reports().Resolve(ctx, second.ID, "rejected", uuid.New())
+	wantCode(t, err, foreignKeyViolation)
+
+	list, err = r.Reports().List(ctx, "open", 10)
+	must(t, err)
+	wantIDs(t, "List(open) after Resolve", list, reportID, second.ID)
+	list, err = r.Reports().List(ctx, "resolved", 10)
+	must(t, err)
+	wantIDs(t, "List(resolved)", list, reportID, first.ID)
+	list, err = r.Reports().List(ctx, "", 1)
+	must(t, err)
+	wantIDs(t, "List(all, 1)", list, reportID, first.ID)
+}
+
+func testRoles(t *testing.T, r repository.RepositorySet) {
+	access := func(id uuid.UUID) models.UserAccess {
+		t.Helper()
+		a, err := r.Roles().Access(ctx, id)
+		must(t, err)
+		equal(t, "access user", a.UserID, id)
+		return a
+	}
+	wantAccess := func(a models.UserAccess, roles, perms []string) {
+		t.Helper()
+		if !slices.Equal(a.Roles, roles) || !slices.Equal(a.Permissions, perms) {
+			t.Fatalf("Access = roles %q perms %q, want %q %q", a.Roles, a.Permissions, roles, perms)
+		}
+	}
+
+	wantAccess(access(alice), []string{"buyer", "seller"}, []string{"listings.create", "listings.edit.own"})
+	wantAccess(access(carol), []string{"buyer", "moderator", "seller"},
+		[]string{"admin.access", "listings.create", "listings.edit.any", "listings.edit.own", "reports.review"})
+	none := access(uuid.New())
+	if none.Roles == nil || none.Permissions == nil || len(none.Roles)+len(none.Permissions) != 0 {
+		t.Fatalf("Access(unknown) = %+v, want empty slices", none)
+	}
+
+	ok, err := r.Roles().HasPermission(ctx, carol, "reports.review")
+	must(t, err)
+	equal(t, "carol reports.review", ok, true)
+	ok, err = r.Roles().HasPermission(ctx, alice, "reports.review")
+	must(t, err)
+	equal(t, "alice reports.review", ok, false)
+
+	must(t, r.Roles().Grant(ctx, dave, "admin"))
+	must(t, r.Roles().Grant(ctx, dave, "admin"))
+	wantNoRows(t, r.Roles().Grant(ctx, dave, "superuser"))
+	ok, err = r.Roles().HasPermission(ctx, dave, "roles.manage")
+	must(t, err)
+	equal(t, "dave roles.manage", ok, true)
+
+	must(t, r.Roles().Revoke(ctx, dave, "admin"))
+	must(t, r.Roles().Revoke(ctx, dave, "seller"))
+	wantAccess(access(dave), []string{"buyer"}, []string{})
+}
+
+func testUsers(t *testing.T, r repository.RepositorySet) {
+	p, err := r.Users().GetProfile(ctx, alice)
+	must(t, err)
+	if p.DisplayName != "alice" || p.Phone != "+996555000001" || !p.Contact.AllowMessages || !p.CreatedAt.Equal(t0) {
+		t.Fatalf("GetProfile(alice) = %+v", p)
+	}
+	_, err = r.Users().GetProfile(ctx, uuid.New())
+	wantNoRows(t, err)
+
+	// телефон профилем не меняется
+	saved, err := r.Users().SaveProfile(ctx, models.UserProfile{ID: alice, DisplayName: "Alice B", AvatarURL: "https://img.example/a.png",
+		LocationText: "Osh", Phone: "+996000000000", Contact: models.ContactPrefs{ShowPhone: true, Preferred: "phone"}})
+	must(t, err)
+	want := models.UserProfile{ID: alice, DisplayName: "Alice B", AvatarURL: "https://img.example/a.png", LocationText: "Osh",
+		Phone: "+996555000001", Contact: models.ContactPrefs{ShowPhone: true, Preferred: "phone"}, CreatedAt: saved.CreatedAt}
+	if saved != want || !saved.CreatedAt.Equal(t0) {
+		t.Fatalf("SaveProfile = %+v, want %+v", saved, want)
+	}
+	p, err = r.Users().GetProfile(ctx, alice)
+	must(t, err)
+	equal(t, "profile after save", p, saved)
+
+	// первый SaveProfile создаёт пользователя с ролями по умолчанию
+	newID := uuid.New()
+	created, err := r.Users().SaveProfile(ctx, models.UserProfile{ID: newID, DisplayName: "eve"})
+	must(t, err)
+	if created.ID != newID || created.DisplayName != "eve" || created.Phone != "" || created.CreatedAt.IsZero() {
+		t.Fatalf("SaveProfile(new) = %+v", created)
+	}
+	a, err := r.Roles().Access(ctx, newID)
+	must(t, err)
+	if !slices.Equal(a.Roles, []string{"buyer", "seller"}) {
+		t.Fatalf("roles of new user = %q, want [buyer seller]", a.Roles)
+	}
+}
+
+func testReviews(t *testing.T, r repository.RepositorySet) {
+	// L7 продано bob в наборе, L1 alice продаёт carol
+	sold, err := r.Listings().GetByID(ctx, l1)
+	must(t, err)
+	sold.Status, sold.BuyerID = "sold", &carol
+	_, err = r.Listings().Update(ctx, sold)
+	must(t, err)
+
+	first, err := r.Reviews().Upsert(ctx, models.Review{ListingID: l7, ReviewerID: bob, SellerID: alice, Rating: 5, Body: "great"})
+	must(t, err)
+	if first.ID == uuid.Nil || first.CreatedAt.IsZero() || !first.UpdatedAt.Equal(first.CreatedAt) {
+		t.Fatalf("Upsert = %+v", first)
+	}
+	second, err := r.Reviews().Upsert(ctx, models.Review{ListingID: l1, ReviewerID: carol, SellerID: alice, Rating: 2})
+	must(t, err)
+
+	// повторный отзыв того же покупателя обновляет оценку, id и created_at остаются
+	upd, err := r.Reviews().Upsert(ctx, models.Review{ListingID: l7, ReviewerID: bob, SellerID: alice, Rating: 4, Body: "good"})
+	must(t, err)
+	if upd.ID != first.ID || !upd.CreatedAt.Equal(first.CreatedAt) || !upd.UpdatedAt.After(first.UpdatedAt) {
+		t.Fatalf("second Upsert = %+v, first %+v", upd, first)
+	}
+
+	for _, bad := range []struct {
+		rv   models.Review
+		code string
+	}{
+		{models.Review{ListingID: l7, ReviewerID: dave, SellerID: alice, Rating: 6}, checkViolation},
+		{models.Review{ListingID: l7, ReviewerID: dave, SellerID: alice, Rating: 0}, checkViolation},
+		{models.Review{ListingID: l7, ReviewerID: alice, SellerID: alice, Rating: 5}, checkViolation},
+		{models.Review{ListingID: uuid.New(), ReviewerID: dave, SellerID: alice, Rating: 5}, foreignKeyViolation},
+		{models.Review{ListingID: l7, ReviewerID: uuid.New(), SellerID: alice, Rating: 5}, foreignKeyViolation},
+		{models.Review{ListingID: l7, ReviewerID: dave, SellerID: alice, Rating: 5}, foreignKeyViolation}, // не покупатель
+		{models.Review{ListingID: l7, ReviewerID: bob, SellerID: carol, Rating: 5}, foreignKeyViolation},  // не продавец объявления
+	} {
+		_, err := r.Reviews().Upsert(ctx, bad.rv)
+		wantCode(t, err, bad.code)
+	}
+
+	for _, tc := range []struct {
+		id   uuid.UUID
+		want bool
+	}{{l7, true}, {l1, true}, {l6, false}, {uuid.New(), false}} {
+		ok, err := r.Reviews().Exists(ctx, tc.id)
+		must(t, err)
+		equal(t, "Exists", ok, tc.want)
+	}
+
+	// пока есть отзыв, покупателя не сменить и не убрать
+	sold.BuyerID = &dave
+	_, err = r.Listings().Update(ctx, sold)
+	wantCode(t, err, foreignKeyViolation)
+	sold.Status, sold.BuyerID = "active", nil
+	_, err = r.Listings().Update(ctx, sold)
+	wantCode(t, err, foreignKeyViolation)
+
+	reviewID := func(rv models.Review) uuid.UUID { return rv.ID }
+	list, err := r.Reviews().ListBySeller(ctx, alice, nil, 10)
+	must(t, err)
+	wantIDs(t, "ListBySeller", list, reviewID, second.ID, first.ID)
+	if list[1].ReviewerName != "bob" || list[1].Rating != 4 || list[1].Body != "good" || list[0].ReviewerName != "carol" {
+		t.Fatalf("ListBySeller = %+v", list)
+	}
+	list, err = r.Reviews().ListBySeller(ctx, alice, &pagination.Cursor{CreatedAt: second.CreatedAt, ID: second.ID}, 10)
+	must(t, err)
+	wantIDs(t, "ListBySeller after cursor", list, reviewID, first.ID)
+
+	st, err := r.Reviews().Stats(ctx, alice)
+	must(t, err)
+	equal(t, "Stats", st, models.RatingStats{Count: 2, Average: 3, Distribution: [5]int{0, 1, 0, 1, 0}})
+	st, err = r.Reviews().Stats(ctx, dave)
+	must(t, err)
+	equal(t, "Stats without reviews", st, models.RatingStats{})
+
+	must(t, r.Reviews().Delete(ctx, l1, carol))
+	st, err = r.Reviews().Stats(ctx, alice)
+	must(t, err)
+	equal(t, "Stats after Delete", st, models.RatingStats{Count: 1, Average: 4, Distribution: [5]int{0, 0, 0, 1, 0}})
+}
+
+func testRateLimits(t *testing.T, r repository.RepositorySet) {
+	now := t0
+	every, window := time.Second, 2*time.Second
+
+	take := func(at time.Time, keys ...string) (bool, time.Duration) {
+		t.Helper()
+		ok, retry, err := r.RateLimits().Take(ctx, keys, at, every, window)
+		must(t, err)
+		return ok, retry
+	}
+
+	for i, want := range []bool{true, true, false} {
+		ok, retry := take(now, "ip:1")
+		equal(t, "Take allowed", ok, want)
+		if !want {
+			equal(t, "Retry-After", retry, time.Second)
+		} else if retry != 0 {
+			t.Fatalf("Take #%d allowed with retry %v", i, retry)
+		}
+	}
+	// исчерпан один из бакетов — не списывается ни из одного
+	ok, retry := take(now, "ip:1", "user:3")
+	equal(t, "Take with an exhausted key", ok, false)
+	equal(t, "Retry-After of several keys", retry, time.Second)
+	for _, want := range []bool{true, true, false} {
+		ok, _ = take(now, "user:3", "user:3") // повтор ключа — один токен
+		equal(t, "user:3 untouched by the rejected Take", ok, want)
+	}
+
+	// через секунду место освобождается
+	ok, _ = take(now.Add(time.Second), "ip:1")
+	equal(t, "Take after a second", ok, true)
+	ok, _ = take(now, "ip:2")
+	equal(t, "other key", ok, true)
+
+	// ip:1 заполнен до now+3s, user:3 — до now+2s, ip:2 — до now+1s
+	n, err := r.RateLimits().DeleteIdle(ctx, now.Add(2*time.Second))
+	must(t, err)
+	equal(t, "DeleteIdle", n, int64(1))
+	ok, _ = take(now.Add(time.Second), "ip:1")
+	equal(t, "ip:1 still limited", ok, false)
+}
+
+func testAIUsage(t *testing.T, r repository.RepositorySet) {
+	day := time.Date(2025, 3, 1, 23, 30, 0, 0, time.UTC)
+	must(t, r.AIUsage().AddUsage(ctx, "user:1", day, 100))
+	must(t, r.AIUsage().AddUsage(ctx, "user:1", day.Add(-12*time.Hour), 50))
+	must(t, r.AIUsage().AddUsage(ctx, "user:1", day.Add(time.Hour), 7)) // уже 2 марта
+	must(t, r.AIUsage().AddUsage(ctx, "user:2", day, 1))
+
+	n, err := r.AIUsage().Usage(ctx, "user:1", day)
+	must(t, err)
+	equal(t, "Usage 1 March", n, 150)
+	n, err = r.AIUsage().Usage(ctx, "user:1", day.Add(time.Hour))
+	must(t, err)
+	equal(t, "Usage 2 March", n, 7)
+	n, err = r.AIUsage().Usage(ctx, "user:3", day)
+	must(t, err)
+	equal(t, "Usage unknown", n, 0)
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// ===== RolesRepository impl =====
+
+type rolesRepo struct{ db *pgxpool.Pool }
+
+func (r *rolesRepo) HasPermission(ctx context.Context, userID uuid.UUID, perm string) (bool, error) {
+	var ok bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM user_role ur
+			JOIN role_permission rp ON rp.role_id = ur.role_id
+			WHERE ur.user_id = $1 AND rp.permission_id = $2
+		)
+	`, userID, perm).Scan(&ok)
+	return ok, err
+}
+
+func (r *rolesRepo) Access(ctx context.Context, userID uuid.UUID) (models.UserAccess, error) {
+	a := models.UserAccess{UserID: userID}
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			COALESCE((SELECT array_agg(role_id ORDER BY role_id) FROM user_role WHERE user_id = $1), '{}'),
+			COALESCE((SELECT array_agg(DISTINCT rp.permission_id ORDER BY rp.permission_id)
+			          FROM user_role ur JOIN role_permission rp ON rp.role_id = ur.role_id
+			          WHERE ur.user_id = $1), '{}')
+	`, userID).Scan(&a.Roles, &a.Permissions)
+	return a, err
+}
+
+func (r *rolesRepo) Grant(ctx context.Context, userID uuid.UUID, role string) error {
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO user_role (user_id, role_id)
+		SELECT $1, id FROM role WHERE id = $2
+		ON CONFLICT DO NOTHING
+	`, userID, role)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		// либо роль уже выдана, либо её нет
+		var exists bool
+		if err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM role WHERE id = $1)`, role).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return pgx.ErrNoRows
+		}
+	}
+	return nil
+}
+
+func (r *rolesRepo) Revoke(ctx context.Context, userID uuid.UUID, role string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM user_role WHERE user_id = $1 AND role_id = $2`, userID, role)
+	return err
+}
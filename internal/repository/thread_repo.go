@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/btynybekov/marketplace/internal/models"
+	"github.com/btynybekov/marketplace/internal/pagination"
+)
+
+// ===== ThreadsRepository impl =====
+
+type threadsRepo struct{ db *pgxpool.Pool }
+
+const threadSelect = `
+	SELECT t.id, t.listing_id, l.title, t.buyer_id, t.seller_id,
+	       COALESCE(lm.body, ''), t.last_message_at, t.created_at
+	FROM dm_thread t
+	JOIN listing l ON l.id = t.listing_id
+	LEFT JOIN LATERAL (
+		SELECT body FROM dm_message m
+		WHERE m.thread_id = t.id
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT 1
+	) lm ON TRUE
+`
+
+func (r *threadsRepo) GetOrCreate(ctx context.Context, listingID, buyerID, sellerID uuid.UUID) (models.Thread, error) {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO dm_thread (listing_id, buyer_id, seller_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (listing_id, buyer_id) DO NOTHING
+	`, listingID, buyerID, sellerID)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	var t models.Thread
+	err = r.db.QueryRow(ctx, threadSelect+`WHERE t.listing_id = $1 AND t.buyer_id = $2`, listingID, buyerID).
+		Scan(&t.ID, &t.ListingID, &t.ListingTitle, &t.BuyerID, &t.SellerID, &t.LastMessage, &t.LastMessageAt, &t.CreatedAt)
+	return t, err
+}
+
+func (r *threadsRepo) Get(ctx context.Context, id uuid.UUID) (models.Thread, error) {
+	var t models.Thread
+	err := r.db.QueryRow(ctx, threadSelect+`WHERE t.id = $1`, id).
+		Scan(&t.ID, &t.ListingID, &t.ListingTitle, &t.BuyerID, &t.SellerID, &t.LastMessage, &t.LastMessageAt, &t.CreatedAt)
+	return t, err
+}
+
+func (r *threadsRepo) Find(ctx context.Context, listingID, buyerID uuid.UUID) (models.Thread, error) {
+	var t models.Thread
+	err := r.db.QueryRow(ctx, threadSelect+`WHERE t.listing_id = $1 AND t.buyer_id = $2`, listingID, buyerID).
+		Scan(&t.ID, &t.ListingID, &t.ListingTitle, &t.BuyerID, &t.SellerID, &t.LastMessage, &t.LastMessageAt, &t.CreatedAt)
+	return t, err
+}
+
+func (r *threadsRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]models.Thread, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT x.*, (
+			SELECT count(*) FROM dm_message m
+			WHERE m.thread_id = x.id AND m.sender_id <> $1 AND m.read_at IS NULL
+		)
+		FROM (`+threadSelect+`
+			WHERE t.buyer_id = $1 OR t.seller_id = $1
+		) x
+		ORDER BY COALESCE(x.last_message_at, x.created_at) DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Thread
+	for rows.Next() {
+		var t models.Thread
+		if err := rows.Scan(&t.ID, &t.ListingID, &t.ListingTitle, &t.BuyerID, &t.SellerID,
+			&t.LastMessage, &t.LastMessageAt, &t.CreatedAt, &t.Unread); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (r *threadsRepo) AppendMessage(ctx context.Context, threadID, senderID uuid.UUID, body string) (models.DirectMessage, error) {
+	var m models.DirectMessage
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO dm_message (thread_id, sender_id, body)
+			VALUES ($1, $2, $3)
+			RETURNING id, thread_id, sender_id, body, read_at, created_at
+		`, threadID, senderID, body).Scan(&m.ID, &m.ThreadID, &m.SenderID, &m.Body, &m.ReadAt, &m.CreatedAt); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `UPDATE dm_thread SET last_message_at = $2 WHERE id = $1`, threadID, m.CreatedAt)
+		return err
+	})
+	return m, err
+}
+
+func (r *threadsRepo) ListMessages(ctx context.Context, threadID uuid.UUID, before *pagination.Cursor, limit int) ([]models.DirectMessage, error) {
+	args := []any{threadID, limit}
+	cond := ""
+	if before != nil {
+		args = append(args, before.CreatedAt, before.ID)
+		cond = "AND (created_at, id) < ($3, $4)"
+	}
+	rows, err := r.db.Query(ctx, `
+		SELECT id, thread_id, sender_id, body, read_at, created_at
+		FROM dm_message
+		WHERE thread_id = $1 `+cond+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.DirectMessage, 0, limit)
+	for rows.Next() {
+		var m models.DirectMessage
+		if err := rows.Scan(&m.ID, &m.ThreadID, &m.SenderID, &m.Body, &m.ReadAt, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, rows.Err()
+}
+
+func (r *threadsRepo) MarkRead(ctx context.Context, threadID, readerID uuid.UUID) (int, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE dm_message SET read_at = now()
+		WHERE thread_id = $1 AND sender_id <> $2 AND read_at IS NULL
+	`, threadID, readerID)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (r *threadsRepo) UnreadCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `
+		SELECT count(*)
+		FROM dm_message m
+		JOIN dm_thread t ON t.id = m.thread_id
+		WHERE (t.buyer_id = $1 OR t.seller_id = $1)
+		  AND m.sender_id <> $1
+		  AND m.read_at IS NULL
+	`, userID).Scan(&n)
+	return n, err
+}
+
+// ===== BlocksRepository impl =====
+
+type blocksRepo struct{ db *pgxpool.Pool }
+
+func (r *blocksRepo) Block(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO user_block (blocker_id, blocked_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, blockerID, blockedID)
+	return err
+}
+
+func (r *blocksRepo) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM user_block WHERE blocker_id = $1 AND blocked_id = $2`, blockerID, blockedID)
+	return err
+}
+
+func (r *blocksRepo) IsBlocked(ctx context.Context, a, b uuid.UUID) (bool, error) {
+	var one int
+	err := r.db.QueryRow(ctx, `
+		SELECT 1 FROM user_block
+		WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		LIMIT 1
+	`, a, b).Scan(&one)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ===== ReportsRepository impl =====
+
+type reportsRepo struct{ db *pgxpool.Pool }
+
+func (r *reportsRepo) Create(ctx context.Context, rep models.AbuseReport) (models.AbuseReport, error) {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO abuse_report (reporter_id, target_type, target_id, reason)
+		SELECT $1, $2, $3, $4
+		WHERE CASE $2
+			WHEN 'user'    THEN EXISTS (SELECT 1 FROM app_user WHERE id = $3)
+			WHEN 'listing' THEN EXISTS (SELECT 1 FROM listing WHERE id = $3)
+			WHEN 'thread'  THEN EXISTS (SELECT 1 FROM dm_thread WHERE id = $3)
+			WHEN 'message' THEN EXISTS (SELECT 1 FROM dm_message WHERE id = $3)
+			WHEN 'review'  THEN EXISTS (SELECT 1 FROM review WHERE id = $3)
+			ELSE true -- неизвестный тип отклонит CHECK
+		END
+		RETURNING id, status, created_at
+	`, rep.ReporterID, rep.TargetType, rep.TargetID, rep.Reason).Scan(&rep.ID, &rep.Status, &rep.CreatedAt)
+	return rep, err
+}
+
+const reportColumns = `id, reporter_id, target_type, target_id, reason, status, resolved_by, resolved_at, created_at`
+
+func scanReport(row pgx.Row) (models.AbuseReport, error) {
+	var rep models.AbuseReport
+	err := row.Scan(&rep.ID, &rep.ReporterID, &rep.TargetType, &rep.TargetID, &rep.Reason,
+		&rep.Status, &rep.ResolvedBy, &rep.ResolvedAt, &rep.CreatedAt)
+	return rep, err
+}
+
+func (r *reportsRepo) List(ctx context.Context, status string, limit int) ([]models.AbuseReport, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+reportColumns+`
+		FROM abuse_report
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at, id
+		LIMIT $2
+	`, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.AbuseReport
+	for rows.Next() {
+		rep, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rep)
+	}
+	return out, rows.Err()
+}
+
+func (r *reportsRepo) Resolve(ctx context.Context, id uuid.UUID, status string, moderatorID uuid.UUID) (models.AbuseReport, error) {
+	return scanReport(r.db.QueryRow(ctx, `
+		UPDATE abuse_report SET status = $2, resolved_by = $3, resolved_at = now()
+		WHERE id = $1
+		RETURNING `+reportColumns, id, status, moderatorID))
+}
@@ -0,0 +1,70 @@
+// Package tracing — OpenTelemetry: провайдер трейсов, экспорт (OTLP или stdout) и спаны
+// запросов к Postgres. HTTP-спаны — otelmux/otelhttp, спаны LLM — ai.Instrument.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName — имя сервиса в трейсах (переопределяется OTEL_SERVICE_NAME).
+const ServiceName = "marketplace"
+
+// Tracer — трейсер для спанов приложения (scope — пакет, где спан начат).
+func Tracer(scope string) trace.Tracer {
+	return otel.Tracer("github.com/btynybekov/marketplace/" + scope)
+}
+
+// Setup — глобальный TracerProvider и пропагация W3C traceparent/baggage.
+// exporter: "otlp" — OTLP/HTTP (адрес и заголовки — стандартные OTEL_EXPORTER_OTLP_*),
+// "stdout" — спаны JSON-строками в stdout (локальный запуск), "" или "none" — трейсинг выключен.
+// Возвращает shutdown: дослать буфер спанов при остановке.
+func Setup(ctx context.Context, exporter string, sampleRatio float64) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exp sdktrace.SpanExporter
+	switch exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exp, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q (otlp | stdout | none)", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %s exporter: %w", exporter, err)
+	}
+
+	// порядок важен: переменные окружения (OTEL_SERVICE_NAME, OTEL_RESOURCE_ATTRIBUTES) — поверх значений по умолчанию
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp, sdktrace.WithBatchTimeout(5*time.Second)),
+		sdktrace.WithResource(res),
+		// решение родителя (traceparent от клиента/прокси) уважаем, корневые — по доле
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,205 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btynybekov/marketplace/internal/httpheader"
+)
+
+// DefaultAssetsPath — путь, под которым раздаётся статика, если в AssetsBaseURL его нет.
+const DefaultAssetsPath = "/static"
+
+// immutable — кэш для URL с отпечатком: содержимое по такому адресу никогда не меняется.
+const immutable = "public, max-age=31536000, immutable"
+
+// asset — файл статики и его сжатые варианты.
+type asset struct {
+	ctype  string
+	hash   string // отпечаток содержимого (часть имени файла и ETag)
+	body   []byte
+	gzip   []byte // app.css.gz из FS или сжатый при загрузке
+	brotli []byte // только готовый app.css.br из FS (в стандартной библиотеке brotli нет)
+}
+
+// Assets — статика с отпечатками: логическое "css/app.css" раздаётся как "css/app.<hash>.css"
+// с immutable-кэшем; по логическому имени — тоже, но с ревалидацией по ETag.
+// Между инстансами и деплоями отпечаток стабилен: он зависит только от содержимого.
+type Assets struct {
+	baseURL string            // AssetsBaseURL без завершающего "/" (путь или CDN)
+	mount   string            // путь на этом сервере
+	byName  map[string]*asset // логическое имя → файл
+	byPath  map[string]*asset // имя с отпечатком → файл
+}
+
+// LoadAssets — читает всю статику из fsys (обычно static.FS) и считает отпечатки.
+// baseURL — AssetsBaseURL: "/static" или CDN ("https://cdn.example.com/static"), который
+// тянет файлы с этого же сервера по тому же пути.
+func LoadAssets(fsys fs.FS, baseURL string) (*Assets, error) {
+	a := &Assets{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		mount:   MountPath(baseURL),
+		byName:  map[string]*asset{},
+		byPath:  map[string]*asset{},
+	}
+	variants := map[string][]byte{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasSuffix(name, ".go") {
+			return err
+		}
+		body, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if ext := path.Ext(name); ext == ".gz" || ext == ".br" {
+			variants[name] = body
+			return nil
+		}
+		sum := sha256.Sum256(body)
+		a.byName[name] = &asset{
+			ctype: mime.TypeByExtension(path.Ext(name)),
+			hash:  hex.EncodeToString(sum[:8]),
+			body:  body,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name, f := range a.byName {
+		f.brotli = variants[name+".br"]
+		f.gzip = variants[name+".gz"]
+		if f.gzip == nil && compressible(f.ctype) {
+			if f.gzip, err = gzipBytes(f.body); err != nil {
+				return nil, err
+			}
+		}
+		// сжатие, которое не уменьшило файл, не отдаём
+		if len(f.gzip) >= len(f.body) {
+			f.gzip = nil
+		}
+		if len(f.brotli) >= len(f.body) {
+			f.brotli = nil
+		}
+		a.byPath[fingerprinted(name, f.hash)] = f
+	}
+	return a, nil
+}
+
+// MountPath — путь раздачи статики по AssetsBaseURL: путь из URL (для CDN — путь origin-а).
+func MountPath(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || strings.Trim(u.Path, "/") == "" {
+		return DefaultAssetsPath
+	}
+	return "/" + strings.Trim(u.Path, "/")
+}
+
+// Mount — путь, под которым Assets нужно повесить на роутер (с http.StripPrefix).
+func (a *Assets) Mount() string { return a.mount }
+
+// URL — адрес файла с отпечатком для шаблонов ({{asset "css/app.css"}}).
+// Неизвестный файл — адрес без отпечатка (404 увидим в логах браузера, а не падением страницы).
+func (a *Assets) URL(name string) string {
+	name = strings.TrimLeft(name, "/")
+	if a == nil {
+		return DefaultAssetsPath + "/" + name
+	}
+	if f, ok := a.byName[name]; ok {
+		return a.baseURL + "/" + fingerprinted(name, f.hash)
+	}
+	return a.baseURL + "/" + name
+}
+
+// ServeHTTP — GET/HEAD файла (путь — без Mount): ETag и 304, Range, br/gzip по Accept-Encoding.
+func (a *Assets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	cache := immutable
+	f, ok := a.byPath[name]
+	if !ok {
+		// старые ссылки и файлы, на которые шаблоны не ссылаются (favicon и т. п.)
+		if f, ok = a.byName[name]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		cache = "no-cache"
+	}
+
+	h := w.Header()
+	h.Set("Cache-Control", cache)
+	h.Set("X-Content-Type-Options", "nosniff")
+	if f.ctype != "" {
+		h.Set("Content-Type", f.ctype)
+	}
+	body, etag := f.body, f.hash
+	if f.gzip != nil || f.brotli != nil {
+		h.Add("Vary", "Accept-Encoding")
+		ae := r.Header.Get("Accept-Encoding")
+		switch {
+		case f.brotli != nil && acceptsEncoding(ae, "br"):
+			body, etag = f.brotli, f.hash+"-br"
+			h.Set("Content-Encoding", "br")
+		case f.gzip != nil && acceptsEncoding(ae, "gzip"):
+			body, etag = f.gzip, f.hash+"-gz"
+			h.Set("Content-Encoding", "gzip")
+		}
+	}
+	// ETag у каждого варианта свой: это разные представления одного файла
+	h.Set("ETag", strconv.Quote(etag))
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+// fingerprinted — css/app.css → css/app.<hash>.css
+func fingerprinted(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+func compressible(ctype string) bool {
+	mt, _, _ := strings.Cut(ctype, ";")
+	switch mt {
+	case "application/javascript", "text/javascript", "application/json", "image/svg+xml", "application/manifest+json":
+		return true
+	}
+	return strings.HasPrefix(mt, "text/")
+}
+
+func gzipBytes(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// acceptsEncoding — разрешает ли Accept-Encoding кодировку enc (явно или через "*"; q=0 — запрет).
+func acceptsEncoding(header, enc string) bool {
+	ok := false
+	for _, it := range httpheader.ParseList(header) {
+		switch it.Value {
+		case enc:
+			return it.Q > 0 // явное упоминание важнее "*"
+		case "*":
+			ok = it.Q > 0
+		}
+	}
+	return ok
+}
@@ -0,0 +1,70 @@
+package web
+
+import (
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/btynybekov/marketplace/internal/models"
+)
+
+// currencySigns — как подписывать суммы; неизвестные валюты — ISO-кодом.
+var currencySigns = map[string]string{
+	"KGS": "сом",
+	"USD": "$",
+	"EUR": "€",
+	"RUB": "₽",
+	"KZT": "₸",
+}
+
+// Funcs — функции шаблонов:
+//
+//	price  — цена объявления (models.Product): {{price .}}
+//	money  — сумма с валютой: {{money .Amount .CurrencyCode}}
+//	asset  — URL файла статики с отпечатком (или на CDN): {{asset "css/app.css"}}
+func Funcs(assets *Assets) template.FuncMap {
+	return template.FuncMap{
+		"price": Price,
+		"money": FormatMoney,
+		"asset": assets.URL,
+	}
+}
+
+// Price — цена объявления в валюте отображения, если она посчитана, иначе — в валюте продавца.
+func Price(p models.Product) string {
+	if p.DisplayPrice != nil {
+		return FormatMoney(p.DisplayPrice.Amount, p.DisplayPrice.CurrencyCode)
+	}
+	return FormatMoney(p.PriceAmount, p.CurrencyCode)
+}
+
+// FormatMoney — "12 500 сом", "1 299,50 $": разряды через неразрывный пробел,
+// дробная часть — только если она есть.
+func FormatMoney(amount float64, code string) string {
+	cents := int64(math.Round(math.Abs(amount) * 100))
+	digits := strconv.FormatInt(cents/100, 10)
+
+	var b strings.Builder
+	if amount < 0 && cents != 0 {
+		b.WriteByte('-')
+	}
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(" ")
+		}
+		b.WriteRune(d)
+	}
+	if frac := cents % 100; frac != 0 {
+		b.WriteString("," + strconv.FormatInt(frac/10, 10) + strconv.FormatInt(frac%10, 10))
+	}
+
+	sign := currencySigns[strings.ToUpper(code)]
+	if sign == "" {
+		sign = strings.ToUpper(code)
+	}
+	if sign != "" {
+		b.WriteString(" " + sign)
+	}
+	return b.String()
+}
@@ -0,0 +1,98 @@
+// Package web — загрузка HTML-шаблонов сайта: общий layout, partials и страницы.
+package web
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+)
+
+const (
+	layoutsGlob  = "layouts/*.html"
+	partialsGlob = "partials/*.html"
+	pagesDir     = "pages"
+	rootTemplate = "base" // layout: подставляет блоки "title", "content", "scripts" страницы
+)
+
+// Options — настройки загрузчика.
+type Options struct {
+	Assets *Assets // статика для функции asset (nil — URL без отпечатков под DefaultAssetsPath)
+	Dir    string  // dev-режим: читать шаблоны из этого каталога при каждом рендере (горячая перезагрузка)
+}
+
+// Templates — страницы сайта. У каждой страницы свой набор layout + partials + страница:
+// блоки "title"/"content" разных страниц в одном наборе перекрывали бы друг друга.
+type Templates struct {
+	fsys   fs.FS
+	funcs  template.FuncMap
+	pages  map[string]*template.Template // "homepage.html" → набор
+	reload bool
+}
+
+// Load — разбирает все страницы из fsys (обычно templates.FS); с opts.Dir — из каталога на диске.
+// Ошибка в любом шаблоне — ошибка загрузки: битая страница не должна всплыть только в проде.
+func Load(fsys fs.FS, opts Options) (*Templates, error) {
+	t := &Templates{fsys: fsys, funcs: Funcs(opts.Assets), pages: map[string]*template.Template{}}
+	if opts.Dir != "" {
+		t.fsys, t.reload = os.DirFS(opts.Dir), true
+	}
+
+	files, err := fs.Glob(t.fsys, pagesDir+"/*.html")
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("templates: no pages found")
+	}
+	for _, file := range files {
+		set, err := t.parse(path.Base(file))
+		if err != nil {
+			return nil, err
+		}
+		t.pages[path.Base(file)] = set
+	}
+	return t, nil
+}
+
+func (t *Templates) parse(page string) (*template.Template, error) {
+	set, err := template.New(page).Funcs(t.funcs).ParseFS(t.fsys, layoutsGlob, partialsGlob, path.Join(pagesDir, page))
+	if err != nil {
+		return nil, fmt.Errorf("templates: %s: %w", page, err)
+	}
+	if set.Lookup(rootTemplate) == nil {
+		return nil, fmt.Errorf("templates: %s: layout %q is not defined", page, rootTemplate)
+	}
+	return set, nil
+}
+
+// Has — есть ли страница name (nil-набор — ни одной: страницы отдаются только в JSON).
+func (t *Templates) Has(name string) bool {
+	return t != nil && t.pages[name] != nil
+}
+
+// Execute — рендерит страницу name в layout. Результат буферизуется:
+// при ошибке шаблона клиент получит ошибку, а не оборванный HTML.
+func (t *Templates) Execute(w io.Writer, name string, data any) error {
+	set := t.pages[name]
+	if set == nil {
+		return fmt.Errorf("templates: page %s not found", name)
+	}
+	if t.reload {
+		var err error
+		if set, err = t.parse(name); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := set.ExecuteTemplate(&buf, rootTemplate, data); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -2,98 +2,123 @@ package main
 
 import (
 	"context"
-	"html/template"
-	"log"
-	"net"
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/gorilla/mux"
-
-	// 👇 проверь пути: они должны совпадать со структурой твоего проекта
-	"github.com/btynybekov/marketplace/config"      // если у тебя internal/config — замени импорт
-	"github.com/btynybekov/marketplace/internal/ai" // <-- ВАЖНО: ai в internal/ai
-	"github.com/btynybekov/marketplace/internal/handlers/factory"
+	"github.com/btynybekov/marketplace/config"
+	"github.com/btynybekov/marketplace/internal/app"
+	"github.com/btynybekov/marketplace/internal/logging"
 	"github.com/btynybekov/marketplace/internal/repository"
-	"github.com/btynybekov/marketplace/storage" // если у тебя internal/storage — замени импорт
+	"github.com/btynybekov/marketplace/internal/repository/fixtures"
+	"github.com/btynybekov/marketplace/internal/repository/memory"
+	"github.com/btynybekov/marketplace/internal/tracing"
 )
 
 func main() {
-	// 1) Конфиг
-	cfg := config.Load()
+	// `marketplace config print [флаги]` — итоговая конфигурация без секретов
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		os.Exit(printConfig(os.Args[3:]))
+	}
 
-	// 2) БД
-	ctx := context.Background()
-	db, err := storage.New(ctx, cfg.DatabaseURL)
+	// 1) Конфиг (config.yaml ← env ← флаги) и логи (JSON в stdout, уровень — LOG_LEVEL / DEBUG)
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		usage()
+		return
+	}
 	if err != nil {
-		log.Fatalf("DB connect error: %v", err)
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(2)
 	}
-	defer db.Close()
-
-	// 3) Репозитории
-	repos := repository.New(db.Pool)
+	logging.Setup(cfg.LogLevel, cfg.DebugMode)
 
-	// 4) AI-клиент (openai | local)
-	var aiClient ai.Client
-	switch cfg.AIProvider {
-	case "local":
-		headers := map[string]string{}
-		if cfg.LocalAIKey != "" {
-			headers["X-API-Key"] = cfg.LocalAIKey
-		}
-		aiClient = ai.NewLocal(cfg.LocalAIURL, headers)
-	default:
-		aiClient = ai.NewOpenAI(cfg.OpenAIKey)
+	if err := run(cfg); err != nil {
+		slog.Error("server failed", logging.Err(err))
+		os.Exit(1)
 	}
+}
 
-	// 5) Шаблоны (если нужны HTML-страницы)
-	var tmpl *template.Template
-	// tmpl = factory.MustParseTemplates("web/templates/*.html")
-
-	// 6) Фабрика хендлеров (сюда ПЕРЕДАЁМ aiClient)
-	hf := factory.NewHandlersFactory(repos, tmpl, cfg, aiClient)
-
-	// 7) Роутер и регистрация маршрутов
-	r := mux.NewRouter()
-
-	// Если нужен API-префикс и/или внешние middleware — раскомментируй:
-	// api := r.PathPrefix("/api/v1").Subrouter()
-	// api.Use(AuthMiddleware)
-	// hf.RegisterRoutes(api)
-	// А если префикс не нужен:
-	hf.RegisterRoutes(r)
+// run — трейсинг, сборка приложения (app.New) и сервер до SIGINT/SIGTERM.
+// Ошибка возвращается, а не завершает процесс: отложенные закрытия (БД, трейсы) выполняются.
+func run(cfg config.EnvConfig) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// 8) HTTP-сервер + graceful shutdown
-	srv := &http.Server{
-		Addr:              ":" + cfg.PORT,
-		Handler:           r,
-		ReadHeaderTimeout: 5 * time.Second,
+	// 2) Трейсинг (TRACING_EXPORTER)
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingExporter, cfg.TracingSampleRatio)
+	if err != nil {
+		return fmt.Errorf("tracing setup: %w", err)
 	}
+	defer func() {
+		// дослать буфер спанов: отдельный таймаут, ctx к этому моменту отменён
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(sctx); err != nil {
+			slog.Error("tracing shutdown failed", logging.Err(err))
+		}
+	}()
 
-	ln, err := net.Listen("tcp", srv.Addr)
+	// 3) БД (или данные в памяти в -demo), репозитории, AI-клиент, хендлеры, фоновые задачи
+	var opts app.Options
+	if cfg.Demo {
+		repos, err := demoRepos(cfg.DemoFixtures)
+		if err != nil {
+			return fmt.Errorf("demo data: %w", err)
+		}
+		opts.Repos = repos
+		slog.Warn("demo mode: storage is in memory, changes are lost on restart", "fixtures", cfg.DemoFixtures)
+	}
+	a, err := app.New(ctx, cfg, opts)
 	if err != nil {
-		log.Fatalf("listen error: %v", err)
+		return err
 	}
+	defer a.Close()
 
-	go func() {
-		log.Printf("Server listening on %s (env=%s)", srv.Addr, cfg.AppEnv)
-		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("http serve error: %v", err)
-		}
-	}()
+	// 4) HTTP-сервер + graceful shutdown с drain
+	return a.Run(ctx)
+}
 
-	// ожидание сигнала для мягкой остановки
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-	<-stop
+// demoRepos — хранилище демо-режима: встроенный каталог или JSON-файл path.
+func demoRepos(path string) (repository.RepositorySet, error) {
+	d, err := fixtures.Demo()
+	if path != "" {
+		d, err = fixtures.Load(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return memory.New(d)
+}
 
-	ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctxShutdown); err != nil {
-		log.Printf("server shutdown error: %v", err)
+// printConfig — `config print`: конфигурация в формате config.yaml (секреты скрыты) и ошибки проверки.
+func printConfig(args []string) int {
+	l, err := config.LoadLayers(args)
+	if l == nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
 	}
-	log.Println("Server gracefully stopped")
+	if l.File != "" {
+		fmt.Printf("# file: %s\n", l.File)
+	}
+	if perr := l.Print(os.Stdout); perr != nil {
+		fmt.Fprintln(os.Stderr, perr)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// usage — справка по флагам (-h).
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: marketplace [flags]\n       marketplace config print [flags]\n\nflags (override "+config.DefaultFile+" and env):")
+	config.PrintDefaults(os.Stderr)
 }
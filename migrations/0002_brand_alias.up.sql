@@ -0,0 +1,34 @@
+BEGIN;
+
+-- Алиасы брендов для нечёткого поиска ("айфон" → Apple)
+CREATE TABLE IF NOT EXISTS brand_alias (
+  id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  brand_id      UUID NOT NULL REFERENCES brand(id) ON DELETE CASCADE,
+  alias         TEXT NOT NULL,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_brand_alias_brand ON brand_alias(brand_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_brand_alias_lower ON brand_alias(lower(alias));
+
+-- Базовые бренды, которые чаще всего пишут по-русски
+INSERT INTO brand (name, slug) VALUES
+  ('Apple', 'apple'),
+  ('Samsung', 'samsung'),
+  ('Xiaomi', 'xiaomi'),
+  ('Huawei', 'huawei'),
+  ('Toyota', 'toyota')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO brand_alias (brand_id, alias)
+SELECT b.id, a.alias
+FROM (VALUES
+  ('apple', 'айфон'), ('apple', 'iphone'), ('apple', 'эпл'), ('apple', 'эппл'), ('apple', 'макбук'), ('apple', 'macbook'),
+  ('samsung', 'самсунг'), ('samsung', 'галакси'), ('samsung', 'galaxy'),
+  ('xiaomi', 'сяоми'), ('xiaomi', 'ксиоми'), ('xiaomi', 'редми'), ('xiaomi', 'redmi'),
+  ('huawei', 'хуавей'), ('huawei', 'хуавэй'),
+  ('toyota', 'тойота')
+) AS a(slug, alias)
+JOIN brand b ON b.slug = a.slug
+ON CONFLICT DO NOTHING;
+
+COMMIT;
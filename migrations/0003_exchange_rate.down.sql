@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX IF EXISTS idx_listing_price_kgs;
+ALTER TABLE listing DROP COLUMN IF EXISTS price_kgs;
+DROP TABLE IF EXISTS exchange_rate;
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Курсы валют к KGS (сколько сомов за 1 единицу валюты)
+CREATE TABLE IF NOT EXISTS exchange_rate (
+  currency_code CHAR(3) PRIMARY KEY,
+  rate_to_kgs   NUMERIC(18,8) NOT NULL CHECK (rate_to_kgs > 0),
+  source        TEXT NOT NULL DEFAULT 'manual',
+  updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+INSERT INTO exchange_rate (currency_code, rate_to_kgs, source)
+VALUES ('KGS', 1, 'builtin')
+ON CONFLICT DO NOTHING;
+
+-- Цена объявления, приведённая к KGS: для фильтров "до 15000 сом" и сортировки по цене
+ALTER TABLE listing ADD COLUMN IF NOT EXISTS price_kgs NUMERIC(14,2);
+UPDATE listing l
+SET price_kgs = round(l.price_amount * r.rate_to_kgs, 2)
+FROM exchange_rate r
+WHERE r.currency_code = l.currency_code;
+CREATE INDEX IF NOT EXISTS idx_listing_price_kgs ON listing(status, price_kgs);
+
+COMMIT;
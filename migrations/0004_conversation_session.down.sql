@@ -0,0 +1,7 @@
+BEGIN;
+DROP INDEX IF EXISTS idx_listing_status_created;
+DROP INDEX IF EXISTS idx_message_conv_created_id;
+CREATE INDEX IF NOT EXISTS idx_message_conv_created ON message(conversation_id, created_at);
+DROP INDEX IF EXISTS uniq_conversation_session;
+ALTER TABLE conversation DROP COLUMN IF EXISTS session_id;
+COMMIT;
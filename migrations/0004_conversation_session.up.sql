@@ -0,0 +1,14 @@
+BEGIN;
+
+-- Внешний идентификатор сессии чата (фронт хранит его у себя)
+ALTER TABLE conversation ADD COLUMN IF NOT EXISTS session_id TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_conversation_session ON conversation(session_id);
+
+-- Keyset-пагинация истории: (created_at, id)
+DROP INDEX IF EXISTS idx_message_conv_created;
+CREATE INDEX IF NOT EXISTS idx_message_conv_created_id ON message(conversation_id, created_at DESC, id DESC);
+
+-- Keyset-пагинация выдачи объявлений
+CREATE INDEX IF NOT EXISTS idx_listing_status_created ON listing(status, created_at DESC, id DESC);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP TABLE IF EXISTS notification;
+DROP TABLE IF EXISTS saved_search;
+DROP TABLE IF EXISTS favorite;
+COMMIT;
@@ -0,0 +1,35 @@
+BEGIN;
+
+-- Избранные объявления
+CREATE TABLE IF NOT EXISTS favorite (
+  user_id       UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  listing_id    UUID NOT NULL REFERENCES listing(id) ON DELETE CASCADE,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, listing_id)
+);
+
+-- Сохранённые поиски (requirements — тот же формат, что у buyer-ассистента)
+CREATE TABLE IF NOT EXISTS saved_search (
+  id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id       UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  name          TEXT NOT NULL,
+  requirements  JSONB NOT NULL,
+  last_run_at   TIMESTAMPTZ,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_saved_search_user ON saved_search(user_id);
+CREATE INDEX IF NOT EXISTS idx_saved_search_last_run ON saved_search(last_run_at NULLS FIRST);
+
+-- Outbox уведомлений: ассистент/клиенты забирают недоставленные
+CREATE TABLE IF NOT EXISTS notification (
+  id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id       UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  kind          TEXT NOT NULL,
+  text          TEXT NOT NULL,
+  payload       JSONB NOT NULL DEFAULT '{}'::jsonb,
+  delivered_at  TIMESTAMPTZ,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_notification_pending ON notification(user_id, created_at) WHERE delivered_at IS NULL;
+
+COMMIT;
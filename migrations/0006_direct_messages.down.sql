@@ -0,0 +1,6 @@
+BEGIN;
+DROP TABLE IF EXISTS abuse_report;
+DROP TABLE IF EXISTS user_block;
+DROP TABLE IF EXISTS dm_message;
+DROP TABLE IF EXISTS dm_thread;
+COMMIT;
@@ -0,0 +1,49 @@
+BEGIN;
+
+-- Переписка покупатель ↔ продавец по объявлению
+CREATE TABLE IF NOT EXISTS dm_thread (
+  id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  listing_id      UUID NOT NULL REFERENCES listing(id) ON DELETE CASCADE,
+  buyer_id        UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  seller_id       UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  last_message_at TIMESTAMPTZ,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (listing_id, buyer_id),
+  CHECK (buyer_id <> seller_id)
+);
+CREATE INDEX IF NOT EXISTS idx_dm_thread_buyer ON dm_thread(buyer_id, last_message_at DESC);
+CREATE INDEX IF NOT EXISTS idx_dm_thread_seller ON dm_thread(seller_id, last_message_at DESC);
+
+CREATE TABLE IF NOT EXISTS dm_message (
+  id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  thread_id       UUID NOT NULL REFERENCES dm_thread(id) ON DELETE CASCADE,
+  sender_id       UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  body            TEXT NOT NULL,
+  read_at         TIMESTAMPTZ,                 -- прочитано получателем
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_dm_message_thread ON dm_message(thread_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_dm_message_unread ON dm_message(thread_id, sender_id) WHERE read_at IS NULL;
+
+-- Блокировки пользователей
+CREATE TABLE IF NOT EXISTS user_block (
+  blocker_id      UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  blocked_id      UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (blocker_id, blocked_id)
+);
+
+-- Жалобы (на пользователя, объявление, переписку, сообщение)
+CREATE TABLE IF NOT EXISTS abuse_report (
+  id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  reporter_id     UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  target_type     TEXT NOT NULL CHECK (target_type IN ('user','listing','thread','message')),
+  target_id       UUID NOT NULL,
+  reason          TEXT NOT NULL,
+  status          TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open','resolved','rejected')),
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_abuse_report_status ON abuse_report(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_abuse_report_target ON abuse_report(target_type, target_id);
+
+COMMIT;
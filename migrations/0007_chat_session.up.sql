@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Серверные сессии чата: случайный ID, TTL со скользящим продлением, привязка к пользователю
+CREATE TABLE IF NOT EXISTS chat_session (
+  id            TEXT PRIMARY KEY,
+  user_id       UUID REFERENCES app_user(id) ON DELETE CASCADE,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_seen_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at    TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_chat_session_user ON chat_session(user_id);
+CREATE INDEX IF NOT EXISTS idx_chat_session_expires ON chat_session(expires_at);
+
+-- Старые ID (sess-YYYYMMDDhhmmss) угадываются по времени, поэтому переносятся уже истёкшими:
+-- история остаётся в conversation, продолжить разговор по старому ID нельзя
+INSERT INTO chat_session (id, user_id, created_at, last_seen_at, expires_at)
+SELECT session_id, user_id, created_at, COALESCE(last_message_at, created_at), now()
+FROM conversation
+WHERE session_id IS NOT NULL
+ON CONFLICT (id) DO NOTHING;
+
+COMMIT;
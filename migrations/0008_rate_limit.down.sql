@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS ai_usage_daily;
+DROP TABLE IF EXISTS rate_limit_bucket;
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- Бакеты rate limit (GCRA: tat — "теоретическое время прибытия" следующего запроса)
+CREATE TABLE IF NOT EXISTS rate_limit_bucket (
+  key           TEXT PRIMARY KEY,
+  tat           TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_bucket_tat ON rate_limit_bucket(tat);
+
+-- Суточный расход LLM-токенов (subject: "user:<uuid>" | "ip:<addr>")
+CREATE TABLE IF NOT EXISTS ai_usage_daily (
+  subject       TEXT NOT NULL,
+  day           DATE NOT NULL,
+  tokens        BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (subject, day)
+);
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+DROP TRIGGER IF EXISTS trg_app_user_default_roles ON app_user;
+DROP FUNCTION IF EXISTS grant_default_roles();
+ALTER TABLE abuse_report DROP COLUMN IF EXISTS resolved_at;
+ALTER TABLE abuse_report DROP COLUMN IF EXISTS resolved_by;
+DROP TABLE IF EXISTS user_role;
+DROP TABLE IF EXISTS role_permission;
+DROP TABLE IF EXISTS permission;
+DROP TABLE IF EXISTS role;
+COMMIT;
@@ -0,0 +1,82 @@
+BEGIN;
+
+-- Роли и права
+CREATE TABLE IF NOT EXISTS role (
+  id            TEXT PRIMARY KEY,
+  description   TEXT NOT NULL,
+  is_default    BOOLEAN NOT NULL DEFAULT FALSE -- выдаётся каждому новому пользователю
+);
+
+CREATE TABLE IF NOT EXISTS permission (
+  id            TEXT PRIMARY KEY,
+  description   TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS role_permission (
+  role_id       TEXT NOT NULL REFERENCES role(id) ON DELETE CASCADE,
+  permission_id TEXT NOT NULL REFERENCES permission(id) ON DELETE CASCADE,
+  PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_role (
+  user_id       UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  role_id       TEXT NOT NULL REFERENCES role(id) ON DELETE CASCADE,
+  granted_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, role_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_role_role ON user_role(role_id);
+
+-- Аудит модерации жалоб
+ALTER TABLE abuse_report ADD COLUMN IF NOT EXISTS resolved_by UUID REFERENCES app_user(id) ON DELETE SET NULL;
+ALTER TABLE abuse_report ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMPTZ;
+
+INSERT INTO role (id, description, is_default) VALUES
+  ('buyer',     'Покупатель', TRUE),
+  ('seller',    'Продавец', TRUE),
+  ('moderator', 'Модератор: жалобы и объявления', FALSE),
+  ('admin',     'Администратор', FALSE)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO permission (id, description) VALUES
+  ('admin.access',      'Доступ к /admin'),
+  ('listings.create',   'Публикация объявлений'),
+  ('listings.edit.own', 'Редактирование своих объявлений'),
+  ('listings.edit.any', 'Редактирование и снятие любых объявлений'),
+  ('brands.manage',     'Управление брендами'),
+  ('reports.review',    'Разбор жалоб'),
+  ('roles.manage',      'Выдача и отзыв ролей')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permission (role_id, permission_id) VALUES
+  ('seller',    'listings.create'),
+  ('seller',    'listings.edit.own'),
+  ('moderator', 'admin.access'),
+  ('moderator', 'listings.edit.any'),
+  ('moderator', 'reports.review'),
+  ('admin',     'admin.access'),
+  ('admin',     'listings.edit.any'),
+  ('admin',     'brands.manage'),
+  ('admin',     'reports.review'),
+  ('admin',     'roles.manage')
+ON CONFLICT DO NOTHING;
+
+-- Роли по умолчанию: существующим пользователям и каждому новому
+INSERT INTO user_role (user_id, role_id)
+SELECT u.id, r.id FROM app_user u CROSS JOIN role r WHERE r.is_default
+ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION grant_default_roles() RETURNS trigger AS $$
+BEGIN
+  INSERT INTO user_role (user_id, role_id)
+  SELECT NEW.id, id FROM role WHERE is_default
+  ON CONFLICT DO NOTHING;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_app_user_default_roles ON app_user;
+CREATE TRIGGER trg_app_user_default_roles
+  AFTER INSERT ON app_user
+  FOR EACH ROW EXECUTE FUNCTION grant_default_roles();
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+DELETE FROM abuse_report WHERE target_type = 'review';
+ALTER TABLE abuse_report DROP CONSTRAINT IF EXISTS abuse_report_target_type_check;
+ALTER TABLE abuse_report ADD CONSTRAINT abuse_report_target_type_check
+  CHECK (target_type IN ('user','listing','thread','message'));
+DROP TABLE IF EXISTS review;
+ALTER TABLE listing DROP CONSTRAINT IF EXISTS uniq_listing_seller_buyer;
+ALTER TABLE listing DROP CONSTRAINT IF EXISTS listing_buyer_check;
+ALTER TABLE listing DROP COLUMN IF EXISTS buyer_id;
+DROP INDEX IF EXISTS idx_listing_seller;
+ALTER TABLE app_user DROP COLUMN IF EXISTS contact_prefs;
+ALTER TABLE app_user DROP COLUMN IF EXISTS location_text;
+ALTER TABLE app_user DROP COLUMN IF EXISTS avatar_url;
+COMMIT;
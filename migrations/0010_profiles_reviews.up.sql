@@ -0,0 +1,41 @@
+BEGIN;
+
+-- Профиль пользователя
+ALTER TABLE app_user ADD COLUMN IF NOT EXISTS avatar_url    TEXT;
+ALTER TABLE app_user ADD COLUMN IF NOT EXISTS location_text TEXT;
+ALTER TABLE app_user ADD COLUMN IF NOT EXISTS contact_prefs JSONB NOT NULL DEFAULT '{"allow_messages": true}'::jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_listing_seller ON listing(seller_id, status, created_at DESC, id DESC);
+
+-- Покупатель проданного объявления: его указывает продавец при переводе в sold
+ALTER TABLE listing ADD COLUMN IF NOT EXISTS buyer_id UUID REFERENCES app_user(id) ON DELETE SET NULL;
+ALTER TABLE listing DROP CONSTRAINT IF EXISTS listing_buyer_check;
+ALTER TABLE listing ADD CONSTRAINT listing_buyer_check
+  CHECK (buyer_id IS NULL OR (buyer_id <> seller_id AND status IN ('sold','deleted')));
+ALTER TABLE listing DROP CONSTRAINT IF EXISTS uniq_listing_seller_buyer;
+ALTER TABLE listing ADD CONSTRAINT uniq_listing_seller_buyer UNIQUE (id, seller_id, buyer_id);
+
+-- Отзывы покупателей о продавцах: один отзыв на проданное объявление, только от его покупателя
+-- и только о его продавце (review_listing_buyer_fkey; пока отзыв есть, покупателя не сменить)
+CREATE TABLE IF NOT EXISTS review (
+  id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  listing_id      UUID NOT NULL REFERENCES listing(id) ON DELETE CASCADE,
+  reviewer_id     UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  seller_id       UUID NOT NULL REFERENCES app_user(id) ON DELETE CASCADE,
+  rating          SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  body            TEXT NOT NULL DEFAULT '',
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (listing_id, reviewer_id),
+  CHECK (reviewer_id <> seller_id),
+  CONSTRAINT review_listing_buyer_fkey FOREIGN KEY (listing_id, seller_id, reviewer_id)
+    REFERENCES listing (id, seller_id, buyer_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_review_seller ON review(seller_id, created_at DESC, id DESC);
+
+-- На отзыв тоже можно пожаловаться
+ALTER TABLE abuse_report DROP CONSTRAINT IF EXISTS abuse_report_target_type_check;
+ALTER TABLE abuse_report ADD CONSTRAINT abuse_report_target_type_check
+  CHECK (target_type IN ('user','listing','thread','message','review'));
+
+COMMIT;
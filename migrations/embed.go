@@ -0,0 +1,12 @@
+// Package migrations — SQL-миграции схемы (формат golang-migrate: NNNN_name.up.sql / .down.sql).
+//
+// Применяются отдельно (`docker compose run --rm migrate up`); в бинарник встроены, чтобы
+// сервер знал, какую версию схемы он ожидает (см. health.Migrations).
+package migrations
+
+import "embed"
+
+// FS — встроенные файлы миграций.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,17 @@
+// Package static — статика сайта (CSS, JS), встроенная в бинарник.
+//
+// Раздаётся web.Assets по URL с отпечатком содержимого (css/app.<hash>.css).
+// Рядом с файлом можно положить сжатые варианты app.css.br / app.css.gz —
+// они отдаются клиентам с Accept-Encoding; gzip без готового файла сжимается при старте.
+// brotli в стандартной библиотеке нет, поэтому .br готовит сборка: `go generate ./static`
+// (нужна утилита brotli; Dockerfile делает это перед go build). Без этого шага — только gzip.
+package static
+
+import "embed"
+
+//go:generate sh -c "find css js -type f \\( -name '*.css' -o -name '*.js' -o -name '*.svg' \\) -exec brotli -kf -q 11 {} +"
+
+// FS — встроенные файлы статики.
+//
+//go:embed css js
+var FS embed.FS
@@ -0,0 +1,57 @@
+// API чата: POST /api/v1/chat/session → {session_id}; POST /api/v1/chat/ajax {session_id, text} → {reply}
+const chatAPI = '/api/v1/chat';
+const sessionKey = 'chat_session_id';
+const messagesDiv = document.getElementById('messages');
+const errorLine = document.getElementById('chat-error');
+
+async function api(method, path, body) {
+    const res = await fetch(chatAPI + path, {
+        method,
+        headers: { 'Content-Type': 'application/json', 'Accept': 'application/json' },
+        body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    const data = await res.json();
+    if (!res.ok) {
+        throw new Error(data.error || res.statusText);
+    }
+    return data;
+}
+
+function appendMessage(role, text) {
+    const p = document.createElement('p');
+    const who = document.createElement('b');
+    who.textContent = role === 'user' ? 'Вы: ' : 'AI: ';
+    p.append(who, text);
+    messagesDiv.append(p);
+    messagesDiv.scrollTop = messagesDiv.scrollHeight;
+}
+
+// startSession — новая сессия или продление сохранённой (истёкшую сервер заменит новой)
+async function startSession() {
+    const saved = localStorage.getItem(sessionKey);
+    const data = await api('POST', '/session', saved ? { session_id: saved } : {});
+    localStorage.setItem(sessionKey, data.session_id);
+    if (data.session_id === saved) {
+        const history = await api('GET', '/history?session_id=' + encodeURIComponent(saved));
+        history.messages.forEach(m => appendMessage(m.role, m.text));
+    }
+    return data.session_id;
+}
+
+const session = startSession();
+
+document.getElementById('chat-form').addEventListener('submit', async (e) => {
+    e.preventDefault();
+    const input = document.getElementById('user-input');
+    const text = input.value.trim();
+    if (!text) return;
+    errorLine.textContent = '';
+    appendMessage('user', text);
+    input.value = '';
+    try {
+        const data = await api('POST', '/ajax', { session_id: await session, text });
+        appendMessage('assistant', data.reply.text);
+    } catch (err) {
+        errorLine.textContent = err.message;
+    }
+});
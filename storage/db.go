@@ -2,44 +2,75 @@ package storage
 
 import (
 	"context"
-	"errors"
-	"os"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/btynybekov/marketplace/internal/logging"
+	"github.com/btynybekov/marketplace/internal/tracing"
 )
 
-type DB struct {
-	Pool *pgxpool.Pool
+// Options — параметры пула (config: database.*); нулевые поля — значения по умолчанию.
+type Options struct {
+	MaxConns          int
+	MinConns          int
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	ConnectTimeout    time.Duration // ping при подключении
+	SlowQuery         time.Duration // запросы дольше пишутся в лог как WARN (с request_id HTTP-запроса)
 }
 
-func NewFromEnv(ctx context.Context) (*DB, error) {
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		return nil, errors.New("DATABASE_URL is empty")
+// withDefaults — Options{} → прежние фиксированные значения.
+func (o Options) withDefaults() Options {
+	if o.MaxConns <= 0 {
+		o.MaxConns = 10
+	}
+	if o.MaxConnLifetime <= 0 {
+		o.MaxConnLifetime = 55 * time.Minute
+	}
+	if o.MaxConnIdleTime <= 0 {
+		o.MaxConnIdleTime = 10 * time.Minute
+	}
+	if o.HealthCheckPeriod <= 0 {
+		o.HealthCheckPeriod = 30 * time.Second
 	}
-	return New(ctx, dsn)
+	if o.ConnectTimeout <= 0 {
+		o.ConnectTimeout = 3 * time.Second
+	}
+	if o.SlowQuery <= 0 {
+		o.SlowQuery = 500 * time.Millisecond
+	}
+	return o
+}
+
+type DB struct {
+	Pool *pgxpool.Pool
 }
 
-func New(ctx context.Context, dsn string) (*DB, error) {
+func New(ctx context.Context, dsn string, opts Options) (*DB, error) {
 	cfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg.MinConns = 0
-	cfg.MaxConns = 10
-	cfg.MaxConnLifetime = 55 * time.Minute
-	cfg.MaxConnIdleTime = 10 * time.Minute
-	cfg.HealthCheckPeriod = 30 * time.Second
+	opts = opts.withDefaults()
+	cfg.MinConns = int32(opts.MinConns)
+	cfg.MaxConns = int32(opts.MaxConns)
+	cfg.MaxConnLifetime = opts.MaxConnLifetime
+	cfg.MaxConnIdleTime = opts.MaxConnIdleTime
+	cfg.HealthCheckPeriod = opts.HealthCheckPeriod
+	// логи запросов (с request_id) и спаны OpenTelemetry
+	cfg.ConnConfig.Tracer = multitracer.New(logging.QueryTracer{Slow: opts.SlowQuery}, tracing.QueryTracer{})
 
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	pctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	pctx, cancel := context.WithTimeout(ctx, opts.ConnectTimeout)
 	defer cancel()
 	if err := pool.Ping(pctx); err != nil {
 		pool.Close()
@@ -0,0 +1,13 @@
+// Package templates — HTML-шаблоны сайта, встроенные в бинарник.
+//
+// layouts/ — общий каркас страницы (шаблон "base"), partials/ — переиспользуемые куски
+// (шапка, карточки), pages/ — страницы: каждая определяет блоки "title" и "content"
+// (и при необходимости "scripts"). Загрузчик — internal/web.
+package templates
+
+import "embed"
+
+// FS — встроенные шаблоны (в dev-режиме вместо них читается каталог на диске, см. web.Options.Dir).
+//
+//go:embed layouts partials pages
+var FS embed.FS